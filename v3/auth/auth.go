@@ -0,0 +1,158 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"errors"
+	"net/http"
+
+	"github.com/Nigel2392/router/v3"
+	"github.com/Nigel2392/router/v3/request"
+)
+
+// Default errors returned by authenticators.
+var (
+	// ErrNoCredentials is returned when the request did not contain any credentials
+	// for the authenticator.
+	//
+	// When chaining authenticators, this error means the next one will be tried.
+	ErrNoCredentials = errors.New("auth: no credentials provided")
+
+	// ErrInvalidCredentials is returned when the request contained credentials,
+	// but they were not valid.
+	ErrInvalidCredentials = errors.New("auth: invalid credentials")
+)
+
+// Authenticator resolves the user for a request.
+//
+// If the request does not carry credentials for this authenticator,
+// ErrNoCredentials should be returned.
+type Authenticator interface {
+	Authenticate(r *request.Request) (request.User, error)
+}
+
+// Challenger can optionally be implemented by an authenticator.
+//
+// The challenge will be set as the WWW-Authenticate header when authentication fails.
+type Challenger interface {
+	Challenge() string
+}
+
+// AuthenticatorFunc is a function which implements the Authenticator interface.
+type AuthenticatorFunc func(r *request.Request) (request.User, error)
+
+// Authenticate calls the function.
+func (f AuthenticatorFunc) Authenticate(r *request.Request) (request.User, error) {
+	return f(r)
+}
+
+// Chain tries every authenticator in order.
+//
+// The first authenticator which does not return ErrNoCredentials decides the outcome.
+//
+// If none of the authenticators found credentials, ErrNoCredentials is returned.
+func Chain(authenticators ...Authenticator) Authenticator {
+	return &chain{authenticators: authenticators}
+}
+
+type chain struct {
+	authenticators []Authenticator
+}
+
+func (c *chain) Authenticate(r *request.Request) (request.User, error) {
+	for _, a := range c.authenticators {
+		var user, err = a.Authenticate(r)
+		if errors.Is(err, ErrNoCredentials) {
+			continue
+		}
+		return user, err
+	}
+	return nil, ErrNoCredentials
+}
+
+// Challenge returns the challenges of all authenticators in the chain.
+func (c *chain) Challenge() string {
+	var challenge string
+	for _, a := range c.authenticators {
+		if ch, ok := a.(Challenger); ok && ch.Challenge() != "" {
+			if challenge != "" {
+				challenge += ", "
+			}
+			challenge += ch.Challenge()
+		}
+	}
+	return challenge
+}
+
+// Middleware authenticates the request with the given authenticator.
+//
+// When authentication succeeds, the user will be set on r.User and r.Data.Request.User.
+//
+// Requests without credentials are passed on without a user,
+// this way the LoginRequiredMiddleware can decide what to do with them.
+//
+// When the credentials are invalid, onError is called.
+// If onError is nil, a 401 Unauthorized response is written.
+func Middleware(a Authenticator, onError func(r *request.Request, err error)) router.Middleware {
+	if a == nil {
+		panic("auth.Middleware: authenticator is nil")
+	}
+	return func(next router.Handler) router.Handler {
+		return router.HandleFunc(func(r *request.Request) {
+			var user, err = a.Authenticate(r)
+			switch {
+			case errors.Is(err, ErrNoCredentials):
+				next.ServeHTTP(r)
+				return
+			case err != nil || user == nil:
+				if err == nil {
+					err = ErrInvalidCredentials
+				}
+				if onError != nil {
+					onError(r, err)
+					return
+				}
+				Unauthorized(r, a)
+				return
+			}
+			SetUser(r, user)
+			next.ServeHTTP(r)
+		})
+	}
+}
+
+// Unauthorized writes a 401 Unauthorized response.
+//
+// If the authenticator implements the Challenger interface,
+// the WWW-Authenticate header will be set.
+func Unauthorized(r *request.Request, a Authenticator) {
+	var challenge string
+	if ch, ok := a.(Challenger); ok {
+		challenge = ch.Challenge()
+	}
+	r.Error(http.StatusUnauthorized, http.StatusText(http.StatusUnauthorized))
+	if challenge != "" {
+		r.Response.Header().Set("WWW-Authenticate", challenge)
+	}
+}
+
+// SetUser sets the user on the request, and on the template data.
+func SetUser(r *request.Request, user request.User) {
+	r.User = user
+	if r.Data == nil {
+		r.Data = request.NewTemplateData()
+	}
+	if r.Data.Request == nil {
+		r.Data.Request = &request.TemplateRequest{}
+	}
+	r.Data.Request.User = user
+}
+
+// CompareSecrets compares two secrets in constant time.
+//
+// Both secrets are hashed first, so the time taken does not leak their length.
+func CompareSecrets(given, expected string) bool {
+	var a = sha256.Sum256([]byte(given))
+	var b = sha256.Sum256([]byte(expected))
+	return subtle.ConstantTimeCompare(a[:], b[:]) == 1
+}
@@ -0,0 +1,341 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Nigel2392/router/v3"
+	"github.com/Nigel2392/router/v3/request"
+	"github.com/Nigel2392/router/v3/request/writer"
+)
+
+type testUser struct {
+	name string
+}
+
+func (u *testUser) IsAuthenticated() bool                     { return true }
+func (u *testUser) IsAdmin() bool                             { return false }
+func (u *testUser) HasPermissions(permissions ...string) bool { return false }
+
+// Session which records how often its token was renewed.
+type testSession struct {
+	values  map[string]any
+	renewed int
+}
+
+func newTestSession() *testSession {
+	return &testSession{values: make(map[string]any)}
+}
+
+func (s *testSession) Set(key string, value any) { s.values[key] = value }
+func (s *testSession) Get(key string) any        { return s.values[key] }
+func (s *testSession) Delete(key string)         { delete(s.values, key) }
+func (s *testSession) Destroy() error            { s.values = make(map[string]any); return nil }
+func (s *testSession) RenewToken() error         { s.renewed++; return nil }
+func (s *testSession) Exists(key string) bool {
+	var _, ok = s.values[key]
+	return ok
+}
+
+var alice = &testUser{name: "alice"}
+
+func newRequest(rq *http.Request) *request.Request {
+	return request.NewRequest(writer.NewClearable(httptest.NewRecorder()), rq, nil)
+}
+
+func basicRequest(username, password string) *request.Request {
+	var rq = httptest.NewRequest("GET", "/", nil)
+	rq.SetBasicAuth(username, password)
+	return newRequest(rq)
+}
+
+func headerRequest(key, value string) *request.Request {
+	var rq = httptest.NewRequest("GET", "/", nil)
+	rq.Header.Set(key, value)
+	return newRequest(rq)
+}
+
+func TestBasic(t *testing.T) {
+	var basic = BasicUsers("admin", map[string]string{"alice": "secret"}, func(username string) request.User {
+		return &testUser{name: username}
+	})
+	var tests = []struct {
+		name string
+		r    *request.Request
+		err  error
+	}{
+		{"valid", basicRequest("alice", "secret"), nil},
+		{"wrong password", basicRequest("alice", "wrong"), ErrInvalidCredentials},
+		{"unknown user", basicRequest("bob", ""), ErrInvalidCredentials},
+		{"no credentials", newRequest(httptest.NewRequest("GET", "/", nil)), ErrNoCredentials},
+		{"other scheme", headerRequest("Authorization", "Bearer token"), ErrNoCredentials},
+	}
+	for _, test := range tests {
+		var user, err = basic.Authenticate(test.r)
+		if !errors.Is(err, test.err) {
+			t.Errorf("%s: got error %v, want %v", test.name, err, test.err)
+			continue
+		}
+		if err == nil && user.(*testUser).name != "alice" {
+			t.Errorf("%s: got user %v", test.name, user)
+		}
+	}
+
+	if c := basic.Challenge(); c != `Basic realm="admin", charset="UTF-8"` {
+		t.Errorf("got challenge %q", c)
+	}
+	if c := (&Basic{}).Challenge(); c != `Basic realm="Restricted", charset="UTF-8"` {
+		t.Errorf("got default challenge %q", c)
+	}
+}
+
+func TestBearer(t *testing.T) {
+	var tests = []struct {
+		header string
+		token  string
+	}{
+		{"Bearer abc", "abc"},
+		{"bearer abc", "abc"},
+		{"BEARER  abc ", "abc"},
+		{"Bearer ", ""},
+		{"Bearerabc", ""},
+		{"Basic abc", ""},
+		{"", ""},
+	}
+	for _, test := range tests {
+		var token, ok = BearerToken(headerRequest("Authorization", test.header))
+		if token != test.token || ok != (test.token != "") {
+			t.Errorf("BearerToken(%q) = %q, %v, want %q", test.header, token, ok, test.token)
+		}
+	}
+
+	var bearer = &Bearer{Validate: func(r *request.Request, token string) (request.User, error) {
+		if token != "abc" {
+			return nil, ErrInvalidCredentials
+		}
+		return alice, nil
+	}}
+	if user, err := bearer.Authenticate(headerRequest("Authorization", "Bearer abc")); err != nil || user != alice {
+		t.Errorf("got %v, %v", user, err)
+	}
+	if _, err := bearer.Authenticate(headerRequest("Authorization", "Bearer xyz")); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("got error %v for an invalid token", err)
+	}
+	if _, err := bearer.Authenticate(headerRequest("X-Other", "abc")); !errors.Is(err, ErrNoCredentials) {
+		t.Errorf("got error %v without a token", err)
+	}
+	if bearer.Challenge() != "Bearer" {
+		t.Errorf("got challenge %q", bearer.Challenge())
+	}
+	bearer.Realm = "api"
+	if bearer.Challenge() != `Bearer realm="api"` {
+		t.Errorf("got challenge %q", bearer.Challenge())
+	}
+}
+
+func TestAPIKey(t *testing.T) {
+	var bob = &testUser{name: "bob"}
+	var keys = APIKeys("X-API-Key", "api_key", map[string]request.User{"alice-key": alice, "bob-key": bob})
+	var tests = []struct {
+		name string
+		rq   *http.Request
+		user request.User
+		err  error
+	}{
+		{"header", httptest.NewRequest("GET", "/", nil), alice, nil},
+		{"query", httptest.NewRequest("GET", "/?api_key=bob-key", nil), bob, nil},
+		{"header before query", httptest.NewRequest("GET", "/?api_key=bob-key", nil), alice, nil},
+		{"invalid", httptest.NewRequest("GET", "/?api_key=other", nil), nil, ErrInvalidCredentials},
+		{"none", httptest.NewRequest("GET", "/", nil), nil, ErrNoCredentials},
+	}
+	tests[0].rq.Header.Set("X-API-Key", "alice-key")
+	tests[2].rq.Header.Set("X-API-Key", "alice-key")
+	for _, test := range tests {
+		var user, err = keys.Authenticate(newRequest(test.rq))
+		if !errors.Is(err, test.err) || user != test.user {
+			t.Errorf("%s: got %v, %v, want %v, %v", test.name, user, err, test.user, test.err)
+		}
+	}
+
+	var headerOnly = &APIKey{Header: "X-API-Key", Validate: keys.Validate}
+	if _, err := headerOnly.Authenticate(newRequest(httptest.NewRequest("GET", "/?api_key=bob-key", nil))); !errors.Is(err, ErrNoCredentials) {
+		t.Errorf("the query should be ignored without Query, got %v", err)
+	}
+}
+
+func TestChain(t *testing.T) {
+	var calls []string
+	var authenticator = func(name string, user request.User, err error) Authenticator {
+		return AuthenticatorFunc(func(r *request.Request) (request.User, error) {
+			calls = append(calls, name)
+			return user, err
+		})
+	}
+
+	var tests = []struct {
+		name  string
+		chain Authenticator
+		user  request.User
+		err   error
+		calls string
+	}{
+		{"first without credentials", Chain(authenticator("a", nil, ErrNoCredentials), authenticator("b", alice, nil)), alice, nil, "ab"},
+		{"first decides", Chain(authenticator("a", alice, nil), authenticator("b", nil, ErrInvalidCredentials)), alice, nil, "a"},
+		{"invalid does not fall through", Chain(authenticator("a", nil, ErrInvalidCredentials), authenticator("b", alice, nil)), nil, ErrInvalidCredentials, "a"},
+		{"none", Chain(authenticator("a", nil, ErrNoCredentials), authenticator("b", nil, ErrNoCredentials)), nil, ErrNoCredentials, "ab"},
+		{"empty", Chain(), nil, ErrNoCredentials, ""},
+	}
+	for _, test := range tests {
+		calls = nil
+		var user, err = test.chain.Authenticate(newRequest(httptest.NewRequest("GET", "/", nil)))
+		if user != test.user || !errors.Is(err, test.err) {
+			t.Errorf("%s: got %v, %v, want %v, %v", test.name, user, err, test.user, test.err)
+		}
+		var got string
+		for _, c := range calls {
+			got += c
+		}
+		if got != test.calls {
+			t.Errorf("%s: called %q, want %q", test.name, got, test.calls)
+		}
+	}
+
+	var chain = Chain(&Basic{Realm: "admin"}, authenticator("func", nil, nil), &Bearer{})
+	if c := chain.(Challenger).Challenge(); c != `Basic realm="admin", charset="UTF-8", Bearer` {
+		t.Errorf("got challenge %q", c)
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	var basic = BasicUsers("admin", map[string]string{"alice": "secret"}, func(username string) request.User {
+		return alice
+	})
+	var handler = router.HandleFunc(func(r *request.Request) {
+		if r.User == nil {
+			r.WriteString("anonymous")
+			return
+		}
+		if r.Data.Request.User != r.User {
+			r.WriteString("template user not set")
+			return
+		}
+		r.WriteString(r.User.(*testUser).name)
+	})
+	var r = router.NewRouter(true)
+	r.Use(Middleware(basic, nil))
+	r.Get("/", handler)
+
+	var serve = func(username, password string) *httptest.ResponseRecorder {
+		var rq = httptest.NewRequest("GET", "/", nil)
+		if username != "" {
+			rq.SetBasicAuth(username, password)
+		}
+		var w = httptest.NewRecorder()
+		r.ServeHTTP(w, rq)
+		return w
+	}
+
+	if w := serve("", ""); w.Code != http.StatusOK || w.Body.String() != "anonymous" {
+		t.Errorf("without credentials: got status %d, body %q", w.Code, w.Body)
+	}
+	if w := serve("alice", "secret"); w.Code != http.StatusOK || w.Body.String() != "alice" {
+		t.Errorf("valid credentials: got status %d, body %q", w.Code, w.Body)
+	}
+
+	// Unauthorized clears the response with Request.Error, the challenge must be set afterwards.
+	var w = serve("alice", "wrong")
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("invalid credentials: got status %d, want 401", w.Code)
+	}
+	if c := w.Header().Get("WWW-Authenticate"); c != basic.Challenge() {
+		t.Errorf("invalid credentials: got WWW-Authenticate %q, want %q", c, basic.Challenge())
+	}
+
+	var handled error
+	r = router.NewRouter(true)
+	r.Use(Middleware(basic, func(r *request.Request, err error) {
+		handled = err
+		r.Error(http.StatusForbidden, "forbidden")
+	}))
+	r.Get("/", handler)
+	if w := serve("alice", "wrong"); w.Code != http.StatusForbidden || !errors.Is(handled, ErrInvalidCredentials) {
+		t.Errorf("onError: got status %d, error %v", w.Code, handled)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("Middleware should panic without an authenticator")
+		}
+	}()
+	Middleware(nil, nil)
+}
+
+func TestSessionHelpers(t *testing.T) {
+	var lookup = func(r *request.Request, id any) (request.User, error) {
+		switch id {
+		case 1:
+			return alice, nil
+		case 2:
+			return nil, errors.New("lookup failed")
+		}
+		return nil, nil
+	}
+	var authenticator = Session(lookup)
+
+	var r = newRequest(httptest.NewRequest("GET", "/", nil))
+	if err := Login(r, alice, 1); !errors.Is(err, ErrNoSession) {
+		t.Errorf("Login without a session: got %v, want ErrNoSession", err)
+	}
+	if err := Logout(r); !errors.Is(err, ErrNoSession) {
+		t.Errorf("Logout without a session: got %v, want ErrNoSession", err)
+	}
+	if _, err := authenticator.Authenticate(r); !errors.Is(err, ErrNoCredentials) {
+		t.Errorf("Session without a session: got %v, want ErrNoCredentials", err)
+	}
+
+	var session = newTestSession()
+	r.Session = session
+	if _, err := authenticator.Authenticate(r); !errors.Is(err, ErrNoCredentials) {
+		t.Errorf("Session before Login: got %v, want ErrNoCredentials", err)
+	}
+
+	if err := Login(r, alice, 1); err != nil {
+		t.Fatal(err)
+	}
+	if session.Get(SESSION_USER_KEY) != 1 || session.renewed != 1 {
+		t.Errorf("Login: got id %v, renewed %d times", session.Get(SESSION_USER_KEY), session.renewed)
+	}
+	if r.User != alice || r.Data.Request.User != alice {
+		t.Error("Login should set the user on the request and the template data")
+	}
+	if user, err := authenticator.Authenticate(r); err != nil || user != alice {
+		t.Errorf("Session after Login: got %v, %v", user, err)
+	}
+
+	session.Set(SESSION_USER_KEY, 2)
+	if _, err := authenticator.Authenticate(r); err == nil || errors.Is(err, ErrNoCredentials) {
+		t.Errorf("Session should return the error of the lookup, got %v", err)
+	}
+	session.Set(SESSION_USER_KEY, 3)
+	if _, err := authenticator.Authenticate(r); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("Session with an unknown user: got %v, want ErrInvalidCredentials", err)
+	}
+
+	if err := Logout(r); err != nil {
+		t.Fatal(err)
+	}
+	if session.Exists(SESSION_USER_KEY) || session.renewed != 2 {
+		t.Errorf("Logout: got id %v, renewed %d times", session.Get(SESSION_USER_KEY), session.renewed)
+	}
+	if r.User != nil || r.Data.Request.User != nil {
+		t.Error("Logout should unset the user")
+	}
+}
+
+func TestCompareSecrets(t *testing.T) {
+	if !CompareSecrets("secret", "secret") || CompareSecrets("secret", "Secret") || CompareSecrets("", "secret") {
+		t.Error("CompareSecrets should only match equal secrets")
+	}
+}
@@ -0,0 +1,55 @@
+package auth
+
+import (
+	"strconv"
+
+	"github.com/Nigel2392/router/v3/request"
+)
+
+// Basic authenticates requests using HTTP Basic authentication.
+type Basic struct {
+	// Realm to send in the WWW-Authenticate header.
+	Realm string
+
+	// Validate the username and password, returning the user.
+	//
+	// Use CompareSecrets to compare passwords in constant time.
+	Validate func(r *request.Request, username, password string) (request.User, error)
+}
+
+// BasicUsers returns a Basic authenticator for a static map of usernames to passwords.
+//
+// The passwords are compared in constant time.
+//
+// The getUser function is called to retrieve the user after the password was verified.
+func BasicUsers(realm string, users map[string]string, getUser func(username string) request.User) *Basic {
+	return &Basic{
+		Realm: realm,
+		Validate: func(r *request.Request, username, password string) (request.User, error) {
+			var expected, ok = users[username]
+			// Always compare, so that unknown users take as long as known users.
+			if !CompareSecrets(password, expected) || !ok {
+				return nil, ErrInvalidCredentials
+			}
+			return getUser(username), nil
+		},
+	}
+}
+
+// Authenticate the request with the Authorization header.
+func (b *Basic) Authenticate(r *request.Request) (request.User, error) {
+	var username, password, ok = r.Request.BasicAuth()
+	if !ok {
+		return nil, ErrNoCredentials
+	}
+	return b.Validate(r, username, password)
+}
+
+// Challenge returns the WWW-Authenticate challenge.
+func (b *Basic) Challenge() string {
+	var realm = b.Realm
+	if realm == "" {
+		realm = "Restricted"
+	}
+	return "Basic realm=" + strconv.Quote(realm) + ", charset=\"UTF-8\""
+}
@@ -0,0 +1,68 @@
+package auth
+
+import (
+	"errors"
+
+	"github.com/Nigel2392/router/v3/request"
+)
+
+// Key used to store the user's identifier in the session.
+var SESSION_USER_KEY = "auth_user_id"
+
+// ErrNoSession is returned when a session helper is used, but no session middleware was set up.
+var ErrNoSession = errors.New("auth: no session on request")
+
+// Session authenticates requests with the identifier stored in the session by Login.
+//
+// The lookup function is used to retrieve the user for the identifier.
+func Session(lookup func(r *request.Request, id any) (request.User, error)) Authenticator {
+	return AuthenticatorFunc(func(r *request.Request) (request.User, error) {
+		if r.Session == nil {
+			return nil, ErrNoCredentials
+		}
+		var id = r.Session.Get(SESSION_USER_KEY)
+		if id == nil {
+			return nil, ErrNoCredentials
+		}
+		var user, err = lookup(r, id)
+		if err != nil {
+			return nil, err
+		}
+		if user == nil {
+			return nil, ErrInvalidCredentials
+		}
+		return user, nil
+	})
+}
+
+// Login stores the user's identifier in the session, and sets the user on the request.
+//
+// The session token is renewed first, to prevent session fixation.
+//
+// The identifier must be a type which can be stored by the session backend.
+func Login(r *request.Request, user request.User, id any) error {
+	if r.Session == nil {
+		return ErrNoSession
+	}
+	if err := r.Session.RenewToken(); err != nil {
+		return err
+	}
+	r.Session.Set(SESSION_USER_KEY, id)
+	SetUser(r, user)
+	return nil
+}
+
+// Logout removes the user's identifier from the session, and unsets the user on the request.
+//
+// The session token is renewed, so the old token can not be reused.
+func Logout(r *request.Request) error {
+	if r.Session == nil {
+		return ErrNoSession
+	}
+	r.Session.Delete(SESSION_USER_KEY)
+	if err := r.Session.RenewToken(); err != nil {
+		return err
+	}
+	SetUser(r, nil)
+	return nil
+}
@@ -0,0 +1,95 @@
+package auth
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/Nigel2392/router/v3/request"
+)
+
+// Bearer authenticates requests using a bearer token in the Authorization header.
+type Bearer struct {
+	// Realm to send in the WWW-Authenticate header.
+	Realm string
+
+	// Validate the token, returning the user.
+	Validate func(r *request.Request, token string) (request.User, error)
+}
+
+// Authenticate the request with the Authorization header.
+func (b *Bearer) Authenticate(r *request.Request) (request.User, error) {
+	var token, ok = BearerToken(r)
+	if !ok {
+		return nil, ErrNoCredentials
+	}
+	return b.Validate(r, token)
+}
+
+// Challenge returns the WWW-Authenticate challenge.
+func (b *Bearer) Challenge() string {
+	if b.Realm == "" {
+		return "Bearer"
+	}
+	return "Bearer realm=" + strconv.Quote(b.Realm)
+}
+
+// BearerToken returns the bearer token from the Authorization header.
+func BearerToken(r *request.Request) (string, bool) {
+	var header = r.Request.Header.Get("Authorization")
+	if len(header) < 7 || !strings.EqualFold(header[:7], "bearer ") {
+		return "", false
+	}
+	var token = strings.TrimSpace(header[7:])
+	return token, token != ""
+}
+
+// APIKey authenticates requests with an API key from a header, or from the query.
+type APIKey struct {
+	// Header to read the key from, for example X-API-Key.
+	Header string
+
+	// Query parameter to read the key from, for example api_key.
+	//
+	// The header takes precedence over the query parameter.
+	Query string
+
+	// Validate the key, returning the user.
+	Validate func(r *request.Request, key string) (request.User, error)
+}
+
+// APIKeys returns an APIKey authenticator for a static map of keys to users.
+//
+// The keys are compared in constant time.
+func APIKeys(header, query string, keys map[string]request.User) *APIKey {
+	return &APIKey{
+		Header: header,
+		Query:  query,
+		Validate: func(r *request.Request, key string) (request.User, error) {
+			var user request.User
+			for k, u := range keys {
+				if CompareSecrets(key, k) {
+					user = u
+				}
+			}
+			if user == nil {
+				return nil, ErrInvalidCredentials
+			}
+			return user, nil
+		},
+	}
+}
+
+// Authenticate the request with the API key.
+func (a *APIKey) Authenticate(r *request.Request) (request.User, error) {
+	var key string
+	if a.Header != "" {
+		key = r.Request.Header.Get(a.Header)
+	}
+	if key == "" && a.Query != "" {
+		key = r.Request.URL.Query().Get(a.Query)
+	}
+	if key == "" {
+		return nil, ErrNoCredentials
+	}
+	return a.Validate(r, key)
+}
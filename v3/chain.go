@@ -0,0 +1,237 @@
+package router
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"reflect"
+	"runtime"
+	"sync"
+	"sync/atomic"
+
+	"github.com/Nigel2392/router/v3/request"
+)
+
+// Increments the router's version, whenever its routes, middleware, policies or groups change.
+//
+// Routes compare it to the version their chain was built with,
+// so a chain is only composed again after something changed.
+// The router's name index is rebuilt in the same way.
+func (r *Router) invalidateChains() {
+	r.version.Add(1)
+}
+
+// Invalidates the chains of the router the route is registered on.
+//
+// Groups which were not added to a router yet have no router,
+// the router is invalidated when the group is added.
+func (r *Route) invalidateChains() {
+	if router := r.router(); router != nil {
+		router.invalidateChains()
+	}
+}
+
+// Returns the router the route is registered on, or nil.
+func (r *Route) router() *Router {
+	for route := r; route != nil; route = route.parent {
+		if route.owner != nil {
+			return route.owner
+		}
+	}
+	return nil
+}
+
+// A middleware with an optional name.
+//
+// The name is used to skip global middleware on a route,
+// and to display the chain for debugging.
+type namedMiddleware struct {
+	name       string
+	middleware Middleware
+}
+
+// Name returns the name of the middleware.
+//
+// If no name was given, the name of the function is used.
+func (m namedMiddleware) Name() string {
+	if m.name != "" {
+		return m.name
+	}
+	var f = runtime.FuncForPC(reflect.ValueOf(m.middleware).Pointer())
+	if f == nil {
+		return "unknown"
+	}
+	return f.Name()
+}
+
+func nameMiddlewares(middlewares []Middleware) []namedMiddleware {
+	var named = make([]namedMiddleware, len(middlewares))
+	for i, m := range middlewares {
+		named[i] = namedMiddleware{middleware: m}
+	}
+	return named
+}
+
+// The compiled chain of a route.
+//
+// Serving a request only loads the pointer, the lock is only taken to compose the chain.
+type routeChain struct {
+	compiled atomic.Pointer[compiledChain]
+	mu       sync.Mutex
+}
+
+type compiledChain struct {
+	handler Handler
+	version uint64
+}
+
+// ChainLink is a single middleware in the chain of a route.
+type ChainLink struct {
+	// Name of the middleware.
+	Name string
+
+	// Where the middleware was registered.
+	// This is "global" for the router's middleware,
+	// the name of the group for group middleware,
+	// or "route" for the route's own middleware.
+	Source string
+}
+
+type sourcedMiddleware struct {
+	namedMiddleware
+	source string
+}
+
+func (r *Router) middlewares(route *Route) []sourcedMiddleware {
+	var lineage = route.lineage()
+	var skip = make(map[string]bool)
+	var enabled = true
+	for _, ancestor := range lineage {
+		if !ancestor.middlewareEnabled {
+			enabled = false
+		}
+		for _, name := range ancestor.skipMiddleware {
+			skip[name] = true
+		}
+	}
+
+	var middlewares = make([]sourcedMiddleware, 0)
+	if !enabled {
+		return middlewares
+	}
+	for _, m := range r.middleware {
+		if m.name != "" && skip[m.name] {
+			continue
+		}
+		middlewares = append(middlewares, sourcedMiddleware{m, "global"})
+	}
+	for i, ancestor := range lineage {
+		var source = ancestor.name
+		if i == len(lineage)-1 {
+			source = "route"
+		} else if source == "" {
+			source = string(ancestor.Path)
+		}
+		for _, m := range ancestor.middleware {
+			// A route can not skip its own middleware.
+			if i != len(lineage)-1 && m.name != "" && skip[m.name] {
+				continue
+			}
+			middlewares = append(middlewares, sourcedMiddleware{m, source})
+		}
+	}
+	return middlewares
+}
+
+// Returns the handler of the route, wrapped in its middleware chain.
+//
+// The chain is composed once, and only composed again
+// after middleware, policies or groups were changed.
+func (r *Router) handler(route *Route) Handler {
+	var version = r.version.Load()
+	if c := route.chain.compiled.Load(); c != nil && c.version == version {
+		return c.handler
+	}
+	route.chain.mu.Lock()
+	defer route.chain.mu.Unlock()
+	if c := route.chain.compiled.Load(); c != nil && c.version == version {
+		return c.handler
+	}
+
+	var handler Handler = route.HandlerFunc
+
+	if tags := route.RobotsTags(); len(tags) > 0 {
+		handler = RobotsTag(tags...)(handler)
+	}
+
+	// Check the policies after all middleware has run,
+	// so the user has been set on the request.
+	var policies = append(append(Policies{}, r.policies...), route.allPolicies()...)
+	if len(policies) > 0 {
+		handler = PolicyMiddleware(nil, policies...)(handler)
+	}
+
+	var middlewares = r.middlewares(route)
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		handler = middlewares[i].middleware(handler)
+	}
+
+	route.chain.compiled.Store(&compiledChain{handler: handler, version: version})
+	return handler
+}
+
+// Serves the ForbiddenHandler, which is looked up for every request,
+// so it can be set after chains were composed.
+//
+// It is set as the request's forbidden handler, so middleware can use it with Request.Forbidden.
+func (r *Router) forbidden(req *request.Request) {
+	if r.ForbiddenHandler != nil {
+		r.ForbiddenHandler.ServeHTTP(req)
+		return
+	}
+	req.Error(http.StatusForbidden, http.StatusText(http.StatusForbidden))
+}
+
+// Chain returns the effective middleware chain of the route, for debugging.
+//
+// The chain is ordered from the outermost to the innermost middleware:
+// global middleware, then the middleware of every parent group, then the route's own middleware.
+func (r *Router) Chain(route *Route) []ChainLink {
+	var middlewares = r.middlewares(route)
+	var links = make([]ChainLink, len(middlewares))
+	for i, m := range middlewares {
+		links[i] = ChainLink{Name: m.Name(), Source: m.source}
+	}
+	return links
+}
+
+// Returns the effective middleware chain of every route in a nicely formatted string for debugging.
+func (r *Router) ChainString() string {
+	var buf bytes.Buffer
+	for _, route := range r.routes {
+		WalkRoutes(route, func(route *Route, _ int) {
+			if route.HandlerFunc == nil {
+				return
+			}
+			fmt.Fprintf(&buf, "%s %s -> %s\n", route.Method, string(route.Path), route.name)
+			for _, link := range r.Chain(route) {
+				fmt.Fprintf(&buf, "  %s (%s)\n", link.Name, link.Source)
+			}
+			if policies := append(append(Policies{}, r.policies...), route.allPolicies()...); len(policies) > 0 {
+				fmt.Fprintf(&buf, "  require %s\n", policies.String())
+			}
+			fmt.Fprintf(&buf, "  %s\n", handlerName(route.HandlerFunc))
+		})
+	}
+	return buf.String()
+}
+
+func handlerName(h Handler) string {
+	var v = reflect.ValueOf(h)
+	if v.Kind() == reflect.Func {
+		if f := runtime.FuncForPC(v.Pointer()); f != nil {
+			return f.Name()
+		}
+	}
+	return fmt.Sprintf("%T", h)
+}
@@ -0,0 +1,145 @@
+package router
+
+import (
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/Nigel2392/router/v3/request"
+)
+
+type chainUser struct{ admin bool }
+
+func (u *chainUser) IsAuthenticated() bool                     { return true }
+func (u *chainUser) IsAdmin() bool                             { return u.admin }
+func (u *chainUser) HasPermissions(permissions ...string) bool { return u.admin }
+
+// Appends the name to the X-Chain header.
+func chainMiddleware(name string) Middleware {
+	return func(next Handler) Handler {
+		return HandleFunc(func(r *request.Request) {
+			r.Response.Header().Add("X-Chain", name)
+			next.ServeHTTP(r)
+		})
+	}
+}
+
+func serveChain(r *Router, path string) (int, string) {
+	var w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+	return w.Code, strings.Join(w.Header().Values("X-Chain"), ",")
+}
+
+func TestChainsArePerRouter(t *testing.T) {
+	var a, b = NewRouter(true), NewRouter(true)
+	var route = a.Get("/", HandleFunc(func(r *request.Request) {})).(*Route)
+	a.handler(route)
+	var compiled = route.chain.compiled.Load()
+
+	b.Use(chainMiddleware("b"))
+	b.Get("/other", HandleFunc(func(r *request.Request) {}))
+	if a.handler(route); route.chain.compiled.Load() != compiled {
+		t.Error("changing another router recomposed the chain")
+	}
+
+	a.Use(chainMiddleware("a"))
+	if a.handler(route); route.chain.compiled.Load() == compiled {
+		t.Error("changing the router did not recompose the chain")
+	}
+	if _, chain := serveChain(a, "/"); chain != "a" {
+		t.Errorf("got chain %q, want %q", chain, "a")
+	}
+}
+
+func TestChainOfAddedGroup(t *testing.T) {
+	var r = NewRouter(true)
+	r.Use(chainMiddleware("global"))
+	var group = Group("/api", "api")
+	group.Use(chainMiddleware("group"))
+	group.Get("/users", HandleFunc(func(r *request.Request) {}))
+	r.AddGroup(group)
+
+	if _, chain := serveChain(r, "/api/users"); chain != "global,group" {
+		t.Errorf("got chain %q, want %q", chain, "global,group")
+	}
+
+	// Changes to the group after it was served are picked up.
+	group.Use(chainMiddleware("late"))
+	if _, chain := serveChain(r, "/api/users"); chain != "global,group,late" {
+		t.Errorf("got chain %q, want %q", chain, "global,group,late")
+	}
+}
+
+func TestForbiddenHandlerAfterServing(t *testing.T) {
+	var r = NewRouter(true)
+	r.Get("/admin", HandleFunc(func(r *request.Request) {})).Require(AdminOnly())
+
+	if status, _ := serveChain(r, "/admin"); status != 403 {
+		t.Fatalf("got status %d, want 403", status)
+	}
+	r.ForbiddenHandler = HandleFunc(func(r *request.Request) {
+		r.Response.WriteHeader(401)
+	})
+	if status, _ := serveChain(r, "/admin"); status != 401 {
+		t.Errorf("got status %d, want the ForbiddenHandler's 401", status)
+	}
+}
+
+func TestPolicyMiddlewareUsesForbiddenHandler(t *testing.T) {
+	var r = NewRouter(true)
+	r.ForbiddenHandler = HandleFunc(func(r *request.Request) {
+		r.Response.WriteHeader(401)
+	})
+	r.Get("/admin", HandleFunc(func(r *request.Request) {})).Use(PolicyMiddleware(nil, AdminOnly()))
+
+	if status, _ := serveChain(r, "/admin"); status != 401 {
+		t.Errorf("got status %d, want the ForbiddenHandler's 401", status)
+	}
+	if r.Require(AdminOnly()) != r {
+		t.Error("Require did not return the router")
+	}
+}
+
+func TestDisableMiddleware(t *testing.T) {
+	var r = NewRouter(true)
+	r.Use(chainMiddleware("global"))
+	var group = r.Group("/group", "group", chainMiddleware("group"))
+	var route = group.Get("/route", HandleFunc(func(r *request.Request) {}))
+	route.Use(chainMiddleware("route"))
+	group.Get("/admin", HandleFunc(func(r *request.Request) {})).Require(AdminOnly())
+
+	if _, chain := serveChain(r, "/group/route"); chain != "global,group,route" {
+		t.Fatalf("got chain %q, want %q", chain, "global,group,route")
+	}
+
+	group.(*Route).DisableMiddleware()
+	if _, chain := serveChain(r, "/group/route"); chain != "" {
+		t.Errorf("got chain %q, want no middleware", chain)
+	}
+	if status, _ := serveChain(r, "/group/admin"); status != 403 {
+		t.Errorf("got status %d, policies should still be checked", status)
+	}
+}
+
+func TestChainConcurrentChanges(t *testing.T) {
+	var r = NewRouter(true)
+	r.Get("/", HandleFunc(func(r *request.Request) {}))
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				if status, _ := serveChain(r, "/"); status != 200 {
+					t.Errorf("got status %d", status)
+					return
+				}
+			}
+		}()
+	}
+	for i := 0; i < 50; i++ {
+		r.invalidateChains()
+	}
+	wg.Wait()
+}
@@ -5,6 +5,11 @@ import (
 	"net/http"
 )
 
+// Hooks which are called with every request, before it is executed.
+//
+// Register tracing.InjectHook to propagate the trace context to outbound requests.
+var REQUEST_HOOKS []func(r *http.Request)
+
 // Client is a client that can be used to execute http requests.
 // - Can be used to execute GET, POST, PUT, DELETE, PATCH requests.
 type Client struct {
@@ -21,6 +26,9 @@ func (c *Client) Do() (*http.Response, error) {
 	if c.request == nil {
 		return nil, errors.New(ErrNoRequest)
 	}
+	for _, hook := range REQUEST_HOOKS {
+		hook(c.request)
+	}
 	var resp, err = c.client.Do(c.request)
 	if err != nil {
 		return nil, err
@@ -0,0 +1,138 @@
+package router
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// A constraint a request must meet for a route to match, after its path matched.
+type constraint func(rq *http.Request) bool
+
+// Header only matches the route, and its children, if the request has the header with the value.
+//
+// Comma separated values, like those of the Accept header, match if one of them is the value.
+// Parameters of a value, like ";q=0.9", are ignored, except for ";q=0" which refuses the value.
+// If the value is empty, the header must be present.
+//
+//	r.Get("/users", usersV2Func).Header("Accept", "application/vnd.api.v2+json")
+//	r.Post("/webhook", pushFunc).Header("X-GitHub-Event", "push")
+func (r *Route) Header(name, value string) Registrar {
+	return r.MatchFunc(func(rq *http.Request) bool {
+		var values = rq.Header.Values(name)
+		if value == "" {
+			return len(values) > 0
+		}
+		for _, v := range values {
+			for _, part := range strings.Split(v, ",") {
+				var params string
+				part, params, _ = strings.Cut(part, ";")
+				if strings.EqualFold(strings.TrimSpace(part), value) && !refused(params) {
+					return true
+				}
+			}
+		}
+		return false
+	})
+}
+
+// Query only matches the route, and its children, if the query parameter is present.
+//
+// If values are given, the parameter must have one of them.
+func (r *Route) Query(name string, values ...string) Registrar {
+	return r.MatchFunc(func(rq *http.Request) bool {
+		var query = rq.URL.Query()
+		if !query.Has(name) {
+			return false
+		}
+		if len(values) == 0 {
+			return true
+		}
+		var got = query.Get(name)
+		for _, v := range values {
+			if v == got {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// NoQuery only matches the route, and its children, if the query parameter is absent.
+func (r *Route) NoQuery(name string) Registrar {
+	return r.MatchFunc(func(rq *http.Request) bool {
+		return !rq.URL.Query().Has(name)
+	})
+}
+
+// Scheme only matches the route, and its children, for requests with one of the schemes, like "https".
+//
+// The scheme is taken from the X-Forwarded-Proto header, if it is set.
+func (r *Route) Scheme(schemes ...string) Registrar {
+	return r.MatchFunc(func(rq *http.Request) bool {
+		var scheme = requestScheme(rq)
+		for _, s := range schemes {
+			if strings.EqualFold(s, scheme) {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// MatchFunc only matches the route, and its children, if the predicate returns true.
+func (r *Route) MatchFunc(predicate func(rq *http.Request) bool) Registrar {
+	r.constraints = append(r.constraints, predicate)
+	return r
+}
+
+// Reports whether the request meets the route's constraints.
+//
+// A nil request meets every constraint.
+func (r *Route) meets(rq *http.Request) bool {
+	if rq == nil {
+		return true
+	}
+	for _, c := range r.constraints {
+		if !c(rq) {
+			return false
+		}
+	}
+	return true
+}
+
+// Reports whether the route, or one of its parents, has constraints.
+func (r *Route) constrained() bool {
+	for route := r; route != nil; route = route.parent {
+		if len(route.constraints) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Reports whether the parameters of a header value contain a quality of 0.
+func refused(params string) bool {
+	for _, param := range strings.Split(params, ";") {
+		var key, value, _ = strings.Cut(param, "=")
+		if strings.EqualFold(strings.TrimSpace(key), "q") {
+			var q, err = strconv.ParseFloat(strings.TrimSpace(value), 64)
+			return err == nil && q == 0
+		}
+	}
+	return false
+}
+
+func requestScheme(rq *http.Request) string {
+	if proto := rq.Header.Get("X-Forwarded-Proto"); proto != "" {
+		proto, _, _ = strings.Cut(proto, ",")
+		return strings.ToLower(strings.TrimSpace(proto))
+	}
+	if rq.URL.Scheme != "" {
+		return rq.URL.Scheme
+	}
+	if rq.TLS != nil {
+		return "https"
+	}
+	return "http"
+}
@@ -0,0 +1,138 @@
+package router
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Nigel2392/router/v3/request"
+)
+
+// Returns a handler which writes the name.
+func writeName(name string) Handler {
+	return HandleFunc(func(r *request.Request) {
+		r.WriteString(name)
+	})
+}
+
+// Serves the request, and returns the status and body.
+func serveRequest(r *Router, rq *http.Request) (int, string) {
+	var w = httptest.NewRecorder()
+	r.ServeHTTP(w, rq)
+	return w.Code, w.Body.String()
+}
+
+func TestHeaderConstraint(t *testing.T) {
+	var r = NewRouter(true)
+	r.Get("/users", writeName("v2")).Header("Accept", "application/vnd.api.v2+json")
+	r.Get("/users", writeName("v1"))
+	r.Post("/webhook", writeName("signed")).Header("X-Signature", "")
+	r.Post("/webhook", writeName("unsigned"))
+
+	var tests = []struct {
+		method string
+		path   string
+		header string
+		value  string
+		want   string
+	}{
+		{"GET", "/users", "Accept", "application/vnd.api.v2+json", "v2"},
+		{"GET", "/users", "Accept", "Application/Vnd.Api.V2+JSON", "v2"},
+		{"GET", "/users", "Accept", "application/vnd.api.v2+json; charset=utf-8", "v2"},
+		{"GET", "/users", "Accept", "text/html, application/vnd.api.v2+json;q=0.5", "v2"},
+		{"GET", "/users", "Accept", "application/vnd.api.v2+json;q=0", "v1"},
+		{"GET", "/users", "Accept", "application/vnd.api.v2+json; q=0.0", "v1"},
+		{"GET", "/users", "Accept", "application/json", "v1"},
+		{"GET", "/users", "", "", "v1"},
+		{"POST", "/webhook", "X-Signature", "abc", "signed"},
+		{"POST", "/webhook", "", "", "unsigned"},
+	}
+	for _, test := range tests {
+		var rq = httptest.NewRequest(test.method, test.path, nil)
+		if test.header != "" {
+			rq.Header.Set(test.header, test.value)
+		}
+		if _, got := serveRequest(r, rq); got != test.want {
+			t.Errorf("%s %s with %s %q: got %q, want %q", test.method, test.path, test.header, test.value, got, test.want)
+		}
+	}
+}
+
+func TestQueryConstraints(t *testing.T) {
+	var r = NewRouter(true)
+	r.Get("/search", writeName("preview")).Query("preview")
+	r.Get("/search", writeName("sorted")).Query("sort", "asc", "desc")
+	r.Get("/search", writeName("plain")).NoQuery("page")
+
+	var tests = map[string]string{
+		"/search?preview":       "preview",
+		"/search?preview=false": "preview",
+		"/search?sort=asc":      "sorted",
+		"/search?sort=random":   "plain",
+		"/search":               "plain",
+	}
+	for path, want := range tests {
+		if _, got := serveRequest(r, httptest.NewRequest("GET", path, nil)); got != want {
+			t.Errorf("GET %s: got %q, want %q", path, got, want)
+		}
+	}
+	if code, _ := serveRequest(r, httptest.NewRequest("GET", "/search?page=2", nil)); code != http.StatusNotFound {
+		t.Errorf("GET /search?page=2: got status %d, want 404 when no route meets its constraints", code)
+	}
+}
+
+func TestSchemeConstraint(t *testing.T) {
+	var r = NewRouter(true)
+	r.Get("/", writeName("secure")).Scheme("https")
+	r.Get("/", writeName("insecure"))
+
+	var plain = httptest.NewRequest("GET", "/", nil)
+	var forwarded = httptest.NewRequest("GET", "/", nil)
+	forwarded.Header.Set("X-Forwarded-Proto", "HTTPS, http")
+	var direct = httptest.NewRequest("GET", "/", nil)
+	direct.TLS = &tls.ConnectionState{}
+
+	for rq, want := range map[*http.Request]string{plain: "insecure", forwarded: "secure", direct: "secure"} {
+		if _, got := serveRequest(r, rq); got != want {
+			t.Errorf("got %q, want %q", got, want)
+		}
+	}
+}
+
+func TestConstraintsAreInherited(t *testing.T) {
+	var r = NewRouter(true)
+	var beta = r.Group("/app", "beta").MatchFunc(func(rq *http.Request) bool {
+		return rq.Header.Get("X-Beta") != ""
+	})
+	beta.Get("/home", writeName("beta"))
+	beta.Group("/admin", "admin").Get("/users", writeName("beta users"))
+	r.Get("/app/home", writeName("stable"))
+
+	var rq = httptest.NewRequest("GET", "/app/home", nil)
+	if _, got := serveRequest(r, rq); got != "stable" {
+		t.Errorf("got %q without the header, want %q", got, "stable")
+	}
+	if code, _ := serveRequest(r, httptest.NewRequest("GET", "/app/admin/users", nil)); code != http.StatusNotFound {
+		t.Errorf("got status %d for a grandchild without the header, want 404", code)
+	}
+
+	for path, want := range map[string]string{"/app/home": "beta", "/app/admin/users": "beta users"} {
+		var rq = httptest.NewRequest("GET", path, nil)
+		rq.Header.Set("X-Beta", "1")
+		if _, got := serveRequest(r, rq); got != want {
+			t.Errorf("GET %s with the header: got %q, want %q", path, got, want)
+		}
+	}
+
+	// Match ignores constraints, MatchRequest does not.
+	if ok, route, _ := r.Match("GET", "/app/home"); !ok || !route.constrained() {
+		t.Error("Match should return the first route, ignoring its constraints")
+	}
+	if ok, route, _ := r.MatchRequest(httptest.NewRequest("GET", "/app/home", nil)); !ok || route.constrained() {
+		t.Error("MatchRequest should skip routes whose constraints fail")
+	}
+	if err := r.Validate(); err != nil {
+		t.Errorf("a constrained route does not shadow later routes: %v", err)
+	}
+}
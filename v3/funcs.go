@@ -13,7 +13,7 @@ import (
 
 // Group creates a new router URL group
 func Group(path string, name string) Registrar {
-	var route = &Route{Path: routevars.URLFormatter(path), middlewareEnabled: true, Method: ALL}
+	var route = &Route{Path: routevars.URLFormatter(path), middlewareEnabled: true, Method: ALL, source: registeredAt()}
 	route.name = name
 	return route
 }
@@ -21,6 +21,7 @@ func Group(path string, name string) Registrar {
 // NewRoute creates a new url group with a handler registered to it.
 func NewRoute(method, path string, name string, handler HandleFunc) Registrar {
 	return &Route{
+		source:            registeredAt(),
 		Path:              routevars.URLFormatter(path),
 		middlewareEnabled: true,
 		Method:            method,
@@ -0,0 +1,37 @@
+package health
+
+import (
+	"context"
+	"fmt"
+)
+
+// Pinger is implemented by *sql.DB, and most other database clients.
+type Pinger interface {
+	PingContext(ctx context.Context) error
+}
+
+// Ping checks a database connection.
+func Ping(db Pinger) Check {
+	return db.PingContext
+}
+
+// Func turns a function without a context into a check.
+func Func(f func() error) Check {
+	return func(ctx context.Context) error {
+		return f()
+	}
+}
+
+// DiskSpace fails if the filesystem of the path has less than minFree bytes available.
+func DiskSpace(path string, minFree uint64) Check {
+	return func(ctx context.Context) error {
+		var free, err = diskFree(path)
+		if err != nil {
+			return err
+		}
+		if free < minFree {
+			return fmt.Errorf("%d bytes free on %s, need %d", free, path, minFree)
+		}
+		return nil
+	}
+}
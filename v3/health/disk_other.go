@@ -0,0 +1,10 @@
+//go:build !unix
+// +build !unix
+
+package health
+
+import "errors"
+
+func diskFree(path string) (uint64, error) {
+	return 0, errors.New("disk space checks are not supported on this platform")
+}
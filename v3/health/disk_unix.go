@@ -0,0 +1,15 @@
+//go:build unix
+// +build unix
+
+package health
+
+import "syscall"
+
+// Returns the bytes available to unprivileged users on the filesystem of the path.
+func diskFree(path string) (uint64, error) {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(path, &stat); err != nil {
+		return 0, err
+	}
+	return uint64(stat.Bavail) * uint64(stat.Bsize), nil
+}
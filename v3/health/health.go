@@ -0,0 +1,247 @@
+// Package health serves health, readiness and liveness endpoints with pluggable checks.
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/Nigel2392/router/v3"
+	"github.com/Nigel2392/router/v3/request"
+)
+
+// Defaults used when the checker does not set them.
+var (
+	// Time a check may take, before it fails.
+	CHECK_TIMEOUT = 5 * time.Second
+
+	// Time the result of a check is reused, so frequent probes do not overload dependencies.
+	CACHE_TTL = time.Second
+)
+
+// Returned by the readiness endpoint while the server is not ready, or shutting down.
+var ErrNotReady = errors.New("server is not ready")
+
+// Name of the result the readiness endpoint adds for Checker.Ready, checks can not use it.
+const ServerCheck = "server"
+
+// Check returns an error if the checked dependency is unhealthy.
+type Check func(ctx context.Context) error
+
+// Status of a check, or of all checks.
+type Status string
+
+const (
+	StatusOK   Status = "ok"
+	StatusFail Status = "fail"
+)
+
+// Result of a single check.
+type Result struct {
+	Status   Status    `json:"status"`
+	Error    string    `json:"error,omitempty"`
+	Duration string    `json:"duration"`
+	Checked  time.Time `json:"checked_at"`
+}
+
+// Report is written by the endpoints as JSON.
+type Report struct {
+	Status Status            `json:"status"`
+	Checks map[string]Result `json:"checks"`
+}
+
+type namedCheck struct {
+	name     string
+	check    Check
+	liveness bool
+
+	mu     sync.Mutex
+	result Result
+	valid  time.Time
+}
+
+// Checker runs named checks, and serves their results.
+type Checker struct {
+	// Timeout of every check, CHECK_TIMEOUT if zero.
+	Timeout time.Duration
+
+	// Time results are cached, CACHE_TTL if zero.
+	CacheTTL time.Duration
+
+	// Ready reports whether the server is ready, like server.Server.Ready.
+	// The readiness endpoint fails while it returns false, so it fails during shutdown.
+	Ready func() bool
+
+	// Show the errors of failing checks in the responses of the endpoints.
+	//
+	// Errors can contain hostnames or connection strings,
+	// only enable this if the endpoints are not public.
+	// Run always returns the errors.
+	ShowErrors bool
+
+	checks []*namedCheck
+	mu     sync.RWMutex
+}
+
+// New returns a checker without checks.
+func New() *Checker {
+	return &Checker{}
+}
+
+// Add adds a readiness check, it is run by /healthz and /readyz.
+//
+// It panics if the name is ServerCheck, or if a check with the name was already added.
+func (c *Checker) Add(name string, check Check) {
+	c.add(&namedCheck{name: name, check: check})
+}
+
+// AddLiveness adds a liveness check, it is run by every endpoint.
+//
+// Liveness checks should only fail if the process must be restarted,
+// so they should not check external dependencies.
+func (c *Checker) AddLiveness(name string, check Check) {
+	c.add(&namedCheck{name: name, check: check, liveness: true})
+}
+
+func (c *Checker) add(check *namedCheck) {
+	if check.name == ServerCheck {
+		panic(fmt.Sprintf("health: %q is reserved for the readiness of the server", check.name))
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, other := range c.checks {
+		if other.name == check.name {
+			panic(fmt.Sprintf("health: %q is already added", check.name))
+		}
+	}
+	c.checks = append(c.checks, check)
+}
+
+// Register registers the /healthz, /readyz and /livez routes on a router or any Registrar.
+func (c *Checker) Register(r router.Getter) {
+	r.Get("/healthz", router.HandleFunc(c.Healthz), "healthz")
+	r.Get("/readyz", router.HandleFunc(c.Readyz), "readyz")
+	r.Get("/livez", router.HandleFunc(c.Livez), "livez")
+}
+
+// Healthz runs every check.
+func (c *Checker) Healthz(r *request.Request) {
+	c.write(r, c.Run(r.Context(), false))
+}
+
+// Readyz runs every check, and fails while the server is not ready.
+func (c *Checker) Readyz(r *request.Request) {
+	var report = c.Run(r.Context(), false)
+	if c.Ready != nil && !c.Ready() {
+		report.Status = StatusFail
+		report.Checks[ServerCheck] = Result{Status: StatusFail, Error: ErrNotReady.Error(), Duration: "0s", Checked: time.Now()}
+	}
+	c.write(r, report)
+}
+
+// Livez runs the liveness checks.
+func (c *Checker) Livez(r *request.Request) {
+	c.write(r, c.Run(r.Context(), true))
+}
+
+// Run runs the checks concurrently, or only the liveness checks, and returns their results.
+func (c *Checker) Run(ctx context.Context, livenessOnly bool) Report {
+	c.mu.RLock()
+	var checks = make([]*namedCheck, 0, len(c.checks))
+	for _, check := range c.checks {
+		if check.liveness || !livenessOnly {
+			checks = append(checks, check)
+		}
+	}
+	c.mu.RUnlock()
+
+	var results = make([]Result, len(checks))
+	var wg sync.WaitGroup
+	for i, check := range checks {
+		wg.Add(1)
+		go func(i int, check *namedCheck) {
+			defer wg.Done()
+			results[i] = c.run(ctx, check)
+		}(i, check)
+	}
+	wg.Wait()
+
+	var report = Report{Status: StatusOK, Checks: make(map[string]Result, len(checks))}
+	for i, check := range checks {
+		report.Checks[check.name] = results[i]
+		if results[i].Status != StatusOK {
+			report.Status = StatusFail
+		}
+	}
+	return report
+}
+
+// Runs the check, or returns its cached result.
+func (c *Checker) run(ctx context.Context, check *namedCheck) Result {
+	check.mu.Lock()
+	defer check.mu.Unlock()
+	if time.Now().Before(check.valid) {
+		return check.result
+	}
+
+	var timeout = c.Timeout
+	if timeout == 0 {
+		timeout = CHECK_TIMEOUT
+	}
+	var checkCtx, cancel = context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var start = time.Now()
+	var errs = make(chan error, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				errs <- errors.New("check panicked")
+			}
+		}()
+		errs <- check.check(checkCtx)
+	}()
+
+	var err error
+	select {
+	case err = <-errs:
+	case <-checkCtx.Done():
+		err = checkCtx.Err()
+	}
+
+	var result = Result{Status: StatusOK, Duration: time.Since(start).String(), Checked: start}
+	if err != nil {
+		result.Status = StatusFail
+		result.Error = err.Error()
+	}
+
+	// Do not cache results of checks which were cut short by the request.
+	if ctx.Err() == nil {
+		var ttl = c.CacheTTL
+		if ttl == 0 {
+			ttl = CACHE_TTL
+		}
+		check.result = result
+		check.valid = time.Now().Add(ttl)
+	}
+	return result
+}
+
+func (c *Checker) write(r *request.Request, report Report) {
+	if !c.ShowErrors {
+		for name, result := range report.Checks {
+			result.Error = ""
+			report.Checks[name] = result
+		}
+	}
+	r.Response.Header().Set("Content-Type", "application/json")
+	r.Response.Header().Set("Cache-Control", "no-store")
+	if report.Status != StatusOK {
+		r.Response.WriteHeader(http.StatusServiceUnavailable)
+	}
+	json.NewEncoder(r.Response).Encode(report)
+}
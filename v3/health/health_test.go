@@ -0,0 +1,223 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Nigel2392/router/v3"
+	"github.com/Nigel2392/router/v3/request"
+	"github.com/Nigel2392/router/v3/request/writer"
+	"github.com/Nigel2392/router/v3/server"
+)
+
+// Serves the request with the handler, and returns the response.
+func serve(handler func(r *request.Request)) *httptest.ResponseRecorder {
+	var w = httptest.NewRecorder()
+	var r = request.NewRequest(writer.NewClearable(w), httptest.NewRequest("GET", "/", nil), nil)
+	handler(r)
+	r.Response.Finalize()
+	return w
+}
+
+func TestChecksRunConcurrently(t *testing.T) {
+	var c = New()
+	c.Timeout = time.Second
+	// Every check waits until all checks started, so they fail if they run one after another.
+	var started sync.WaitGroup
+	started.Add(3)
+	var check = func(ctx context.Context) error {
+		started.Done()
+		var done = make(chan struct{})
+		go func() {
+			started.Wait()
+			close(done)
+		}()
+		select {
+		case <-done:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	c.Add("a", check)
+	c.Add("b", check)
+	c.AddLiveness("c", check)
+
+	var report = c.Run(context.Background(), false)
+	if report.Status != StatusOK || len(report.Checks) != 3 {
+		t.Fatalf("got report %+v", report)
+	}
+}
+
+func TestCheckTimeout(t *testing.T) {
+	var c = New()
+	c.Timeout = 20 * time.Millisecond
+	c.Add("slow", func(ctx context.Context) error {
+		<-ctx.Done()
+		return nil
+	})
+	c.Add("ignores context", func(ctx context.Context) error {
+		time.Sleep(time.Second)
+		return nil
+	})
+
+	var start = time.Now()
+	var report = c.Run(context.Background(), false)
+	if time.Since(start) > 500*time.Millisecond {
+		t.Errorf("Run took %s, checks should be cut short by the timeout", time.Since(start))
+	}
+	for name, result := range report.Checks {
+		if result.Status != StatusFail || result.Error != context.DeadlineExceeded.Error() {
+			t.Errorf("%s: got result %+v", name, result)
+		}
+	}
+}
+
+func TestCheckCache(t *testing.T) {
+	var c = New()
+	c.CacheTTL = 50 * time.Millisecond
+	var calls atomic.Int32
+	c.Add("counted", func(ctx context.Context) error {
+		calls.Add(1)
+		return ctx.Err()
+	})
+
+	c.Run(context.Background(), false)
+	c.Run(context.Background(), false)
+	if n := calls.Load(); n != 1 {
+		t.Errorf("got %d calls within the TTL, want 1", n)
+	}
+	time.Sleep(60 * time.Millisecond)
+	c.Run(context.Background(), false)
+	if n := calls.Load(); n != 2 {
+		t.Errorf("got %d calls after the TTL, want 2", n)
+	}
+
+	// Results of checks cut short by the request are not cached.
+	var ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+	time.Sleep(60 * time.Millisecond)
+	if report := c.Run(ctx, false); report.Status != StatusFail {
+		t.Errorf("got status %s for a cancelled run, want %s", report.Status, StatusFail)
+	}
+	if report := c.Run(context.Background(), false); report.Status != StatusOK {
+		t.Errorf("got status %s, the result of a cancelled run should not be cached", report.Status)
+	}
+}
+
+func TestLivezOnlyRunsLivenessChecks(t *testing.T) {
+	var c = New()
+	c.Add("db", func(ctx context.Context) error { return errors.New("down") })
+	c.AddLiveness("deadlock", func(ctx context.Context) error { return nil })
+
+	if w := serve(c.Livez); w.Code != http.StatusOK {
+		t.Errorf("livez: got status %d, want 200", w.Code)
+	}
+	if w := serve(c.Healthz); w.Code != http.StatusServiceUnavailable {
+		t.Errorf("healthz: got status %d, want 503", w.Code)
+	}
+}
+
+func TestErrorsAreHidden(t *testing.T) {
+	var c = New()
+	c.Add("db", func(ctx context.Context) error {
+		return errors.New("dial tcp db.internal:5432: connection refused")
+	})
+
+	for _, handler := range []func(*request.Request){c.Healthz, c.Readyz} {
+		var w = serve(handler)
+		if w.Code != http.StatusServiceUnavailable {
+			t.Errorf("got status %d, want 503", w.Code)
+		}
+		if strings.Contains(w.Body.String(), "db.internal") {
+			t.Errorf("the error should not be shown: %s", w.Body)
+		}
+	}
+	if report := c.Run(context.Background(), false); report.Checks["db"].Error == "" {
+		t.Error("Run should return the error")
+	}
+
+	c.ShowErrors = true
+	if w := serve(c.Healthz); !strings.Contains(w.Body.String(), "db.internal") {
+		t.Errorf("the error should be shown with ShowErrors: %s", w.Body)
+	}
+}
+
+func TestReservedNames(t *testing.T) {
+	var c = New()
+	c.Add("db", func(ctx context.Context) error { return nil })
+	for _, name := range []string{ServerCheck, "db"} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("adding a check named %q should panic", name)
+				}
+			}()
+			c.Add(name, func(ctx context.Context) error { return nil })
+		}()
+	}
+}
+
+func TestReadyzDuringShutdown(t *testing.T) {
+	var r = router.NewRouter(true)
+	var srv = server.New(r, server.Options{Addr: "127.0.0.1:0", DrainDelay: 200 * time.Millisecond})
+	var c = New()
+	c.Ready = srv.Ready
+	c.Add("db", func(ctx context.Context) error { return nil })
+
+	var readyz = func() (int, Report) {
+		var w = serve(c.Readyz)
+		var report Report
+		if err := json.Unmarshal(w.Body.Bytes(), &report); err != nil {
+			t.Fatal(err)
+		}
+		return w.Code, report
+	}
+
+	if code, _ := readyz(); code != http.StatusServiceUnavailable {
+		t.Errorf("before serving: got status %d, want 503", code)
+	}
+
+	var done = make(chan error, 1)
+	go func() {
+		done <- srv.Serve(context.Background())
+	}()
+	for i := 0; i < 100 && !srv.Ready(); i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if code, report := readyz(); code != http.StatusOK || report.Checks[ServerCheck].Status != "" {
+		t.Fatalf("while serving: got status %d, report %+v", code, report)
+	}
+
+	var shutdown = make(chan error, 1)
+	go func() {
+		shutdown <- srv.Shutdown(context.Background())
+	}()
+	for i := 0; i < 100 && !srv.ShuttingDown(); i++ {
+		time.Sleep(time.Millisecond)
+	}
+	// The server is still draining, but readiness already fails.
+	var code, report = readyz()
+	if code != http.StatusServiceUnavailable || report.Checks[ServerCheck].Status != StatusFail || report.Checks["db"].Status != StatusOK {
+		t.Errorf("during shutdown: got status %d, report %+v", code, report)
+	}
+	select {
+	case <-shutdown:
+		t.Error("Shutdown returned before the drain delay")
+	default:
+	}
+	if err := <-shutdown; err != nil {
+		t.Fatal(err)
+	}
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+}
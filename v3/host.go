@@ -0,0 +1,173 @@
+package router
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/Nigel2392/router/v3/request/params"
+)
+
+// Passed as host by Router.Match, to match routes of every host.
+//
+// Only the router passes it internally, request hosts equal to it are replaced by requestHost,
+// and host patterns never treat it as a wildcard.
+const anyHost = "*"
+
+// A host pattern like "{tenant}.example.com".
+type hostPattern struct {
+	pattern string
+	regex   *regexp.Regexp
+	vars    []string
+}
+
+var hostPatterns sync.Map
+
+// Returns the compiled host pattern.
+//
+// Every label of the pattern is either a literal, or a variable like {tenant} matching a single label.
+func compileHost(pattern string) *hostPattern {
+	if p, ok := hostPatterns.Load(pattern); ok {
+		return p.(*hostPattern)
+	}
+	var p = &hostPattern{pattern: pattern}
+	var labels = strings.Split(pattern, ".")
+	for i, label := range labels {
+		if strings.HasPrefix(label, "{") && strings.HasSuffix(label, "}") {
+			p.vars = append(p.vars, label[1:len(label)-1])
+			labels[i] = "([^.]+)"
+			continue
+		}
+		labels[i] = regexp.QuoteMeta(label)
+	}
+	p.regex = regexp.MustCompile("(?i)^" + strings.Join(labels, `\.`) + "$")
+	hostPatterns.Store(pattern, p)
+	return p
+}
+
+// Returns the host variables, if the host matches the pattern.
+func (p *hostPattern) match(host string) (params.URLParams, bool) {
+	var m = p.regex.FindStringSubmatch(stripPort(host))
+	if m == nil {
+		return nil, false
+	}
+	var vars = make(params.URLParams, len(p.vars))
+	for i, name := range p.vars {
+		vars[name] = strings.ToLower(m[i+1])
+	}
+	return vars, true
+}
+
+// Returns the host, with the variables replaced by the arguments.
+func (p *hostPattern) format(args map[string]any) (string, error) {
+	var labels = strings.Split(p.pattern, ".")
+	for i, label := range labels {
+		if !strings.HasPrefix(label, "{") || !strings.HasSuffix(label, "}") {
+			continue
+		}
+		var name = label[1 : len(label)-1]
+		var v, ok = args[name]
+		if !ok {
+			return "", fmt.Errorf("%w %q", ErrMissingArgument, name)
+		}
+		var value = fmt.Sprint(v)
+		if value == "" || strings.ContainsAny(value, "./:") {
+			return "", fmt.Errorf("%w %q: %q is not a host label", ErrInvalidArgument, name, value)
+		}
+		labels[i] = value
+	}
+	return strings.Join(labels, "."), nil
+}
+
+func (p *hostPattern) hasVariable(name string) bool {
+	for _, v := range p.vars {
+		if v == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns the host of the request, which never matches every host like anyHost.
+func requestHost(rq *http.Request) string {
+	if rq.Host == anyHost {
+		return ""
+	}
+	return rq.Host
+}
+
+func stripPort(host string) string {
+	if i := strings.LastIndexByte(host, ':'); i != -1 && !strings.HasSuffix(host, "]") {
+		return host[:i]
+	}
+	return host
+}
+
+// MatchHost reports whether the host matches a pattern like "{tenant}.example.com",
+// and returns the values of the pattern's variables.
+//
+// Hosts are matched case-insensitively, and the port of the host is ignored.
+func MatchHost(pattern, host string) (params.URLParams, bool) {
+	return compileHost(pattern).match(host)
+}
+
+// Host returns a group of routes which only match requests for the host pattern.
+//
+// Variables of the pattern, like {tenant} in "{tenant}.example.com", match a single label of the host,
+// and are added to the URL parameters of the request.
+//
+// Routes bound to a host are matched before routes which are not, regardless of the order they were registered in.
+//
+//	var tenant = r.Host("{tenant}.example.com", "tenant")
+//	tenant.Get("/", dashboardFunc, "dashboard")
+func (r *Router) Host(pattern string, name ...string) Registrar {
+	var route = &Route{host: compileHost(pattern), middlewareEnabled: true, source: registeredAt(), owner: r}
+	if len(name) > 0 {
+		route.name = name[0]
+	}
+	r.routes = append(r.routes, route)
+	r.invalidateChains()
+	return route
+}
+
+// Hosts returns the host patterns of the router, for use with the AllowedHosts middleware.
+func (r *Router) Hosts() []string {
+	var hosts = make([]string, 0)
+	r.Walk(func(route *Route, depth int) {
+		if route.host != nil {
+			hosts = append(hosts, route.host.pattern)
+		}
+	})
+	return hosts
+}
+
+// MatchHost returns the route that matches the given method, host and path.
+//
+// Match ignores the host patterns of routes, MatchHost does not.
+func (r *Router) MatchHost(method, host, path string) (bool, *Route, params.URLParams) {
+	if host == anyHost {
+		host = ""
+	}
+	var route, vars, _ = r.match(method, host, path, nil)
+	return route != nil, route, vars
+}
+
+// Host returns the host pattern the route is bound to, or an empty string.
+func (r *Route) Host() string {
+	if p := r.hostPattern(); p != nil {
+		return p.pattern
+	}
+	return ""
+}
+
+// Returns the host pattern of the route, or of its closest parent with a host pattern.
+func (r *Route) hostPattern() *hostPattern {
+	for route := r; route != nil; route = route.parent {
+		if route.host != nil {
+			return route.host
+		}
+	}
+	return nil
+}
@@ -0,0 +1,136 @@
+package router
+
+import (
+	"errors"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Nigel2392/router/v3/request"
+	"github.com/Nigel2392/router/v3/request/params"
+)
+
+func TestMatchHost(t *testing.T) {
+	var tests = []struct {
+		pattern string
+		host    string
+		match   bool
+		tenant  string
+	}{
+		{"{tenant}.example.com", "acme.example.com", true, "acme"},
+		{"{tenant}.example.com", "ACME.example.com:8080", true, "acme"},
+		{"{tenant}.example.com", "a.b.example.com", false, ""},
+		{"{tenant}.example.com", "example.com", false, ""},
+		{"{tenant}.example.com", "*", false, ""},
+		{"example.com", "*", false, ""},
+		{"example.com", "", false, ""},
+	}
+	for _, test := range tests {
+		var vars, ok = MatchHost(test.pattern, test.host)
+		if ok != test.match {
+			t.Errorf("MatchHost(%q, %q) = %v, want %v", test.pattern, test.host, ok, test.match)
+			continue
+		}
+		if ok && vars.Get("tenant") != test.tenant {
+			t.Errorf("MatchHost(%q, %q) tenant = %q, want %q", test.pattern, test.host, vars.Get("tenant"), test.tenant)
+		}
+	}
+}
+
+func TestHostRoutesRejectWildcardHost(t *testing.T) {
+	var r = NewRouter(true)
+	r.Host("admin.example.com").Get("/", HandleFunc(func(r *request.Request) {
+		r.WriteString("admin")
+	}))
+
+	for _, host := range []string{"*", "", "other.example.com"} {
+		var rq = httptest.NewRequest("GET", "/", nil)
+		rq.Host = host
+		var w = httptest.NewRecorder()
+		r.ServeHTTP(w, rq)
+		if w.Code != 404 {
+			t.Errorf("Host %q: got status %d, want 404", host, w.Code)
+		}
+		if ok, _, _ := r.MatchHost("GET", host, "/"); ok {
+			t.Errorf("MatchHost with host %q matched a host route", host)
+		}
+	}
+
+	if ok, _, _ := r.Match("GET", "/"); !ok {
+		t.Error("Match should ignore host patterns")
+	}
+}
+
+func TestHostRoutesMatchFirst(t *testing.T) {
+	var r = NewRouter(true)
+	r.Get("/", HandleFunc(func(r *request.Request) {
+		r.WriteString("any")
+	}))
+	r.Host("admin.example.com").Get("/", HandleFunc(func(r *request.Request) {
+		r.WriteString("admin")
+	}))
+
+	for host, want := range map[string]string{"admin.example.com": "admin", "example.com": "any"} {
+		var rq = httptest.NewRequest("GET", "/", nil)
+		rq.Host = host
+		var w = httptest.NewRecorder()
+		r.ServeHTTP(w, rq)
+		if w.Body.String() != want {
+			t.Errorf("Host %q: got %q, want %q", host, w.Body.String(), want)
+		}
+	}
+	if err := r.Validate(); err != nil {
+		t.Errorf("a host route registered later is not shadowed: %v", err)
+	}
+}
+
+func TestHostVariablesInURLParams(t *testing.T) {
+	var r = NewRouter(true)
+	var got params.URLParams
+	r.Host("{tenant}.example.com").Get("/users/<<id:int>>", HandleFunc(func(r *request.Request) {
+		got = r.URLParams
+	}))
+
+	var rq = httptest.NewRequest("GET", "/users/42", nil)
+	rq.Host = "Acme.example.com:8080"
+	r.ServeHTTP(httptest.NewRecorder(), rq)
+	if got.Get("tenant") != "acme" || got.Get("id") != "42" {
+		t.Errorf("got URL parameters %v, want tenant and id", got)
+	}
+
+	if ok, _, vars := r.MatchHost("GET", "acme.example.com", "/users/42"); !ok || vars.Get("tenant") != "acme" {
+		t.Errorf("MatchHost: got %v, %v", ok, vars)
+	}
+}
+
+func TestReverseHost(t *testing.T) {
+	var r = NewRouter(true)
+	var tenant = r.Host("{tenant}.example.com", "tenant")
+	tenant.Get("/users/<<id:int>>", HandleFunc(func(r *request.Request) {}), "user")
+	r.Get("/about", HandleFunc(func(r *request.Request) {}), "about")
+
+	var args = map[string]any{"tenant": "acme", "id": 42}
+	if path, err := r.Reverse("tenant:user", args, nil); err != nil || path != "/users/42" {
+		t.Errorf("Reverse: got %q, %v", path, err)
+	}
+
+	var tests = []struct {
+		name    string
+		baseURL string
+		args    map[string]any
+		want    string
+		err     error
+	}{
+		{"tenant:user", "", args, "https://acme.example.com/users/42", nil},
+		{"tenant:user", "http://localhost:8080", args, "http://acme.example.com/users/42", nil},
+		{"tenant:user", "", map[string]any{"id": 42}, "", ErrMissingArgument},
+		{"tenant:user", "", map[string]any{"tenant": "a.b", "id": 42}, "", ErrInvalidArgument},
+		{"about", "https://example.com/", nil, "https://example.com/about", nil},
+	}
+	for _, test := range tests {
+		r.BaseURL = test.baseURL
+		var got, err = r.AbsoluteURL(test.name, test.args, nil)
+		if !errors.Is(err, test.err) || got != test.want {
+			t.Errorf("AbsoluteURL(%q, %v) with BaseURL %q: got %q, %v, want %q, %v", test.name, test.args, test.baseURL, got, err, test.want, test.err)
+		}
+	}
+}
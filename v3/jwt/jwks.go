@@ -0,0 +1,159 @@
+package jwt
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+
+	"github.com/Nigel2392/router/v3"
+	"github.com/Nigel2392/router/v3/request"
+)
+
+// JWK is a single JSON Web Key, as described in RFC 7517.
+//
+// Only public keys are ever encoded.
+type JWK struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid,omitempty"`
+	Use       string `json:"use,omitempty"`
+	Algorithm string `json:"alg,omitempty"`
+
+	// RSA
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+
+	// EC and OKP
+	Curve string `json:"crv,omitempty"`
+	X     string `json:"x,omitempty"`
+	Y     string `json:"y,omitempty"`
+}
+
+// JWKS is a JSON Web Key Set document.
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS returns the public keys of the set as a JWKS document.
+//
+// HS256 keys are secret, and are never included.
+func (ks *KeySet) JWKS() *JWKS {
+	var doc = &JWKS{Keys: make([]JWK, 0)}
+	for _, k := range ks.Keys() {
+		var jwk = JWK{KeyID: k.ID, Use: "sig", Algorithm: string(k.Algorithm)}
+		switch pub := k.public.(type) {
+		case *rsa.PublicKey:
+			jwk.KeyType = "RSA"
+			jwk.N = b64.EncodeToString(pub.N.Bytes())
+			jwk.E = b64.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+		case *ecdsa.PublicKey:
+			var x, y = make([]byte, 32), make([]byte, 32)
+			pub.X.FillBytes(x)
+			pub.Y.FillBytes(y)
+			jwk.KeyType = "EC"
+			jwk.Curve = "P-256"
+			jwk.X = b64.EncodeToString(x)
+			jwk.Y = b64.EncodeToString(y)
+		case ed25519.PublicKey:
+			jwk.KeyType = "OKP"
+			jwk.Curve = "Ed25519"
+			jwk.X = b64.EncodeToString(pub)
+		default:
+			continue
+		}
+		doc.Keys = append(doc.Keys, jwk)
+	}
+	return doc
+}
+
+// ParseJWKS parses a JWKS document into a verification-only key set.
+//
+// Keys of unsupported types are skipped.
+func ParseJWKS(data []byte) (*KeySet, error) {
+	var doc JWKS
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	var ks = NewKeySet()
+	for _, jwk := range doc.Keys {
+		var key, err = jwk.Key()
+		if err == ErrUnsupportedKey {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		ks.Add(key)
+	}
+	return ks, nil
+}
+
+// Key returns the verification key for the JWK.
+func (j *JWK) Key() (*Key, error) {
+	switch {
+	case j.KeyType == "RSA":
+		var n, err = b64.DecodeString(j.N)
+		if err != nil {
+			return nil, fmt.Errorf("jwt: invalid JWK %q: %w", j.KeyID, err)
+		}
+		e, err := b64.DecodeString(j.E)
+		if err != nil {
+			return nil, fmt.Errorf("jwt: invalid JWK %q: %w", j.KeyID, err)
+		}
+		return NewPublicKey(j.KeyID, &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		})
+	case j.KeyType == "EC" && j.Curve == "P-256":
+		var x, err = b64.DecodeString(j.X)
+		if err != nil {
+			return nil, fmt.Errorf("jwt: invalid JWK %q: %w", j.KeyID, err)
+		}
+		y, err := b64.DecodeString(j.Y)
+		if err != nil {
+			return nil, fmt.Errorf("jwt: invalid JWK %q: %w", j.KeyID, err)
+		}
+		var pub = &ecdsa.PublicKey{
+			Curve: elliptic.P256(),
+			X:     new(big.Int).SetBytes(x),
+			Y:     new(big.Int).SetBytes(y),
+		}
+		if !pub.Curve.IsOnCurve(pub.X, pub.Y) {
+			return nil, fmt.Errorf("jwt: invalid JWK %q: point is not on curve", j.KeyID)
+		}
+		return NewPublicKey(j.KeyID, pub)
+	case j.KeyType == "OKP" && j.Curve == "Ed25519":
+		var x, err = b64.DecodeString(j.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("jwt: invalid JWK %q", j.KeyID)
+		}
+		return NewPublicKey(j.KeyID, ed25519.PublicKey(x))
+	}
+	return nil, ErrUnsupportedKey
+}
+
+// JWKSHandler returns a handler which serves the public keys of the key set.
+//
+// The document is generated on every request, so rotated keys are served immediately.
+func JWKSHandler(keys *KeySet) func(r *request.Request) {
+	return func(r *request.Request) {
+		var data, err = json.Marshal(keys.JWKS())
+		if err != nil {
+			r.Error(http.StatusInternalServerError, err.Error())
+			return
+		}
+		r.Response.Header().Set("Content-Type", "application/jwk-set+json")
+		r.Response.Header().Set("Cache-Control", "public, max-age=300")
+		r.Response.Write(data)
+	}
+}
+
+// JWKSRoute returns a route which serves the JWKS document.
+//
+// The conventional path is /.well-known/jwks.json.
+func JWKSRoute(path string, name string, keys *KeySet) router.Registrar {
+	return router.NewRoute(router.GET, path, name, JWKSHandler(keys))
+}
@@ -0,0 +1,335 @@
+package jwt
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Default errors returned when signing or verifying tokens.
+var (
+	ErrMalformed       = errors.New("jwt: malformed token")
+	ErrSignature       = errors.New("jwt: invalid signature")
+	ErrAlgorithm       = errors.New("jwt: unexpected signing algorithm")
+	ErrKeyNotFound     = errors.New("jwt: key not found")
+	ErrUnsupportedKey  = errors.New("jwt: unsupported key type")
+	ErrCannotSign      = errors.New("jwt: key can not be used for signing")
+	ErrExpired         = errors.New("jwt: token is expired")
+	ErrNotYetValid     = errors.New("jwt: token is not valid yet")
+	ErrMissingExpiry   = errors.New("jwt: token has no expiry")
+	ErrInvalidIssuer   = errors.New("jwt: invalid issuer")
+	ErrInvalidAudience = errors.New("jwt: invalid audience")
+)
+
+var b64 = base64.RawURLEncoding
+
+// Header of a token.
+type Header struct {
+	Algorithm Algorithm `json:"alg"`
+	Type      string    `json:"typ,omitempty"`
+	KeyID     string    `json:"kid,omitempty"`
+}
+
+// Audience is the aud claim.
+//
+// It is encoded as a string when it holds a single value, as allowed by RFC 7519.
+type Audience []string
+
+// Contains reports whether the audience contains the value.
+func (a Audience) Contains(aud string) bool {
+	for _, v := range a {
+		if v == aud {
+			return true
+		}
+	}
+	return false
+}
+
+func (a Audience) MarshalJSON() ([]byte, error) {
+	if len(a) == 1 {
+		return json.Marshal(a[0])
+	}
+	return json.Marshal([]string(a))
+}
+
+func (a *Audience) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*a = Audience{s}
+		return nil
+	}
+	var l []string
+	if err := json.Unmarshal(data, &l); err != nil {
+		return err
+	}
+	*a = l
+	return nil
+}
+
+// Claims of a token.
+//
+// Registered claims are available as fields,
+// any other claims are stored in Extra.
+type Claims struct {
+	Issuer    string
+	Subject   string
+	Audience  Audience
+	ExpiresAt time.Time
+	NotBefore time.Time
+	IssuedAt  time.Time
+	ID        string
+
+	// Private claims, for example permissions.
+	Extra map[string]any
+}
+
+// NewClaims returns claims for the subject, which expire after the given duration.
+func NewClaims(subject string, expiresIn time.Duration) *Claims {
+	var now = time.Now()
+	return &Claims{
+		Subject:   subject,
+		IssuedAt:  now,
+		ExpiresAt: now.Add(expiresIn),
+		Extra:     make(map[string]any),
+	}
+}
+
+// Set a private claim.
+func (c *Claims) Set(key string, value any) {
+	if c.Extra == nil {
+		c.Extra = make(map[string]any)
+	}
+	c.Extra[key] = value
+}
+
+// Get a private claim.
+func (c *Claims) Get(key string) any {
+	return c.Extra[key]
+}
+
+func (c *Claims) MarshalJSON() ([]byte, error) {
+	var m = make(map[string]any, len(c.Extra)+7)
+	for k, v := range c.Extra {
+		m[k] = v
+	}
+	if c.Issuer != "" {
+		m["iss"] = c.Issuer
+	}
+	if c.Subject != "" {
+		m["sub"] = c.Subject
+	}
+	if len(c.Audience) > 0 {
+		m["aud"] = c.Audience
+	}
+	if !c.ExpiresAt.IsZero() {
+		m["exp"] = c.ExpiresAt.Unix()
+	}
+	if !c.NotBefore.IsZero() {
+		m["nbf"] = c.NotBefore.Unix()
+	}
+	if !c.IssuedAt.IsZero() {
+		m["iat"] = c.IssuedAt.Unix()
+	}
+	if c.ID != "" {
+		m["jti"] = c.ID
+	}
+	return json.Marshal(m)
+}
+
+func (c *Claims) UnmarshalJSON(data []byte) error {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	c.Extra = make(map[string]any)
+	for k, v := range raw {
+		var err error
+		switch k {
+		case "iss":
+			err = json.Unmarshal(v, &c.Issuer)
+		case "sub":
+			err = json.Unmarshal(v, &c.Subject)
+		case "aud":
+			err = json.Unmarshal(v, &c.Audience)
+		case "exp":
+			c.ExpiresAt, err = numericDate(v)
+		case "nbf":
+			c.NotBefore, err = numericDate(v)
+		case "iat":
+			c.IssuedAt, err = numericDate(v)
+		case "jti":
+			err = json.Unmarshal(v, &c.ID)
+		default:
+			var value any
+			err = json.Unmarshal(v, &value)
+			c.Extra[k] = value
+		}
+		if err != nil {
+			return fmt.Errorf("%w: claim %q: %v", ErrMalformed, k, err)
+		}
+	}
+	return nil
+}
+
+func numericDate(data []byte) (time.Time, error) {
+	var f float64
+	if err := json.Unmarshal(data, &f); err != nil {
+		return time.Time{}, err
+	}
+	return time.Unix(int64(f), 0), nil
+}
+
+// ValidationOptions configure which claims are checked when verifying a token.
+//
+// The exp and nbf claims are always checked when they are present.
+type ValidationOptions struct {
+	// Expected issuer, checked when not empty.
+	Issuer string
+
+	// Expected audience, checked when not empty.
+	// The token's audience must contain this value.
+	Audience string
+
+	// Require the exp claim to be set.
+	RequireExpiry bool
+
+	// Allowed clock skew when checking exp and nbf.
+	Leeway time.Duration
+
+	// Function returning the current time, defaults to time.Now.
+	Now func() time.Time
+}
+
+// Validate the claims.
+func (o *ValidationOptions) Validate(c *Claims) error {
+	if o == nil {
+		o = &ValidationOptions{}
+	}
+	var now = time.Now()
+	if o.Now != nil {
+		now = o.Now()
+	}
+	if c.ExpiresAt.IsZero() && o.RequireExpiry {
+		return ErrMissingExpiry
+	}
+	if !c.ExpiresAt.IsZero() && !now.Before(c.ExpiresAt.Add(o.Leeway)) {
+		return ErrExpired
+	}
+	if !c.NotBefore.IsZero() && now.Add(o.Leeway).Before(c.NotBefore) {
+		return ErrNotYetValid
+	}
+	if o.Issuer != "" && c.Issuer != o.Issuer {
+		return ErrInvalidIssuer
+	}
+	if o.Audience != "" && !c.Audience.Contains(o.Audience) {
+		return ErrInvalidAudience
+	}
+	return nil
+}
+
+// Sign the claims with the given key, returning the compact token.
+func Sign(claims *Claims, key *Key) (string, error) {
+	var header = Header{Algorithm: key.Algorithm, Type: "JWT", KeyID: key.ID}
+	var headerJSON, err = json.Marshal(header)
+	if err != nil {
+		return "", err
+	}
+	claimsJSON, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	b.WriteString(b64.EncodeToString(headerJSON))
+	b.WriteByte('.')
+	b.WriteString(b64.EncodeToString(claimsJSON))
+	sig, err := key.sign([]byte(b.String()))
+	if err != nil {
+		return "", err
+	}
+	b.WriteByte('.')
+	b.WriteString(b64.EncodeToString(sig))
+	return b.String(), nil
+}
+
+// Verify the token with a key from the key set, and validate its claims.
+//
+// The key is selected by the kid header.
+// If the token has no kid, every key with the token's algorithm is tried,
+// so key sets without a signing key, like those returned by ParseJWKS, can verify it.
+//
+// The alg header must match the algorithm of the key,
+// tokens with "alg": "none" are never accepted.
+func Verify(token string, keys *KeySet, opts *ValidationOptions) (*Claims, error) {
+	var parts = strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrMalformed
+	}
+	var header Header
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, err
+	}
+
+	var candidates []*Key
+	if header.KeyID != "" {
+		var key, ok = keys.Key(header.KeyID)
+		if !ok {
+			return nil, fmt.Errorf("%w: %q", ErrKeyNotFound, header.KeyID)
+		}
+		if header.Algorithm != key.Algorithm {
+			return nil, ErrAlgorithm
+		}
+		candidates = []*Key{key}
+	} else {
+		for _, key := range keys.Keys() {
+			if key.Algorithm == header.Algorithm {
+				candidates = append(candidates, key)
+			}
+		}
+		if len(candidates) == 0 {
+			return nil, ErrAlgorithm
+		}
+	}
+
+	sig, err := b64.DecodeString(parts[2])
+	if err != nil {
+		return nil, ErrMalformed
+	}
+	var verified bool
+	for _, key := range candidates {
+		if key.verify([]byte(parts[0]+"."+parts[1]), sig) {
+			verified = true
+			break
+		}
+	}
+	if !verified {
+		return nil, ErrSignature
+	}
+
+	var claims = &Claims{}
+	if err := decodeSegment(parts[1], claims); err != nil {
+		return nil, err
+	}
+	if err := opts.Validate(claims); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+func decodeSegment(seg string, v any) error {
+	var data, err = b64.DecodeString(seg)
+	if err != nil {
+		return ErrMalformed
+	}
+	var dec = json.NewDecoder(bytes.NewReader(data))
+	if err := dec.Decode(v); err != nil {
+		if errors.Is(err, ErrMalformed) {
+			return err
+		}
+		return fmt.Errorf("%w: %v", ErrMalformed, err)
+	}
+	return nil
+}
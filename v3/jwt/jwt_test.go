@@ -0,0 +1,293 @@
+package jwt
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Nigel2392/router/v3/auth"
+	"github.com/Nigel2392/router/v3/request"
+	"github.com/Nigel2392/router/v3/request/writer"
+)
+
+func testKeys(t *testing.T, id string) map[Algorithm]*Key {
+	t.Helper()
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	es256, err := NewECDSAKey(id, ecKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var secret = make([]byte, 32)
+	rand.Read(secret)
+	return map[Algorithm]*Key{
+		HS256: NewHMACKey(id, secret),
+		RS256: NewRSAKey(id, rsaKey),
+		ES256: es256,
+		EdDSA: NewEd25519Key(id, edKey),
+	}
+}
+
+// Signs the claims with the key, with a custom header.
+func signWithHeader(t *testing.T, header Header, claims *Claims, key *Key) string {
+	t.Helper()
+	var h, _ = json.Marshal(header)
+	var c, _ = json.Marshal(claims)
+	var data = b64.EncodeToString(h) + "." + b64.EncodeToString(c)
+	var sig, err = key.sign([]byte(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data + "." + b64.EncodeToString(sig)
+}
+
+// Returns a verification-only key set, as a client fetching the JWKS would have it.
+func publicKeySet(t *testing.T, keys ...*Key) *KeySet {
+	t.Helper()
+	var data, err = json.Marshal(NewKeySet(keys...).JWKS())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ks, err := ParseJWKS(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ks
+}
+
+func TestSignVerify(t *testing.T) {
+	var keys = testKeys(t, "key")
+	var unnamed = testKeys(t, "")
+	var other = testKeys(t, "key")
+
+	for alg, key := range keys {
+		t.Run(string(alg), func(t *testing.T) {
+			var claims = NewClaims("user", time.Hour)
+			var ks = NewKeySet(key)
+			var token, err = ks.Sign(claims)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var parts = strings.Split(token, ".")
+
+			// An algorithm of another type, with the same kid.
+			var mismatched = RS256
+			if alg == RS256 {
+				mismatched = ES256
+			}
+
+			type verifyTest struct {
+				name  string
+				token string
+				keys  *KeySet
+				err   error
+			}
+			var tests = []verifyTest{
+				{"valid", token, ks, nil},
+				{"tampered signature", parts[0] + "." + parts[1] + "." + b64.EncodeToString([]byte("signature")), ks, ErrSignature},
+				{"tampered claims", parts[0] + "." + b64.EncodeToString([]byte(`{"sub":"admin"}`)) + "." + parts[2], ks, ErrSignature},
+				{"other key", token, NewKeySet(other[alg]), ErrSignature},
+				{"unknown kid", token, NewKeySet(unnamed[alg]), ErrKeyNotFound},
+				{"alg mismatch", signWithHeader(t, Header{Algorithm: mismatched, KeyID: "key"}, claims, key), ks, ErrAlgorithm},
+				{"alg none", signWithHeader(t, Header{Algorithm: "none"}, claims, key), ks, ErrAlgorithm},
+				{"expired", signWithHeader(t, Header{Algorithm: alg, KeyID: "key"}, NewClaims("user", -time.Minute), key), ks, ErrExpired},
+				{"missing kid", signWithHeader(t, Header{Algorithm: alg}, claims, unnamed[alg]), NewKeySet(key, unnamed[alg]), nil},
+				{"missing kid, no matching key", signWithHeader(t, Header{Algorithm: alg}, claims, other[alg]), NewKeySet(key), ErrSignature},
+				{"malformed", "a.b", ks, ErrMalformed},
+			}
+			if alg != HS256 {
+				var public = publicKeySet(t, key)
+				tests = append(tests,
+					verifyTest{"public key set", token, public, nil},
+					verifyTest{"public key set, missing kid", signWithHeader(t, Header{Algorithm: alg}, claims, key), public, nil},
+				)
+			}
+
+			for _, test := range tests {
+				var got, err = Verify(test.token, test.keys, nil)
+				if !errors.Is(err, test.err) {
+					t.Errorf("%s: got error %v, want %v", test.name, err, test.err)
+					continue
+				}
+				if err == nil && got.Subject != "user" {
+					t.Errorf("%s: got subject %q, want %q", test.name, got.Subject, "user")
+				}
+			}
+		})
+	}
+}
+
+func TestValidateClaims(t *testing.T) {
+	var now = time.Date(2023, 1, 1, 12, 0, 0, 0, time.UTC)
+	var at = func() time.Time { return now }
+	var tests = []struct {
+		name   string
+		claims *Claims
+		opts   *ValidationOptions
+		err    error
+	}{
+		{"valid", &Claims{ExpiresAt: now.Add(time.Minute)}, &ValidationOptions{Now: at}, nil},
+		{"expired", &Claims{ExpiresAt: now}, &ValidationOptions{Now: at}, ErrExpired},
+		{"expired within leeway", &Claims{ExpiresAt: now.Add(-time.Second)}, &ValidationOptions{Now: at, Leeway: time.Minute}, nil},
+		{"not yet valid", &Claims{NotBefore: now.Add(time.Minute)}, &ValidationOptions{Now: at}, ErrNotYetValid},
+		{"not yet valid within leeway", &Claims{NotBefore: now.Add(time.Second)}, &ValidationOptions{Now: at, Leeway: time.Minute}, nil},
+		{"missing expiry", &Claims{}, &ValidationOptions{Now: at, RequireExpiry: true}, ErrMissingExpiry},
+		{"issuer", &Claims{Issuer: "https://issuer"}, &ValidationOptions{Now: at, Issuer: "https://issuer"}, nil},
+		{"wrong issuer", &Claims{Issuer: "https://other"}, &ValidationOptions{Now: at, Issuer: "https://issuer"}, ErrInvalidIssuer},
+		{"audience", &Claims{Audience: Audience{"a", "b"}}, &ValidationOptions{Now: at, Audience: "b"}, nil},
+		{"wrong audience", &Claims{Audience: Audience{"a"}}, &ValidationOptions{Now: at, Audience: "b"}, ErrInvalidAudience},
+		{"no options", &Claims{}, nil, nil},
+	}
+	for _, test := range tests {
+		if err := test.opts.Validate(test.claims); !errors.Is(err, test.err) {
+			t.Errorf("%s: got error %v, want %v", test.name, err, test.err)
+		}
+	}
+}
+
+func TestClaimsJSON(t *testing.T) {
+	var claims = NewClaims("user", time.Hour)
+	claims.Audience = Audience{"app"}
+	claims.Set("permissions", []string{"read"})
+	var data, err = json.Marshal(claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"aud":"app"`) {
+		t.Errorf("a single audience should be encoded as a string: %s", data)
+	}
+	var decoded Claims
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Subject != "user" || !decoded.Audience.Contains("app") || decoded.ExpiresAt.Unix() != claims.ExpiresAt.Unix() {
+		t.Errorf("got claims %+v, want %+v", decoded, claims)
+	}
+	if perms, ok := decoded.Get("permissions").([]any); !ok || len(perms) != 1 {
+		t.Errorf("got permissions %v", decoded.Get("permissions"))
+	}
+}
+
+func TestKeyRotation(t *testing.T) {
+	var old, current = testKeys(t, "old")[ES256], testKeys(t, "new")[ES256]
+	var ks = NewKeySet(old)
+	var oldToken, err = ks.Sign(NewClaims("user", time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ks.Add(current)
+	if err := ks.SetActive("new"); err != nil {
+		t.Fatal(err)
+	}
+	newToken, err := ks.Sign(NewClaims("user", time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if header, _ := b64.DecodeString(strings.Split(newToken, ".")[0]); !strings.Contains(string(header), `"kid":"new"`) {
+		t.Errorf("new tokens should be signed with the active key: %s", header)
+	}
+	for _, token := range []string{oldToken, newToken} {
+		if _, err := ks.Verify(token, nil); err != nil {
+			t.Errorf("tokens signed with both keys should verify during rotation: %v", err)
+		}
+	}
+
+	ks.Remove("old")
+	if _, err := ks.Verify(oldToken, nil); !errors.Is(err, ErrKeyNotFound) {
+		t.Errorf("got error %v after removing the old key, want ErrKeyNotFound", err)
+	}
+	if _, err := ks.Verify(newToken, nil); err != nil {
+		t.Error(err)
+	}
+
+	if err := publicKeySet(t, current).SetActive("new"); !errors.Is(err, ErrCannotSign) {
+		t.Errorf("got error %v activating a public key, want ErrCannotSign", err)
+	}
+	if _, err := publicKeySet(t, current).Sign(NewClaims("user", time.Hour)); err == nil {
+		t.Error("a public key set should not sign tokens")
+	}
+}
+
+func TestJWKSRoundTrip(t *testing.T) {
+	var keys = testKeys(t, "key")
+	var ks = NewKeySet(keys[HS256])
+	for _, alg := range []Algorithm{RS256, ES256, EdDSA} {
+		var key = keys[alg]
+		key.ID = string(alg)
+		ks.Add(key)
+	}
+	var doc = ks.JWKS()
+	if len(doc.Keys) != 3 {
+		t.Fatalf("got %d keys, want 3, HS256 secrets must not be published", len(doc.Keys))
+	}
+	var public = publicKeySet(t, ks.Keys()...)
+	for _, alg := range []Algorithm{RS256, ES256, EdDSA} {
+		var key, ok = public.Key(string(alg))
+		if !ok {
+			t.Errorf("%s: key not found", alg)
+			continue
+		}
+		if key.Algorithm != alg || key.CanSign() {
+			t.Errorf("%s: got algorithm %s, can sign %v", alg, key.Algorithm, key.CanSign())
+		}
+	}
+	if _, err := ParseJWKS([]byte(`{"keys":[{"kty":"EC","crv":"P-256","x":"AA","y":"AA"}]}`)); err == nil {
+		t.Error("a point which is not on the curve should be rejected")
+	}
+}
+
+func TestNewECDSAKeyCurve(t *testing.T) {
+	var key, err = ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewECDSAKey("key", key); !errors.Is(err, ErrUnsupportedKey) {
+		t.Errorf("got error %v, want ErrUnsupportedKey", err)
+	}
+}
+
+func TestAuthenticatorExtract(t *testing.T) {
+	var ks = NewKeySet(testKeys(t, "key")[HS256])
+	var token, err = ks.Sign(NewClaims("user", time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var a = Authenticator(&Options{
+		Keys: ks,
+		Extract: func(r *request.Request) (string, bool) {
+			var token = r.QueryParams.Get("token")
+			return token, token != ""
+		},
+	})
+
+	var rq = httptest.NewRequest("GET", "/?token="+token, nil)
+	user, err := a.Authenticate(request.NewRequest(writer.NewClearable(httptest.NewRecorder()), rq, nil))
+	if err != nil || user.(*User).Claims.Subject != "user" {
+		t.Errorf("got user %v, error %v", user, err)
+	}
+
+	rq = httptest.NewRequest("GET", "/", nil)
+	rq.Header.Set("Authorization", "Bearer "+token)
+	if _, err := a.Authenticate(request.NewRequest(writer.NewClearable(httptest.NewRecorder()), rq, nil)); !errors.Is(err, auth.ErrNoCredentials) {
+		t.Errorf("the Authorization header should be ignored when Extract is set, got %v", err)
+	}
+}
@@ -0,0 +1,277 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+)
+
+// Algorithm is a JWS signing algorithm.
+type Algorithm string
+
+// Supported signing algorithms.
+const (
+	HS256 Algorithm = "HS256"
+	RS256 Algorithm = "RS256"
+	ES256 Algorithm = "ES256"
+	EdDSA Algorithm = "EdDSA"
+)
+
+// Key is a single signing or verification key.
+//
+// Keys are identified by their ID, which is set as the "kid" header of a token.
+type Key struct {
+	// ID of the key, set as the kid header.
+	ID string
+
+	// Algorithm to sign and verify with.
+	Algorithm Algorithm
+
+	// Key used for signing.
+	// This is nil for verification-only keys.
+	//
+	// []byte for HS256, *rsa.PrivateKey, *ecdsa.PrivateKey or ed25519.PrivateKey.
+	private any
+
+	// Key used for verification.
+	//
+	// []byte for HS256, *rsa.PublicKey, *ecdsa.PublicKey or ed25519.PublicKey.
+	public any
+}
+
+// NewHMACKey returns a new HS256 key with the given secret.
+func NewHMACKey(id string, secret []byte) *Key {
+	return &Key{ID: id, Algorithm: HS256, private: secret, public: secret}
+}
+
+// NewRSAKey returns a new RS256 key.
+func NewRSAKey(id string, key *rsa.PrivateKey) *Key {
+	return &Key{ID: id, Algorithm: RS256, private: key, public: &key.PublicKey}
+}
+
+// NewECDSAKey returns a new ES256 key.
+//
+// The key must use the P-256 curve, ErrUnsupportedKey is returned otherwise.
+func NewECDSAKey(id string, key *ecdsa.PrivateKey) (*Key, error) {
+	if key.Curve != elliptic.P256() {
+		return nil, ErrUnsupportedKey
+	}
+	return &Key{ID: id, Algorithm: ES256, private: key, public: &key.PublicKey}, nil
+}
+
+// NewEd25519Key returns a new EdDSA key.
+func NewEd25519Key(id string, key ed25519.PrivateKey) *Key {
+	return &Key{ID: id, Algorithm: EdDSA, private: key, public: key.Public()}
+}
+
+// NewPublicKey returns a key which can only be used to verify tokens.
+//
+// The public key must be a *rsa.PublicKey, *ecdsa.PublicKey or ed25519.PublicKey.
+func NewPublicKey(id string, public crypto.PublicKey) (*Key, error) {
+	switch pub := public.(type) {
+	case *rsa.PublicKey:
+		return &Key{ID: id, Algorithm: RS256, public: pub}, nil
+	case *ecdsa.PublicKey:
+		if pub.Curve != elliptic.P256() {
+			return nil, ErrUnsupportedKey
+		}
+		return &Key{ID: id, Algorithm: ES256, public: pub}, nil
+	case ed25519.PublicKey:
+		return &Key{ID: id, Algorithm: EdDSA, public: pub}, nil
+	}
+	return nil, ErrUnsupportedKey
+}
+
+// CanSign reports whether the key can be used to sign tokens.
+func (k *Key) CanSign() bool {
+	return k.private != nil
+}
+
+// Public returns the public key, or the secret for HS256 keys.
+func (k *Key) Public() any {
+	return k.public
+}
+
+func (k *Key) sign(data []byte) ([]byte, error) {
+	if k.private == nil {
+		return nil, ErrCannotSign
+	}
+	var digest = sha256.Sum256(data)
+	switch key := k.private.(type) {
+	case []byte:
+		var mac = hmac.New(sha256.New, key)
+		mac.Write(data)
+		return mac.Sum(nil), nil
+	case *rsa.PrivateKey:
+		return rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	case *ecdsa.PrivateKey:
+		var r, s, err = ecdsa.Sign(rand.Reader, key, digest[:])
+		if err != nil {
+			return nil, err
+		}
+		// JWS uses the fixed size R || S encoding, not ASN.1.
+		var sig = make([]byte, 64)
+		r.FillBytes(sig[:32])
+		s.FillBytes(sig[32:])
+		return sig, nil
+	case ed25519.PrivateKey:
+		return ed25519.Sign(key, data), nil
+	}
+	return nil, ErrUnsupportedKey
+}
+
+func (k *Key) verify(data, sig []byte) bool {
+	var digest = sha256.Sum256(data)
+	switch key := k.public.(type) {
+	case []byte:
+		var mac = hmac.New(sha256.New, key)
+		mac.Write(data)
+		return hmac.Equal(sig, mac.Sum(nil))
+	case *rsa.PublicKey:
+		return rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], sig) == nil
+	case *ecdsa.PublicKey:
+		if len(sig) != 64 {
+			return false
+		}
+		var r = new(big.Int).SetBytes(sig[:32])
+		var s = new(big.Int).SetBytes(sig[32:])
+		return ecdsa.Verify(key, digest[:], r, s)
+	case ed25519.PublicKey:
+		return ed25519.Verify(key, data, sig)
+	}
+	return false
+}
+
+// KeySet holds the keys used to sign and verify tokens.
+//
+// Keys can be rotated by adding a new key, making it active,
+// and removing the old key once all tokens signed with it have expired.
+//
+// A KeySet is safe for concurrent use.
+type KeySet struct {
+	keys   []*Key
+	active string
+	mu     sync.RWMutex
+}
+
+// NewKeySet returns a new key set.
+//
+// The first key which can sign is made the active key.
+func NewKeySet(keys ...*Key) *KeySet {
+	var ks = &KeySet{}
+	for _, k := range keys {
+		ks.Add(k)
+	}
+	return ks
+}
+
+// Add a key to the set.
+//
+// A key with the same ID will be replaced.
+func (ks *KeySet) Add(key *Key) {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+	for i, k := range ks.keys {
+		if k.ID == key.ID {
+			ks.keys[i] = key
+			return
+		}
+	}
+	ks.keys = append(ks.keys, key)
+	if ks.active == "" && key.CanSign() {
+		ks.active = key.ID
+	}
+}
+
+// Remove a key from the set.
+func (ks *KeySet) Remove(id string) {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+	for i, k := range ks.keys {
+		if k.ID == id {
+			ks.keys = append(ks.keys[:i], ks.keys[i+1:]...)
+			break
+		}
+	}
+	if ks.active == id {
+		ks.active = ""
+	}
+}
+
+// SetActive sets the key used to sign new tokens.
+func (ks *KeySet) SetActive(id string) error {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+	for _, k := range ks.keys {
+		if k.ID == id {
+			if !k.CanSign() {
+				return ErrCannotSign
+			}
+			ks.active = id
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %q", ErrKeyNotFound, id)
+}
+
+// Key returns the key with the given ID.
+func (ks *KeySet) Key(id string) (*Key, bool) {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+	for _, k := range ks.keys {
+		if k.ID == id {
+			return k, true
+		}
+	}
+	return nil, false
+}
+
+// Keys returns all keys in the set.
+func (ks *KeySet) Keys() []*Key {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+	var keys = make([]*Key, len(ks.keys))
+	copy(keys, ks.keys)
+	return keys
+}
+
+// Active returns the key used to sign new tokens.
+func (ks *KeySet) Active() (*Key, error) {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+	if ks.active == "" {
+		return nil, errors.New("jwt: no active signing key")
+	}
+	for _, k := range ks.keys {
+		if k.ID == ks.active {
+			return k, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %q", ErrKeyNotFound, ks.active)
+}
+
+// Sign the claims with the active key.
+func (ks *KeySet) Sign(claims *Claims) (string, error) {
+	var key, err = ks.Active()
+	if err != nil {
+		return "", err
+	}
+	return Sign(claims, key)
+}
+
+// Verify the token, and validate its claims.
+//
+// The key is selected by the kid header of the token,
+// every key with the token's algorithm is tried if it has none.
+func (ks *KeySet) Verify(token string, opts *ValidationOptions) (*Claims, error) {
+	return Verify(token, ks, opts)
+}
@@ -0,0 +1,134 @@
+package jwt
+
+import (
+	"strings"
+
+	"github.com/Nigel2392/router/v3"
+	"github.com/Nigel2392/router/v3/auth"
+	"github.com/Nigel2392/router/v3/request"
+)
+
+// Names of the private claims read by the default User.
+var (
+	CLAIM_PERMISSIONS = "permissions"
+	CLAIM_ADMIN       = "admin"
+)
+
+// User is the default request.User created from verified claims.
+//
+// Permissions are read from the CLAIM_PERMISSIONS claim,
+// which can be a list of strings, or a space separated string like the OAuth2 scope claim.
+type User struct {
+	Claims      *Claims
+	Permissions []string
+	Admin       bool
+}
+
+// NewUser creates a new user from the claims.
+func NewUser(claims *Claims) *User {
+	var u = &User{Claims: claims}
+	switch perms := claims.Get(CLAIM_PERMISSIONS).(type) {
+	case []any:
+		for _, p := range perms {
+			if s, ok := p.(string); ok {
+				u.Permissions = append(u.Permissions, s)
+			}
+		}
+	case string:
+		u.Permissions = strings.Fields(perms)
+	}
+	u.Admin, _ = claims.Get(CLAIM_ADMIN).(bool)
+	return u
+}
+
+// A user with verified claims is always authenticated.
+func (u *User) IsAuthenticated() bool {
+	return true
+}
+
+func (u *User) IsAdmin() bool {
+	return u.Admin
+}
+
+// HasPermissions reports whether the user has all of the permissions.
+//
+// Admins have all permissions.
+func (u *User) HasPermissions(permissions ...string) bool {
+	if u.Admin {
+		return true
+	}
+outer:
+	for _, perm := range permissions {
+		for _, p := range u.Permissions {
+			if p == perm {
+				continue outer
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// Options for the JWT middleware.
+type Options struct {
+	// Keys used to verify tokens.
+	Keys *KeySet
+
+	// Claims validation options.
+	Validation *ValidationOptions
+
+	// Extract the token from the request.
+	// Defaults to the bearer token in the Authorization header.
+	Extract func(r *request.Request) (string, bool)
+
+	// Create the user from the verified claims.
+	// Defaults to NewUser.
+	UserFunc func(r *request.Request, claims *Claims) (request.User, error)
+
+	// Called when the token is invalid.
+	// Defaults to a 401 Unauthorized response.
+	OnError func(r *request.Request, err error)
+}
+
+// Authenticator returns an auth.Authenticator which verifies tokens,
+// so it can be chained with other authenticators.
+//
+// The token is read with opts.Extract, or from the Authorization header if it is not set.
+func Authenticator(opts *Options) auth.Authenticator {
+	if opts == nil || opts.Keys == nil {
+		panic("jwt.Authenticator: no keys provided")
+	}
+	if opts.Extract != nil {
+		return auth.AuthenticatorFunc(func(r *request.Request) (request.User, error) {
+			var token, ok = opts.Extract(r)
+			if !ok {
+				return nil, auth.ErrNoCredentials
+			}
+			return opts.authenticate(r, token)
+		})
+	}
+	return &auth.Bearer{
+		Validate: func(r *request.Request, token string) (request.User, error) {
+			return opts.authenticate(r, token)
+		},
+	}
+}
+
+func (o *Options) authenticate(r *request.Request, token string) (request.User, error) {
+	var claims, err = Verify(token, o.Keys, o.Validation)
+	if err != nil {
+		return nil, err
+	}
+	if o.UserFunc != nil {
+		return o.UserFunc(r, claims)
+	}
+	return NewUser(claims), nil
+}
+
+// Middleware verifies the token of the request, and sets the user on the request.
+//
+// Requests without a token are passed through without a user,
+// use the LoginRequiredMiddleware to require authentication.
+func Middleware(opts *Options) router.Middleware {
+	return auth.Middleware(Authenticator(opts), opts.OnError)
+}
@@ -0,0 +1,59 @@
+package router
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Nigel2392/router/v3/request"
+)
+
+func TestMethodMatching(t *testing.T) {
+	var r = NewRouter(true)
+	var handler = HandleFunc(func(r *request.Request) {
+		r.WriteString("ok")
+	})
+	r.Get("/page", handler)
+	r.Post("/form", handler)
+	r.Any("/any", handler)
+
+	var tests = []struct {
+		method string
+		path   string
+		status int
+		allow  string
+	}{
+		{"GET", "/page", 200, ""},
+		{"HEAD", "/page", 200, ""},
+		{"POST", "/page", 405, "GET, HEAD"},
+		{"POST", "/form", 200, ""},
+		{"HEAD", "/form", 405, "POST"},
+		{"DELETE", "/any", 200, ""},
+	}
+	for _, test := range tests {
+		var w = httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(test.method, test.path, nil))
+		if w.Code != test.status {
+			t.Errorf("%s %s: got status %d, want %d", test.method, test.path, w.Code, test.status)
+		}
+		if allow := w.Header().Get("Allow"); allow != test.allow {
+			t.Errorf("%s %s: got Allow %q, want %q", test.method, test.path, allow, test.allow)
+		}
+	}
+}
+
+func TestMethodNotAllowedHandlerKeepsAllow(t *testing.T) {
+	var r = NewRouter(true)
+	r.Get("/page", HandleFunc(func(r *request.Request) {}))
+	r.MethodNotAllowedHandler = HandleFunc(func(r *request.Request) {
+		r.Error(405, "not allowed")
+	})
+
+	var w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("POST", "/page", nil))
+	if w.Code != 405 {
+		t.Errorf("got status %d, want 405", w.Code)
+	}
+	if allow := w.Header().Get("Allow"); allow != "GET, HEAD" {
+		t.Errorf("got Allow %q, want %q", allow, "GET, HEAD")
+	}
+}
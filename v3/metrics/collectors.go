@@ -0,0 +1,198 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// A single labelled value of a metric.
+type series struct {
+	labels []string
+	value  float64
+
+	// Histograms only.
+	buckets []uint64
+	count   uint64
+}
+
+// The series of a metric, by their label values.
+type metric struct {
+	name    string
+	help    string
+	typ     string
+	labels  []string
+	buckets []float64
+	series  map[string]*series
+	mu      sync.Mutex
+}
+
+func newMetric(name, help, typ string, buckets []float64, labels []string) *metric {
+	return &metric{name: name, help: help, typ: typ, labels: labels, buckets: buckets, series: make(map[string]*series)}
+}
+
+// Returns the series of the label values, the lock must be held.
+func (m *metric) get(values []string) *series {
+	if len(values) != len(m.labels) {
+		panic(fmt.Sprintf("metrics: %s has %d labels, got %d values", m.name, len(m.labels), len(values)))
+	}
+	var key = strings.Join(values, "\xff")
+	var s, ok = m.series[key]
+	if !ok {
+		s = &series{labels: append([]string(nil), values...)}
+		if m.typ == "histogram" {
+			s.buckets = make([]uint64, len(m.buckets))
+		}
+		m.series[key] = s
+	}
+	return s
+}
+
+func (m *metric) add(v float64, values []string) {
+	m.mu.Lock()
+	m.get(values).value += v
+	m.mu.Unlock()
+}
+
+func (m *metric) set(v float64, values []string) {
+	m.mu.Lock()
+	m.get(values).value = v
+	m.mu.Unlock()
+}
+
+func (m *metric) observe(v float64, values []string) {
+	m.mu.Lock()
+	var s = m.get(values)
+	for i, le := range m.buckets {
+		if v <= le {
+			s.buckets[i]++
+		}
+	}
+	s.count++
+	s.value += v
+	m.mu.Unlock()
+}
+
+// Writes the metric in the Prometheus text exposition format.
+func (m *metric) write(w io.Writer) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var keys = make([]string, 0, len(m.series))
+	for key := range m.series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "# HELP %s %s\n", m.name, escapeHelp(m.help))
+	fmt.Fprintf(&b, "# TYPE %s %s\n", m.name, m.typ)
+	for _, key := range keys {
+		var s = m.series[key]
+		if m.typ != "histogram" {
+			fmt.Fprintf(&b, "%s%s %s\n", m.name, m.labelString(s.labels, ""), formatFloat(s.value))
+			continue
+		}
+		for i, le := range m.buckets {
+			fmt.Fprintf(&b, "%s_bucket%s %d\n", m.name, m.labelString(s.labels, formatFloat(le)), s.buckets[i])
+		}
+		fmt.Fprintf(&b, "%s_bucket%s %d\n", m.name, m.labelString(s.labels, "+Inf"), s.count)
+		fmt.Fprintf(&b, "%s_sum%s %s\n", m.name, m.labelString(s.labels, ""), formatFloat(s.value))
+		fmt.Fprintf(&b, "%s_count%s %d\n", m.name, m.labelString(s.labels, ""), s.count)
+	}
+	var _, err = io.WriteString(w, b.String())
+	return err
+}
+
+func (m *metric) labelString(values []string, le string) string {
+	if len(values) == 0 && le == "" {
+		return ""
+	}
+	var pairs = make([]string, 0, len(values)+1)
+	for i, v := range values {
+		pairs = append(pairs, m.labels[i]+`="`+escapeLabel(v)+`"`)
+	}
+	if le != "" {
+		pairs = append(pairs, `le="`+le+`"`)
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+var helpEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+
+func escapeLabel(s string) string {
+	return labelEscaper.Replace(s)
+}
+
+func escapeHelp(s string) string {
+	return helpEscaper.Replace(s)
+}
+
+func formatFloat(f float64) string {
+	switch {
+	case math.IsInf(f, 1):
+		return "+Inf"
+	case math.IsInf(f, -1):
+		return "-Inf"
+	case math.IsNaN(f):
+		return "NaN"
+	}
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+// Counter is a value which only goes up, like the number of requests.
+type Counter struct {
+	m *metric
+}
+
+// Inc adds one to the counter with the label values.
+func (c *Counter) Inc(values ...string) {
+	c.m.add(1, values)
+}
+
+// Add adds a positive value to the counter with the label values.
+func (c *Counter) Add(v float64, values ...string) {
+	if v < 0 {
+		panic("metrics: counters can not decrease")
+	}
+	c.m.add(v, values)
+}
+
+// Gauge is a value which goes up and down, like the number of requests in flight.
+type Gauge struct {
+	m *metric
+}
+
+// Set sets the gauge with the label values.
+func (g *Gauge) Set(v float64, values ...string) {
+	g.m.set(v, values)
+}
+
+// Add adds a value to the gauge with the label values.
+func (g *Gauge) Add(v float64, values ...string) {
+	g.m.add(v, values)
+}
+
+// Inc adds one to the gauge with the label values.
+func (g *Gauge) Inc(values ...string) {
+	g.m.add(1, values)
+}
+
+// Dec subtracts one from the gauge with the label values.
+func (g *Gauge) Dec(values ...string) {
+	g.m.add(-1, values)
+}
+
+// Histogram counts observations, like request durations, in buckets.
+type Histogram struct {
+	m *metric
+}
+
+// Observe adds an observation to the histogram with the label values.
+func (h *Histogram) Observe(v float64, values ...string) {
+	h.m.observe(v, values)
+}
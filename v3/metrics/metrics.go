@@ -0,0 +1,178 @@
+// Package metrics collects per-route request metrics, and serves them in the Prometheus text format.
+package metrics
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Nigel2392/router/v3"
+	"github.com/Nigel2392/router/v3/request"
+	"github.com/Nigel2392/router/v3/request/writer"
+)
+
+// Default buckets of the latency histogram, in seconds.
+var DURATION_BUCKETS = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// Default buckets of the response size histogram, in bytes.
+var SIZE_BUCKETS = []float64{100, 1000, 10000, 100000, 1000000, 10000000}
+
+// Hook is called after every request, to update custom metrics.
+type Hook func(r *request.Request, status int, duration time.Duration, size int)
+
+var nameRegex = regexp.MustCompile(`^[a-zA-Z_:][a-zA-Z0-9_:]*$`)
+
+// Metrics collects request metrics, labelled by the name of the route.
+//
+// Routes without a name are labelled with their path pattern,
+// so the number of series stays as low as the number of routes.
+type Metrics struct {
+	// Prefix of the request metrics.
+	Namespace string
+
+	requests *Counter
+	duration *Histogram
+	inFlight *Gauge
+	size     *Histogram
+
+	metrics []*metric
+	names   map[string]bool
+	hooks   []Hook
+	mu      sync.RWMutex
+}
+
+// New returns metrics with the request metrics registered under the namespace, like "http".
+func New(namespace string) *Metrics {
+	var m = &Metrics{Namespace: namespace, names: make(map[string]bool)}
+	m.requests = m.Counter(m.prefixed("requests_total"), "Number of requests, by route, method and status.", "route", "method", "status")
+	m.duration = m.Histogram(m.prefixed("request_duration_seconds"), "Request latency in seconds.", DURATION_BUCKETS, "route", "method")
+	m.inFlight = m.Gauge(m.prefixed("requests_in_flight"), "Number of requests being served.", "route")
+	m.size = m.Histogram(m.prefixed("response_size_bytes"), "Response size in bytes.", SIZE_BUCKETS, "route", "method")
+	return m
+}
+
+func (m *Metrics) prefixed(name string) string {
+	if m.Namespace == "" {
+		return name
+	}
+	return m.Namespace + "_" + name
+}
+
+func (m *Metrics) register(name, help, typ string, buckets []float64, labels []string) *metric {
+	if !nameRegex.MatchString(name) {
+		panic(fmt.Sprintf("metrics: invalid metric name %q", name))
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.names[name] {
+		panic(fmt.Sprintf("metrics: %q is already registered", name))
+	}
+	m.names[name] = true
+	var metric = newMetric(name, help, typ, buckets, labels)
+	m.metrics = append(m.metrics, metric)
+	return metric
+}
+
+// Counter registers a custom counter.
+func (m *Metrics) Counter(name, help string, labels ...string) *Counter {
+	return &Counter{m.register(name, help, "counter", nil, labels)}
+}
+
+// Gauge registers a custom gauge.
+func (m *Metrics) Gauge(name, help string, labels ...string) *Gauge {
+	return &Gauge{m.register(name, help, "gauge", nil, labels)}
+}
+
+// Histogram registers a custom histogram, with the upper bounds of its buckets.
+func (m *Metrics) Histogram(name, help string, buckets []float64, labels ...string) *Histogram {
+	buckets = append([]float64(nil), buckets...)
+	sort.Float64s(buckets)
+	return &Histogram{m.register(name, help, "histogram", buckets, labels)}
+}
+
+// OnRequest adds hooks which are called after every request, to update custom metrics.
+func (m *Metrics) OnRequest(hooks ...Hook) {
+	m.mu.Lock()
+	m.hooks = append(m.hooks, hooks...)
+	m.mu.Unlock()
+}
+
+// Middleware records the metrics of every request.
+//
+// Use it as global middleware, requests which do not match a route are not recorded.
+func (m *Metrics) Middleware() router.Middleware {
+	return func(next router.Handler) router.Handler {
+		return router.HandleFunc(func(r *request.Request) {
+			var route = r.Route.Name
+			if route == "" {
+				route = r.Route.Pattern
+			}
+			var method = methodLabel(r.Method())
+
+			m.inFlight.Inc(route)
+			var start = time.Now()
+			var rec = writer.NewStatusRecorder(r.Response)
+			r.Response = rec
+			defer func() {
+				var duration = time.Since(start)
+				var status = rec.Status()
+				// The panic is passed on, to be handled by a recoverer.
+				var p = recover()
+				if p != nil {
+					status = http.StatusInternalServerError
+				}
+				var size = r.Response.Buffer().Len()
+				m.inFlight.Dec(route)
+				m.requests.Inc(route, method, strconv.Itoa(status))
+				m.duration.Observe(duration.Seconds(), route, method)
+				m.size.Observe(float64(size), route, method)
+
+				m.mu.RLock()
+				var hooks = m.hooks
+				m.mu.RUnlock()
+				for _, hook := range hooks {
+					hook(r, status, duration, size)
+				}
+				if p != nil {
+					panic(p)
+				}
+			}()
+			next.ServeHTTP(r)
+		})
+	}
+}
+
+// Returns the method label of the request method.
+//
+// Unknown methods are labelled "OTHER", so clients can not create unlimited label values.
+func methodLabel(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+		http.MethodDelete, http.MethodOptions, http.MethodConnect, http.MethodTrace:
+		return method
+	}
+	return "OTHER"
+}
+
+// ServeHTTP writes the metrics in the Prometheus text exposition format.
+func (m *Metrics) ServeHTTP(r *request.Request) {
+	var buf bytes.Buffer
+	m.mu.RLock()
+	var metrics = m.metrics
+	m.mu.RUnlock()
+	for _, metric := range metrics {
+		metric.write(&buf)
+	}
+	r.Response.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	r.Response.Write(buf.Bytes())
+}
+
+// Register registers the metrics endpoint on the path, like "/metrics".
+func (m *Metrics) Register(r router.Getter, path string) router.Registrar {
+	return r.Get(path, m, "metrics")
+}
@@ -0,0 +1,51 @@
+package metrics_test
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Nigel2392/router/v3"
+	"github.com/Nigel2392/router/v3/metrics"
+	"github.com/Nigel2392/router/v3/middleware"
+	"github.com/Nigel2392/router/v3/request"
+)
+
+func TestMiddleware(t *testing.T) {
+	var m = metrics.New("http")
+	var r = router.NewRouter(true)
+	r.Use(middleware.ProblemRecoverer(), m.Middleware())
+	r.Any("/any", router.HandleFunc(func(r *request.Request) {
+		r.WriteString("ok")
+	}), "any")
+	r.Get("/panic", router.HandleFunc(func(r *request.Request) {
+		panic("boom")
+	}), "panic")
+	m.Register(r, "/metrics")
+
+	for _, rq := range []struct{ method, path string }{
+		{"GET", "/any"},
+		{"PROPFIND", "/any"},
+		{"MADE-UP", "/any"},
+		{"GET", "/panic"},
+	} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(rq.method, rq.path, nil))
+	}
+
+	var w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+	var body = w.Body.String()
+	for _, line := range []string{
+		`http_requests_total{route="any",method="GET",status="200"} 1`,
+		`http_requests_total{route="any",method="OTHER",status="200"} 2`,
+		`http_requests_total{route="panic",method="GET",status="500"} 1`,
+		`http_requests_in_flight{route="panic"} 0`,
+	} {
+		if !strings.Contains(body, line) {
+			t.Errorf("metrics do not contain %q:\n%s", line, body)
+		}
+	}
+	if strings.Contains(body, "PROPFIND") || strings.Contains(body, "MADE-UP") {
+		t.Errorf("unknown methods should be labelled OTHER:\n%s", body)
+	}
+}
@@ -9,6 +9,9 @@ import (
 )
 
 // Check if the request.Host is in the allowed hosts list
+//
+// Hosts can be patterns like "{tenant}.example.com", see router.MatchHost.
+// The patterns of a router's host groups are returned by Router.Hosts.
 func AllowedHosts(allowed_hosts ...string) func(next router.Handler) router.Handler {
 	if len(allowed_hosts) == 0 {
 		panic("AllowedHosts: No hosts provided.")
@@ -29,7 +32,7 @@ func AllowedHosts(allowed_hosts ...string) func(next router.Handler) router.Hand
 			var allowed = false
 			var requestHost = request.GetHost(r)
 			for _, host := range allowed_hosts {
-				if host == requestHost {
+				if _, ok := router.MatchHost(host, requestHost); ok {
 					allowed = true
 					break
 				}
@@ -0,0 +1,60 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Nigel2392/router/v3"
+	"github.com/Nigel2392/router/v3/request"
+)
+
+func TestAllowedHosts(t *testing.T) {
+	var r = router.NewRouter(true)
+	r.Use(AllowedHosts("example.com", "{tenant}.example.com"))
+	r.Get("/", router.HandleFunc(func(r *request.Request) {
+		r.WriteString("ok")
+	}))
+
+	var tests = []struct {
+		host   string
+		status int
+	}{
+		{"example.com", http.StatusOK},
+		{"EXAMPLE.com:8080", http.StatusOK},
+		{"acme.example.com", http.StatusOK},
+		{"evil.com", http.StatusForbidden},
+		{"a.b.example.com", http.StatusForbidden},
+		{"*", http.StatusForbidden},
+	}
+	for _, test := range tests {
+		var rq = httptest.NewRequest("GET", "/", nil)
+		rq.Host = test.host
+		var w = httptest.NewRecorder()
+		r.ServeHTTP(w, rq)
+		if w.Code != test.status {
+			t.Errorf("Host %q: got status %d, want %d", test.host, w.Code, test.status)
+		}
+	}
+}
+
+func TestAllowedHostsLiveWildcardHost(t *testing.T) {
+	var r = router.NewRouter(true)
+	r.Use(AllowedHosts("example.com"))
+	r.Get("/", router.HandleFunc(func(r *request.Request) {
+		r.WriteString("ok")
+	}))
+	var srv = httptest.NewServer(r)
+	defer srv.Close()
+
+	var rq, _ = http.NewRequest("GET", srv.URL, nil)
+	rq.Host = "*"
+	var resp, err = http.DefaultClient.Do(rq)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("Host *: got status %d, want %d", resp.StatusCode, http.StatusForbidden)
+	}
+}
@@ -72,3 +72,24 @@ func LogoutRequiredRedirectMiddleware(nextURL string) func(next router.Handler)
 		router.RedirectWithNextURL(r, nextURL)
 	})
 }
+
+// Middleware that only allows users who have all of the given permissions to continue.
+// Otherwise, the router's ForbiddenHandler is called.
+func PermissionRequired(permissions ...string) router.Middleware {
+	return PolicyRequired(router.AllOf(permissions...))
+}
+
+// Middleware that only allows administrators to continue.
+// Otherwise, the router's ForbiddenHandler is called.
+func AdminRequired(next router.Handler) router.Handler {
+	return PolicyRequired(router.AdminOnly())(next)
+}
+
+// Middleware that only allows users who meet all of the policies to continue.
+// Otherwise, the router's ForbiddenHandler is called.
+//
+// Policies added with this middleware are not listed by router.Permissions(),
+// use Registrar.Require to declare policies which can be audited.
+func PolicyRequired(policies ...*router.Policy) router.Middleware {
+	return router.PolicyMiddleware(nil, policies...)
+}
@@ -6,10 +6,18 @@ import (
 
 	"github.com/Nigel2392/router/v3"
 	"github.com/Nigel2392/router/v3/request"
+	"github.com/Nigel2392/router/v3/request/response"
 )
 
+// Called with every panic recovered by Recoverer, before the error handler.
+//
+// Use it to record panics, for example with tracing.PanicHook.
+var PANIC_HOOKS []func(r *request.Request, err error)
+
 // Recoverer recovers from panics and logs the error,
 // if the logger was set.
+//
+// The PANIC_HOOKS are called with the panic.
 func Recoverer(onError func(err error, r *request.Request)) router.Middleware {
 	return func(next router.Handler) router.Handler {
 		return router.HandleFunc(func(r *request.Request) {
@@ -19,15 +27,20 @@ func Recoverer(onError func(err error, r *request.Request)) router.Middleware {
 						DEFAULT_LOGGER.Error(FormatMessage(r, "PANIC", "Panic: %v", err))
 					}
 					r.Response.Clear()
+					var panicErr error
+					switch err := err.(type) {
+					case error:
+						panicErr = err
+					case string:
+						panicErr = errors.New(err)
+					default:
+						panicErr = fmt.Errorf("%v", err)
+					}
+					for _, hook := range PANIC_HOOKS {
+						hook(r, panicErr)
+					}
 					var newHandler = router.HandleFunc(func(r *request.Request) {
-						switch err := err.(type) {
-						case error:
-							onError(err, r)
-						case string:
-							onError(errors.New(err), r)
-						default:
-							onError(fmt.Errorf("%v", err), r)
-						}
+						onError(panicErr, r)
 					})
 					newHandler.ServeHTTP(r)
 				}
@@ -36,3 +49,13 @@ func Recoverer(onError func(err error, r *request.Request)) router.Middleware {
 		})
 	}
 }
+
+// ProblemRecoverer recovers from panics,
+// and writes a 500 Internal Server Error problem document with response.Error.
+//
+// The panic value is never shown to the client.
+func ProblemRecoverer() router.Middleware {
+	return Recoverer(func(err error, r *request.Request) {
+		response.Error(r, response.InternalServerError("").WithError(err))
+	})
+}
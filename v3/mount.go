@@ -0,0 +1,142 @@
+package router
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/Nigel2392/router/v3/request"
+	"github.com/Nigel2392/routevars"
+)
+
+// A handler mounted on a path prefix.
+type mount struct {
+	prefix string
+	name   string
+	// The route used to run the router's middleware before the mounted handler.
+	route  *Route
+	router *Router
+}
+
+type mountKey struct{}
+
+// The router is already mounted on another router, or on the same router twice.
+var ErrAlreadyMounted = errors.New("router: router is already mounted")
+
+// Mount serves every request below the prefix with the handler, with the prefix stripped from the path.
+//
+// The handler can be a *Router, which keeps its own NotFound, MethodNotAllowed and error handlers.
+// Paths reversed by a mounted router include the prefix where it is mounted.
+//
+// The router's global middleware is run before the mounted handler.
+// Mounts are matched after the router's own routes, in the order they were added,
+// so a mount on "/" serves every path which no route matches.
+//
+// A router can only be mounted once, and not inside of itself.
+// ErrAlreadyMounted is returned otherwise.
+//
+//	var admin = router.NewRouter(true)
+//	admin.Get("/users", usersFunc, "users")
+//	r.Mount("/admin", admin, "admin")
+//
+//	r.Reverse("admin:users", nil, nil) // /admin/users
+func (r *Router) Mount(prefix string, handler http.Handler, name ...string) error {
+	prefix = "/" + strings.Trim(prefix, "/")
+	var m = &mount{prefix: prefix}
+	if len(name) > 0 {
+		m.name = name[0]
+	}
+	if child, ok := handler.(*Router); ok {
+		if child.mountParent != nil {
+			return ErrAlreadyMounted
+		}
+		for parent := r; parent != nil; parent = parent.mountParent {
+			if parent == child {
+				return fmt.Errorf("%w: it can not be mounted inside of itself", ErrAlreadyMounted)
+			}
+		}
+		child.mountParent = r
+		child.mountPrefix = prefix
+		m.router = child
+	}
+	m.route = &Route{
+		Method:            ALL,
+		Path:              routevars.URLFormatter(prefix),
+		HandlerFunc:       mountHandler(prefix, handler),
+		middlewareEnabled: true,
+		name:              m.name,
+		source:            registeredAt(),
+		owner:             r,
+	}
+	r.mounts = append(r.mounts, m)
+	r.invalidateChains()
+	return nil
+}
+
+// MountPoint returns the prefix where the router is mounted, including the prefixes of its parents.
+//
+// It returns an empty string if the router is not mounted.
+func (r *Router) MountPoint() string {
+	if r.mountParent == nil {
+		return ""
+	}
+	return r.mountParent.MountPoint() + r.mountPrefix
+}
+
+// MountPrefix returns the prefix which was stripped from the request's path by Mount,
+// including the prefixes of parent mounts.
+//
+// It returns an empty string if the request was not served by a mounted handler.
+func MountPrefix(rq *http.Request) string {
+	var prefix, _ = rq.Context().Value(mountKey{}).(string)
+	return prefix
+}
+
+// Returns the mount which serves the path.
+func (r *Router) matchMount(path string) *mount {
+	for _, m := range r.mounts {
+		if path == m.prefix || strings.HasPrefix(path, m.prefix+"/") || m.prefix == "/" {
+			return m
+		}
+	}
+	return nil
+}
+
+func mountHandler(prefix string, handler http.Handler) Handler {
+	if prefix == "/" {
+		prefix = ""
+	}
+	return HandleFunc(func(r *request.Request) {
+		var rq = new(http.Request)
+		*rq = *r.Request
+		rq.URL = new(url.URL)
+		*rq.URL = *r.Request.URL
+		rq.URL.Path = strings.TrimPrefix(rq.URL.Path, prefix)
+		if rq.URL.Path == "" {
+			rq.URL.Path = "/"
+		}
+		if rq.URL.RawPath != "" {
+			rq.URL.RawPath = strings.TrimPrefix(rq.URL.RawPath, prefix)
+			if rq.URL.RawPath == "" {
+				rq.URL.RawPath = "/"
+			}
+		}
+		rq = rq.WithContext(context.WithValue(rq.Context(), mountKey{}, MountPrefix(r.Request)+prefix))
+		handler.ServeHTTP(r.Response, rq)
+	})
+}
+
+// Reverse a name like "admin:users" in a router mounted with the name "admin".
+func (r *Router) reverseMounted(name string, params map[string]any, query url.Values) (string, bool, error) {
+	for _, m := range r.mounts {
+		if m.router == nil || m.name == "" || !strings.HasPrefix(name, m.name+":") {
+			continue
+		}
+		var path, err = m.router.Reverse(strings.TrimPrefix(name, m.name+":"), params, query)
+		return path, true, err
+	}
+	return "", false, nil
+}
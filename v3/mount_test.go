@@ -0,0 +1,59 @@
+package router
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Nigel2392/router/v3/request"
+)
+
+func TestMountOnRootKeepsRoutes(t *testing.T) {
+	var r = NewRouter(true)
+	r.Get("/api", HandleFunc(func(r *request.Request) {
+		r.WriteString("api")
+	}))
+	if err := r.Mount("/", http.HandlerFunc(func(w http.ResponseWriter, rq *http.Request) {
+		w.Write([]byte("mounted " + rq.URL.Path))
+	})); err != nil {
+		t.Fatal(err)
+	}
+
+	var tests = []struct {
+		method string
+		path   string
+		code   int
+		body   string
+	}{
+		{"GET", "/api", 200, "api"},
+		{"GET", "/index.html", 200, "mounted /index.html"},
+		{"POST", "/api", 405, ""},
+	}
+	for _, test := range tests {
+		var w = httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(test.method, test.path, nil))
+		if w.Code != test.code {
+			t.Errorf("%s %s: got status %d, want %d", test.method, test.path, w.Code, test.code)
+		}
+		if test.body != "" && w.Body.String() != test.body {
+			t.Errorf("%s %s: got body %q, want %q", test.method, test.path, w.Body.String(), test.body)
+		}
+	}
+}
+
+func TestMountTwice(t *testing.T) {
+	var r, other, admin = NewRouter(true), NewRouter(true), NewRouter(true)
+	if err := r.Mount("/admin", admin); err != nil {
+		t.Fatal(err)
+	}
+	if err := other.Mount("/admin", admin); !errors.Is(err, ErrAlreadyMounted) {
+		t.Errorf("mounting a router twice: got %v, want ErrAlreadyMounted", err)
+	}
+	if err := admin.Mount("/root", r); !errors.Is(err, ErrAlreadyMounted) {
+		t.Errorf("mounting a router inside of itself: got %v, want ErrAlreadyMounted", err)
+	}
+	if admin.MountPoint() != "/admin" {
+		t.Errorf("got mount point %q, want /admin", admin.MountPoint())
+	}
+}
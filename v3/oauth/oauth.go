@@ -0,0 +1,286 @@
+package oauth
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Nigel2392/router/v3"
+	"github.com/Nigel2392/router/v3/auth"
+	"github.com/Nigel2392/router/v3/jwt"
+	"github.com/Nigel2392/router/v3/request"
+)
+
+// Keys used to store the login flow's state in the session.
+var (
+	SESSION_STATE_KEY    = "oauth_state"
+	SESSION_VERIFIER_KEY = "oauth_verifier"
+	SESSION_NONCE_KEY    = "oauth_nonce"
+	SESSION_NEXT_KEY     = "oauth_next"
+)
+
+// Default errors returned during the login flow.
+var (
+	ErrInvalidState = errors.New("oauth: invalid state")
+	ErrInvalidNonce = errors.New("oauth: invalid nonce")
+	ErrMissingCode  = errors.New("oauth: missing authorization code")
+	ErrNoIDToken    = errors.New("oauth: provider did not return an id_token")
+)
+
+// Endpoint holds the provider's URLs.
+type Endpoint struct {
+	// Authorization endpoint, the user is redirected here to log in.
+	AuthURL string
+
+	// Token endpoint, used to exchange the code for tokens.
+	TokenURL string
+
+	// UserInfo endpoint, optional.
+	UserInfoURL string
+
+	// JWKS endpoint, required to verify ID tokens.
+	JWKSURL string
+
+	// Issuer, the iss claim of the ID token must match this value.
+	Issuer string
+}
+
+// Config for an OAuth2 or OpenID Connect provider.
+type Config struct {
+	ClientID     string
+	ClientSecret string
+
+	// URL of the callback route, which must be registered with the provider.
+	RedirectURL string
+
+	// Scopes to request. Include "openid" to receive an ID token.
+	Scopes []string
+
+	Endpoint Endpoint
+
+	// Extra parameters to add to the authorization URL.
+	AuthParams url.Values
+
+	// HTTP client used to talk to the provider.
+	// Defaults to a client with a 10 second timeout.
+	HTTPClient *http.Client
+
+	// Create the user from the merged ID token and userinfo claims.
+	// Defaults to NewUser.
+	MapUser func(r *request.Request, claims map[string]any, token *Token) (request.User, error)
+
+	// Called after the user has logged in.
+	// Defaults to storing the subject in the session with auth.Login,
+	// and redirecting to the page the user came from.
+	OnLogin func(r *request.Request, user request.User, token *Token)
+
+	// Called when the login flow fails.
+	// Defaults to a 400 Bad Request response.
+	OnError func(r *request.Request, err error)
+}
+
+// Provider runs the login flow for a single configuration.
+type Provider struct {
+	Config *Config
+
+	keys        *jwt.KeySet
+	keysFetched time.Time
+	keysMu      sync.Mutex
+}
+
+// New creates a new provider.
+func New(config *Config) *Provider {
+	if config.HTTPClient == nil {
+		config.HTTPClient = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &Provider{Config: config}
+}
+
+// Register adds the login and callback routes to the registrar.
+//
+// The routes will be named name+"_login" and name+"_callback".
+func (p *Provider) Register(reg router.Registrar, loginPath, callbackPath, name string) {
+	reg.Get(loginPath, router.HandleFunc(p.Login), name+"_login")
+	reg.Get(callbackPath, router.HandleFunc(p.Callback), name+"_callback")
+}
+
+// Login redirects the user to the provider's authorization endpoint.
+//
+// The "next" query parameter is stored, so the user can be redirected back after logging in.
+func (p *Provider) Login(r *request.Request) {
+	var u, err = p.AuthCodeURL(r)
+	if err != nil {
+		p.error(r, err)
+		return
+	}
+	if next := r.QueryParams.Get("next"); next != "" && isLocalURL(next) {
+		r.Session.Set(SESSION_NEXT_KEY, next)
+	}
+	http.Redirect(r.Response, r.Request, u, http.StatusFound)
+}
+
+// AuthCodeURL generates the state, nonce and PKCE verifier,
+// stores them in the session and returns the authorization URL.
+func (p *Provider) AuthCodeURL(r *request.Request) (string, error) {
+	if r.Session == nil {
+		return "", auth.ErrNoSession
+	}
+	var state, nonce, verifier = randomString(), randomString(), randomString()
+	r.Session.Set(SESSION_STATE_KEY, state)
+	r.Session.Set(SESSION_NONCE_KEY, nonce)
+	r.Session.Set(SESSION_VERIFIER_KEY, verifier)
+
+	var u, err = url.Parse(p.Config.Endpoint.AuthURL)
+	if err != nil {
+		return "", err
+	}
+	var q = u.Query()
+	for k, v := range p.Config.AuthParams {
+		q[k] = v
+	}
+	q.Set("response_type", "code")
+	q.Set("client_id", p.Config.ClientID)
+	q.Set("redirect_uri", p.Config.RedirectURL)
+	q.Set("state", state)
+	q.Set("code_challenge", challenge(verifier))
+	q.Set("code_challenge_method", "S256")
+	if len(p.Config.Scopes) > 0 {
+		q.Set("scope", strings.Join(p.Config.Scopes, " "))
+	}
+	if p.isOpenID() {
+		q.Set("nonce", nonce)
+	}
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
+
+// Callback handles the redirect back from the provider.
+//
+// It verifies the state, exchanges the code, verifies the ID token,
+// fetches the userinfo and finally calls OnLogin.
+func (p *Provider) Callback(r *request.Request) {
+	var user, token, err = p.callback(r)
+	if err != nil {
+		p.error(r, err)
+		return
+	}
+	if p.Config.OnLogin != nil {
+		p.Config.OnLogin(r, user, token)
+		return
+	}
+	var sub, ok = subject(user)
+	if !ok {
+		p.error(r, errors.New("oauth: Config.OnLogin is required to log in custom users"))
+		return
+	}
+	if err := auth.Login(r, user, sub); err != nil {
+		p.error(r, err)
+		return
+	}
+	var next, _ = r.Session.Get(SESSION_NEXT_KEY).(string)
+	r.Session.Delete(SESSION_NEXT_KEY)
+	if next == "" {
+		next = "/"
+	}
+	r.Redirect(next, http.StatusFound)
+}
+
+func (p *Provider) callback(r *request.Request) (request.User, *Token, error) {
+	if r.Session == nil {
+		return nil, nil, auth.ErrNoSession
+	}
+	var state, _ = r.Session.Get(SESSION_STATE_KEY).(string)
+	var nonce, _ = r.Session.Get(SESSION_NONCE_KEY).(string)
+	var verifier, _ = r.Session.Get(SESSION_VERIFIER_KEY).(string)
+	// The state can only be used once.
+	r.Session.Delete(SESSION_STATE_KEY)
+	r.Session.Delete(SESSION_NONCE_KEY)
+	r.Session.Delete(SESSION_VERIFIER_KEY)
+
+	if e := r.QueryParams.Get("error"); e != "" {
+		return nil, nil, &ProviderError{Code: e, Description: r.QueryParams.Get("error_description")}
+	}
+	if state == "" || !auth.CompareSecrets(r.QueryParams.Get("state"), state) {
+		return nil, nil, ErrInvalidState
+	}
+	// A nonce is always issued for OpenID Connect, it must be verified.
+	if nonce == "" && p.isOpenID() {
+		return nil, nil, ErrInvalidNonce
+	}
+	var code = r.QueryParams.Get("code")
+	if code == "" {
+		return nil, nil, ErrMissingCode
+	}
+
+	var ctx = r.Context()
+	var token, err = p.Exchange(ctx, code, verifier)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var claims = make(map[string]any)
+	if p.isOpenID() {
+		if token.IDToken == "" {
+			return nil, nil, ErrNoIDToken
+		}
+		idClaims, err := p.VerifyIDToken(ctx, token.IDToken, nonce)
+		if err != nil {
+			return nil, nil, err
+		}
+		for k, v := range idClaims.Extra {
+			claims[k] = v
+		}
+		claims["sub"] = idClaims.Subject
+		claims["iss"] = idClaims.Issuer
+	}
+
+	if p.Config.Endpoint.UserInfoURL != "" {
+		info, err := p.UserInfo(ctx, token.AccessToken)
+		if err != nil {
+			return nil, nil, err
+		}
+		// The userinfo subject must match the ID token's subject.
+		if sub, ok := claims["sub"]; ok && info["sub"] != nil && info["sub"] != sub {
+			return nil, nil, errors.New("oauth: userinfo subject does not match id_token")
+		}
+		for k, v := range info {
+			claims[k] = v
+		}
+	}
+
+	var user request.User
+	if p.Config.MapUser != nil {
+		user, err = p.Config.MapUser(r, claims, token)
+	} else {
+		user = NewUser(claims, token)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	return user, token, nil
+}
+
+func (p *Provider) isOpenID() bool {
+	for _, s := range p.Config.Scopes {
+		if s == "openid" {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *Provider) error(r *request.Request, err error) {
+	if p.Config.OnError != nil {
+		p.Config.OnError(r, err)
+		return
+	}
+	r.Error(http.StatusBadRequest, err.Error())
+}
+
+// Only allow redirects to paths on this site.
+func isLocalURL(u string) bool {
+	return strings.HasPrefix(u, "/") && !strings.HasPrefix(u, "//") && !strings.HasPrefix(u, "/\\")
+}
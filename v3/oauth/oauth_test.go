@@ -0,0 +1,387 @@
+package oauth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Nigel2392/router/v3/jwt"
+	"github.com/Nigel2392/router/v3/request"
+	"github.com/Nigel2392/router/v3/request/writer"
+)
+
+// An OpenID Connect provider with discovery, token and JWKS endpoints.
+type stubProvider struct {
+	*httptest.Server
+
+	keys       *jwt.KeySet
+	jwksHits   atomic.Int32
+	mu         sync.Mutex
+	codes      map[string]url.Values
+	tokenNonce string
+	signKey    *jwt.Key
+}
+
+func newStubProvider(t *testing.T) *stubProvider {
+	var s = &stubProvider{codes: make(map[string]url.Values)}
+	s.signKey = newECDSAKey(t, "key-1")
+	s.keys = jwt.NewKeySet(s.signKey)
+
+	var mux = http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 s.URL,
+			"authorization_endpoint": s.URL + "/authorize",
+			"token_endpoint":         s.URL + "/token",
+			"jwks_uri":               s.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		s.jwksHits.Add(1)
+		json.NewEncoder(w).Encode(s.keys.JWKS())
+	})
+	mux.HandleFunc("/token", s.token)
+	s.Server = httptest.NewServer(mux)
+	t.Cleanup(s.Close)
+	return s
+}
+
+func newECDSAKey(t *testing.T, id string) *jwt.Key {
+	var key, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signKey, err := jwt.NewECDSAKey(id, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signKey
+}
+
+// Approve the authorization request, like a user logging in at the provider.
+func (s *stubProvider) authorize(t *testing.T, authURL string) string {
+	var u, err = url.Parse(authURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var code = randomString()
+	s.mu.Lock()
+	s.codes[code] = u.Query()
+	s.mu.Unlock()
+	return code
+}
+
+func (s *stubProvider) token(w http.ResponseWriter, r *http.Request) {
+	var fail = func(code string) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": code})
+	}
+	r.ParseForm()
+	s.mu.Lock()
+	var auth, ok = s.codes[r.PostForm.Get("code")]
+	delete(s.codes, r.PostForm.Get("code"))
+	s.mu.Unlock()
+	switch {
+	case !ok:
+		fail("invalid_grant")
+		return
+	case auth.Get("code_challenge_method") != "S256" || challenge(r.PostForm.Get("code_verifier")) != auth.Get("code_challenge"):
+		fail("invalid_grant")
+		return
+	case r.PostForm.Get("redirect_uri") != auth.Get("redirect_uri"):
+		fail("invalid_grant")
+		return
+	}
+
+	var claims = jwt.NewClaims("user-1", time.Minute)
+	claims.Issuer = s.URL
+	claims.Audience = jwt.Audience{auth.Get("client_id")}
+	claims.Set("email", "user@example.com")
+	var nonce = auth.Get("nonce")
+	if s.tokenNonce != "" {
+		nonce = s.tokenNonce
+	}
+	claims.Set("nonce", nonce)
+	var idToken, err = jwt.Sign(claims, s.signKey)
+	if err != nil {
+		fail("server_error")
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]any{
+		"access_token": "access",
+		"token_type":   "Bearer",
+		"expires_in":   3600,
+		"id_token":     idToken,
+	})
+}
+
+type mapSession map[string]any
+
+func (s mapSession) Set(key string, value interface{}) { s[key] = value }
+func (s mapSession) Get(key string) interface{}        { return s[key] }
+func (s mapSession) Exists(key string) bool            { _, ok := s[key]; return ok }
+func (s mapSession) Delete(key string)                 { delete(s, key) }
+func (s mapSession) Destroy() error                    { return nil }
+func (s mapSession) RenewToken() error                 { return nil }
+
+func newTestRequest(target string, session mapSession) *request.Request {
+	var rq = request.NewRequest(writer.NewClearable(httptest.NewRecorder()), httptest.NewRequest("GET", target, nil), nil)
+	rq.Session = session
+	return rq
+}
+
+type loginResult struct {
+	user request.User
+	err  error
+}
+
+func newTestProvider(t *testing.T, stub *stubProvider) *Provider {
+	var config = &Config{
+		ClientID:    "client",
+		RedirectURL: "http://localhost/callback",
+		Scopes:      []string{"openid", "email"},
+	}
+	var p, err = Discover(context.Background(), stub.URL, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+// Run the login flow, mutate can change the session or callback query before the callback.
+func runLogin(t *testing.T, p *Provider, stub *stubProvider, mutate func(session mapSession, query url.Values)) *loginResult {
+	var session = make(mapSession)
+	var authURL, err = p.AuthCodeURL(newTestRequest("/login", session))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var u, _ = url.Parse(authURL)
+	var query = url.Values{}
+	query.Set("state", u.Query().Get("state"))
+	query.Set("code", stub.authorize(t, authURL))
+	if mutate != nil {
+		mutate(session, query)
+	}
+	var result = &loginResult{}
+	p.Config.OnLogin = func(r *request.Request, user request.User, token *Token) {
+		result.user = user
+	}
+	p.Config.OnError = func(r *request.Request, err error) {
+		result.err = err
+	}
+	p.Callback(newTestRequest("/callback?"+query.Encode(), session))
+	return result
+}
+
+func TestDiscover(t *testing.T) {
+	var stub = newStubProvider(t)
+	var p = newTestProvider(t, stub)
+	if p.Config.Endpoint.TokenURL != stub.URL+"/token" || p.Config.Endpoint.JWKSURL != stub.URL+"/jwks" {
+		t.Errorf("unexpected endpoints %+v", p.Config.Endpoint)
+	}
+	if _, err := Discover(context.Background(), stub.URL+"/other", &Config{}); err == nil {
+		t.Error("Discover should fail for an unknown issuer")
+	}
+}
+
+func TestAuthCodeURL(t *testing.T) {
+	var stub = newStubProvider(t)
+	var p = newTestProvider(t, stub)
+	var session = make(mapSession)
+	var authURL, err = p.AuthCodeURL(newTestRequest("/login", session))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var u, _ = url.Parse(authURL)
+	var q = u.Query()
+	if q.Get("state") != session[SESSION_STATE_KEY] {
+		t.Error("state was not stored in the session")
+	}
+	if q.Get("nonce") != session[SESSION_NONCE_KEY] {
+		t.Error("nonce was not stored in the session")
+	}
+	if q.Get("code_challenge") != challenge(session[SESSION_VERIFIER_KEY].(string)) || q.Get("code_challenge_method") != "S256" {
+		t.Error("code challenge does not match the stored verifier")
+	}
+	if q.Get("scope") != "openid email" || q.Get("client_id") != "client" {
+		t.Errorf("unexpected query %v", q)
+	}
+}
+
+func TestLoginFlow(t *testing.T) {
+	var stub = newStubProvider(t)
+	var p = newTestProvider(t, stub)
+
+	var tests = []struct {
+		name   string
+		mutate func(session mapSession, query url.Values)
+		nonce  string
+		err    error
+	}{
+		{name: "success"},
+		{name: "wrong state", mutate: func(session mapSession, query url.Values) {
+			query.Set("state", "other")
+		}, err: ErrInvalidState},
+		{name: "missing state", mutate: func(session mapSession, query url.Values) {
+			delete(session, SESSION_STATE_KEY)
+		}, err: ErrInvalidState},
+		{name: "missing code", mutate: func(session mapSession, query url.Values) {
+			query.Del("code")
+		}, err: ErrMissingCode},
+		{name: "provider error", mutate: func(session mapSession, query url.Values) {
+			query.Set("error", "access_denied")
+		}, err: &ProviderError{}},
+		{name: "wrong verifier", mutate: func(session mapSession, query url.Values) {
+			session[SESSION_VERIFIER_KEY] = randomString()
+		}, err: &ProviderError{}},
+		{name: "missing verifier", mutate: func(session mapSession, query url.Values) {
+			delete(session, SESSION_VERIFIER_KEY)
+		}, err: &ProviderError{}},
+		{name: "wrong nonce", nonce: "other", err: ErrInvalidNonce},
+		{name: "missing session nonce", mutate: func(session mapSession, query url.Values) {
+			delete(session, SESSION_NONCE_KEY)
+		}, err: ErrInvalidNonce},
+	}
+	for _, test := range tests {
+		stub.tokenNonce = test.nonce
+		var result = runLogin(t, p, stub, test.mutate)
+		switch want := test.err.(type) {
+		case nil:
+			if result.err != nil {
+				t.Errorf("%s: unexpected error %v", test.name, result.err)
+				continue
+			}
+			var user, ok = result.user.(*User)
+			if !ok || user.Subject != "user-1" || user.Email != "user@example.com" {
+				t.Errorf("%s: unexpected user %#v", test.name, result.user)
+			}
+		case *ProviderError:
+			if !errors.As(result.err, &want) {
+				t.Errorf("%s: got error %v, want a provider error", test.name, result.err)
+			}
+		default:
+			if !errors.Is(result.err, want) {
+				t.Errorf("%s: got error %v, want %v", test.name, result.err, want)
+			}
+		}
+	}
+}
+
+func TestVerifyIDTokenNonce(t *testing.T) {
+	var stub = newStubProvider(t)
+	var p = newTestProvider(t, stub)
+	var sign = func(nonce string) string {
+		var claims = jwt.NewClaims("user-1", time.Minute)
+		claims.Issuer = stub.URL
+		claims.Audience = jwt.Audience{"client"}
+		if nonce != "" {
+			claims.Set("nonce", nonce)
+		}
+		var token, err = jwt.Sign(claims, stub.signKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return token
+	}
+
+	var tests = []struct {
+		claim, nonce string
+		ok           bool
+	}{
+		{"abc", "abc", true},
+		{"", "", true},
+		{"abc", "def", false},
+		{"", "abc", false},
+		{"abc", "", false},
+	}
+	for _, test := range tests {
+		var _, err = p.VerifyIDToken(context.Background(), sign(test.claim), test.nonce)
+		if (err == nil) != test.ok {
+			t.Errorf("nonce claim %q, expected %q: got error %v", test.claim, test.nonce, err)
+		}
+	}
+}
+
+func TestJWKSRefreshIsRateLimited(t *testing.T) {
+	var stub = newStubProvider(t)
+	var p = newTestProvider(t, stub)
+	var sign = func(key *jwt.Key) string {
+		var claims = jwt.NewClaims("user-1", time.Minute)
+		claims.Issuer = stub.URL
+		claims.Audience = jwt.Audience{"client"}
+		var token, err = jwt.Sign(claims, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return token
+	}
+
+	if _, err := p.VerifyIDToken(context.Background(), sign(stub.signKey), ""); err != nil {
+		t.Fatal(err)
+	}
+	var unknown = newECDSAKey(t, "unknown")
+	for i := 0; i < 5; i++ {
+		if _, err := p.VerifyIDToken(context.Background(), sign(unknown), ""); !errors.Is(err, jwt.ErrKeyNotFound) {
+			t.Fatalf("got error %v, want %v", err, jwt.ErrKeyNotFound)
+		}
+	}
+	if hits := stub.jwksHits.Load(); hits != 1 {
+		t.Errorf("JWKS was fetched %d times, want 1", hits)
+	}
+
+	// A rotated key is picked up once the interval has passed.
+	var interval = JWKS_REFRESH_INTERVAL
+	JWKS_REFRESH_INTERVAL = 0
+	defer func() { JWKS_REFRESH_INTERVAL = interval }()
+	var rotated = newECDSAKey(t, "key-2")
+	stub.keys.Add(rotated)
+	if _, err := p.VerifyIDToken(context.Background(), sign(rotated), ""); err != nil {
+		t.Errorf("rotated key: %v", err)
+	}
+	if hits := stub.jwksHits.Load(); hits != 2 {
+		t.Errorf("JWKS was fetched %d times, want 2", hits)
+	}
+}
+
+func TestVerifyIDTokenWithoutKeyID(t *testing.T) {
+	var stub = newStubProvider(t)
+	// A provider with a single key, which leaves the kid out of its JWKS and tokens.
+	stub.signKey = newECDSAKey(t, "")
+	stub.keys = jwt.NewKeySet(stub.signKey)
+	var p = newTestProvider(t, stub)
+
+	var claims = jwt.NewClaims("user-1", time.Minute)
+	claims.Issuer = stub.URL
+	claims.Audience = jwt.Audience{"client"}
+	var token, err = jwt.Sign(claims, stub.signKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	verified, err := p.VerifyIDToken(context.Background(), token, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if verified.Subject != "user-1" {
+		t.Errorf("got subject %q, want %q", verified.Subject, "user-1")
+	}
+
+	var other = newECDSAKey(t, "")
+	token, err = jwt.Sign(claims, other)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.VerifyIDToken(context.Background(), token, ""); !errors.Is(err, jwt.ErrSignature) {
+		t.Errorf("got error %v, want %v", err, jwt.ErrSignature)
+	}
+}
@@ -0,0 +1,235 @@
+package oauth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/Nigel2392/router/v3/auth"
+	"github.com/Nigel2392/router/v3/jwt"
+)
+
+// Token is the response of the token endpoint.
+type Token struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type,omitempty"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	ExpiresIn    int64  `json:"expires_in,omitempty"`
+	IDToken      string `json:"id_token,omitempty"`
+	Scope        string `json:"scope,omitempty"`
+
+	// Time the token expires, calculated from ExpiresIn.
+	Expiry time.Time `json:"-"`
+}
+
+// ProviderError is an error returned by the provider.
+type ProviderError struct {
+	Code        string `json:"error"`
+	Description string `json:"error_description,omitempty"`
+	StatusCode  int    `json:"-"`
+}
+
+func (e *ProviderError) Error() string {
+	if e.Description != "" {
+		return "oauth: " + e.Code + ": " + e.Description
+	}
+	return "oauth: " + e.Code
+}
+
+// Exchange the authorization code for tokens.
+//
+// The verifier is the PKCE code verifier which was stored when the user was redirected.
+func (p *Provider) Exchange(ctx context.Context, code, verifier string) (*Token, error) {
+	var form = url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", p.Config.RedirectURL)
+	form.Set("client_id", p.Config.ClientID)
+	if verifier != "" {
+		form.Set("code_verifier", verifier)
+	}
+
+	var req, err = http.NewRequestWithContext(ctx, http.MethodPost, p.Config.Endpoint.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if p.Config.ClientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(p.Config.ClientID), url.QueryEscape(p.Config.ClientSecret))
+	}
+
+	var token = &Token{}
+	if err := p.doJSON(req, token); err != nil {
+		return nil, err
+	}
+	if token.AccessToken == "" {
+		return nil, errors.New("oauth: token response has no access_token")
+	}
+	if token.ExpiresIn > 0 {
+		token.Expiry = time.Now().Add(time.Duration(token.ExpiresIn) * time.Second)
+	}
+	return token, nil
+}
+
+// UserInfo fetches the claims of the user from the userinfo endpoint.
+func (p *Provider) UserInfo(ctx context.Context, accessToken string) (map[string]any, error) {
+	var req, err = http.NewRequestWithContext(ctx, http.MethodGet, p.Config.Endpoint.UserInfoURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	req.Header.Set("Accept", "application/json")
+	var info = make(map[string]any)
+	if err := p.doJSON(req, &info); err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+// Minimum time between two fetches of the provider's JWKS.
+//
+// A token signed with an unknown key triggers a refetch, this limits how often that can happen.
+var JWKS_REFRESH_INTERVAL = time.Minute
+
+// VerifyIDToken verifies the ID token's signature with the provider's JWKS,
+// and validates the issuer, audience, expiry and nonce.
+//
+// If the nonce is empty, the ID token must not have a nonce claim.
+func (p *Provider) VerifyIDToken(ctx context.Context, idToken, nonce string) (*jwt.Claims, error) {
+	var keys, err = p.keySet(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+	var opts = &jwt.ValidationOptions{
+		Issuer:        p.Config.Endpoint.Issuer,
+		Audience:      p.Config.ClientID,
+		RequireExpiry: true,
+		Leeway:        time.Minute,
+	}
+	claims, err := jwt.Verify(idToken, keys, opts)
+	if errors.Is(err, jwt.ErrKeyNotFound) {
+		// The provider might have rotated its keys.
+		if keys, err = p.keySet(ctx, true); err != nil {
+			return nil, err
+		}
+		claims, err = jwt.Verify(idToken, keys, opts)
+	}
+	if err != nil {
+		return nil, err
+	}
+	var n, _ = claims.Get("nonce").(string)
+	if (nonce != "" || n != "") && (n == "" || !auth.CompareSecrets(n, nonce)) {
+		return nil, ErrInvalidNonce
+	}
+	return claims, nil
+}
+
+func (p *Provider) keySet(ctx context.Context, refresh bool) (*jwt.KeySet, error) {
+	p.keysMu.Lock()
+	defer p.keysMu.Unlock()
+	if p.keys != nil && (!refresh || time.Since(p.keysFetched) < JWKS_REFRESH_INTERVAL) {
+		return p.keys, nil
+	}
+	if p.Config.Endpoint.JWKSURL == "" {
+		return nil, errors.New("oauth: no JWKS URL configured")
+	}
+	var req, err = http.NewRequestWithContext(ctx, http.MethodGet, p.Config.Endpoint.JWKSURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := p.Config.HTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("oauth: fetching JWKS: %s", resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, err
+	}
+	keys, err := jwt.ParseJWKS(data)
+	if err != nil {
+		return nil, err
+	}
+	p.keys = keys
+	p.keysFetched = time.Now()
+	return keys, nil
+}
+
+// Discover creates a provider from the issuer's OpenID Connect discovery document.
+func Discover(ctx context.Context, issuer string, config *Config) (*Provider, error) {
+	var p = New(config)
+	var u = strings.TrimSuffix(issuer, "/") + "/.well-known/openid-configuration"
+	var req, err = http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	var doc struct {
+		Issuer      string `json:"issuer"`
+		AuthURL     string `json:"authorization_endpoint"`
+		TokenURL    string `json:"token_endpoint"`
+		UserInfoURL string `json:"userinfo_endpoint"`
+		JWKSURL     string `json:"jwks_uri"`
+	}
+	if err := p.doJSON(req, &doc); err != nil {
+		return nil, err
+	}
+	if doc.Issuer != issuer {
+		return nil, fmt.Errorf("oauth: issuer %q does not match discovery document %q", issuer, doc.Issuer)
+	}
+	config.Endpoint = Endpoint{
+		AuthURL:     doc.AuthURL,
+		TokenURL:    doc.TokenURL,
+		UserInfoURL: doc.UserInfoURL,
+		JWKSURL:     doc.JWKSURL,
+		Issuer:      doc.Issuer,
+	}
+	return p, nil
+}
+
+func (p *Provider) doJSON(req *http.Request, v any) error {
+	var resp, err = p.Config.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		var perr = &ProviderError{StatusCode: resp.StatusCode}
+		if json.Unmarshal(data, perr) != nil || perr.Code == "" {
+			perr.Code = resp.Status
+		}
+		return perr
+	}
+	return json.Unmarshal(data, v)
+}
+
+// Generate a random string, used for the state, nonce and PKCE verifier.
+func randomString() string {
+	var b = make([]byte, 32)
+	if _, err := io.ReadFull(rand.Reader, b); err != nil {
+		panic(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// PKCE S256 code challenge for the verifier.
+func challenge(verifier string) string {
+	var sum = sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
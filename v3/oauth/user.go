@@ -0,0 +1,54 @@
+package oauth
+
+import (
+	"github.com/Nigel2392/router/v3/request"
+)
+
+// User is the default user created after logging in.
+type User struct {
+	Subject string
+	Email   string
+	Name    string
+	Picture string
+
+	// All claims from the ID token and userinfo endpoint.
+	Claims map[string]any
+
+	// The tokens returned by the provider.
+	Token *Token
+}
+
+// NewUser creates a new user from the claims.
+func NewUser(claims map[string]any, token *Token) *User {
+	var u = &User{Claims: claims, Token: token}
+	u.Subject, _ = claims["sub"].(string)
+	u.Email, _ = claims["email"].(string)
+	u.Name, _ = claims["name"].(string)
+	u.Picture, _ = claims["picture"].(string)
+	return u
+}
+
+// A user who completed the login flow is always authenticated.
+func (u *User) IsAuthenticated() bool {
+	return true
+}
+
+// Users from a provider are never administrators.
+// Use Config.MapUser to map them to your own users.
+func (u *User) IsAdmin() bool {
+	return false
+}
+
+// Users from a provider have no permissions.
+// Use Config.MapUser to map them to your own users.
+func (u *User) HasPermissions(permissions ...string) bool {
+	return len(permissions) == 0
+}
+
+// The identifier stored in the session by the default OnLogin.
+func subject(user request.User) (string, bool) {
+	if u, ok := user.(*User); ok && u.Subject != "" {
+		return u.Subject, true
+	}
+	return "", false
+}
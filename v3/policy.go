@@ -0,0 +1,164 @@
+package router
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/Nigel2392/router/v3/request"
+)
+
+// Policy describes the requirements a user must meet to access a route.
+//
+// A user must always be authenticated to pass a policy.
+type Policy struct {
+	// The user must have all of these permissions.
+	AllOf []string
+
+	// The user must have at least one of these permissions.
+	AnyOf []string
+
+	// The user must be an administrator.
+	Admin bool
+}
+
+// AllOf returns a policy which requires all of the permissions.
+func AllOf(permissions ...string) *Policy {
+	return &Policy{AllOf: permissions}
+}
+
+// AnyOf returns a policy which requires at least one of the permissions.
+func AnyOf(permissions ...string) *Policy {
+	return &Policy{AnyOf: permissions}
+}
+
+// AdminOnly returns a policy which requires the user to be an administrator.
+func AdminOnly() *Policy {
+	return &Policy{Admin: true}
+}
+
+// Allows reports whether the user meets the requirements of the policy.
+func (p *Policy) Allows(u request.User) bool {
+	if u == nil || !u.IsAuthenticated() {
+		return false
+	}
+	if p.Admin && !u.IsAdmin() {
+		return false
+	}
+	if len(p.AllOf) > 0 && !u.HasPermissions(p.AllOf...) {
+		return false
+	}
+	if len(p.AnyOf) > 0 {
+		for _, perm := range p.AnyOf {
+			if u.HasPermissions(perm) {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// Returns a readable representation of the policy.
+//
+// For example: admin & all(post.edit, post.delete) & any(staff, editor)
+func (p *Policy) String() string {
+	var parts = make([]string, 0, 3)
+	if p.Admin {
+		parts = append(parts, "admin")
+	}
+	if len(p.AllOf) > 0 {
+		parts = append(parts, "all("+strings.Join(p.AllOf, ", ")+")")
+	}
+	if len(p.AnyOf) > 0 {
+		parts = append(parts, "any("+strings.Join(p.AnyOf, ", ")+")")
+	}
+	if len(parts) == 0 {
+		return "authenticated"
+	}
+	return strings.Join(parts, " & ")
+}
+
+// Policies is a list of policies which must all be met.
+type Policies []*Policy
+
+// Allows reports whether the user meets the requirements of all policies.
+func (p Policies) Allows(u request.User) bool {
+	for _, policy := range p {
+		if !policy.Allows(u) {
+			return false
+		}
+	}
+	return true
+}
+
+func (p Policies) String() string {
+	var parts = make([]string, len(p))
+	for i, policy := range p {
+		parts[i] = policy.String()
+	}
+	return strings.Join(parts, " & ")
+}
+
+// PolicyMiddleware returns a middleware which only allows users who meet all of the policies.
+//
+// If forbidden is nil, the router's ForbiddenHandler is called,
+// or a 403 Forbidden response is written if it is not set.
+func PolicyMiddleware(forbidden Handler, policies ...*Policy) Middleware {
+	return func(next Handler) Handler {
+		return HandleFunc(func(r *request.Request) {
+			if Policies(policies).Allows(r.User) {
+				next.ServeHTTP(r)
+				return
+			}
+			if forbidden != nil {
+				forbidden.ServeHTTP(r)
+				return
+			}
+			r.Forbidden()
+		})
+	}
+}
+
+// RoutePermissions describes the policies required for a single route.
+type RoutePermissions struct {
+	Method   string
+	Path     string
+	Name     string
+	Policies Policies
+}
+
+// Permissions returns the policies required for every route with a handler.
+//
+// This can be used to audit which routes are accessible to whom.
+func (r *Router) Permissions() []RoutePermissions {
+	var perms = make([]RoutePermissions, 0)
+	for _, route := range r.routes {
+		WalkRoutes(route, func(route *Route, _ int) {
+			if route.HandlerFunc == nil {
+				return
+			}
+			var policies = append(append(Policies{}, r.policies...), route.allPolicies()...)
+			perms = append(perms, RoutePermissions{
+				Method:   route.Method,
+				Path:     string(route.Path),
+				Name:     route.name,
+				Policies: policies,
+			})
+		})
+	}
+	return perms
+}
+
+// Returns the required policies for all routes in a nicely formatted string for auditing.
+func (r *Router) PermissionsString() string {
+	var buf bytes.Buffer
+	for _, p := range r.Permissions() {
+		var policies = "public"
+		if len(p.Policies) > 0 {
+			policies = p.Policies.String()
+		}
+		fmt.Fprintf(&buf, "%s %s -> %s [%s]\n", p.Method, p.Path, p.Name, policies)
+	}
+	return buf.String()
+}
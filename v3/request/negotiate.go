@@ -0,0 +1,133 @@
+package request
+
+import (
+	"mime"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// MediaRange is a single entry of an Accept header.
+type MediaRange struct {
+	Type    string
+	Subtype string
+	Quality float64
+	Params  map[string]string
+}
+
+// Reports whether the media type matches the range.
+//
+// Returns the specificity of the match, higher is more specific.
+// A specificity of -1 means the range does not match.
+func (m MediaRange) match(mediaType string) int {
+	var typ, sub, ok = strings.Cut(mediaType, "/")
+	if !ok {
+		return -1
+	}
+	switch {
+	case m.Type == "*" && m.Subtype == "*":
+		return 0
+	case m.Type == typ && m.Subtype == "*":
+		return 1
+	case m.Type == typ && m.Subtype == sub:
+		return 2
+	}
+	return -1
+}
+
+// ParseAccept parses an Accept header into media ranges,
+// sorted by quality, highest first.
+//
+// Invalid entries are skipped.
+func ParseAccept(header string) []MediaRange {
+	var ranges = make([]MediaRange, 0)
+	for _, part := range strings.Split(header, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		var mediaType, params, err = mime.ParseMediaType(part)
+		if err != nil {
+			continue
+		}
+		var typ, sub, ok = strings.Cut(mediaType, "/")
+		if !ok {
+			continue
+		}
+		var rng = MediaRange{Type: typ, Subtype: sub, Quality: 1, Params: params}
+		if q, ok := params["q"]; ok {
+			if f, err := strconv.ParseFloat(q, 64); err == nil && f >= 0 && f <= 1 {
+				rng.Quality = f
+			}
+			delete(params, "q")
+		}
+		ranges = append(ranges, rng)
+	}
+	sort.SliceStable(ranges, func(i, j int) bool {
+		return ranges[i].Quality > ranges[j].Quality
+	})
+	return ranges
+}
+
+// Negotiate returns the offer which best matches the Accept header.
+//
+// Offers are given in order of preference,
+// which is used to break ties between offers of equal quality.
+//
+// If the header is empty, the first offer is returned.
+// If none of the offers are acceptable, an empty string is returned.
+func Negotiate(acceptHeader string, offers ...string) string {
+	if len(offers) == 0 {
+		return ""
+	}
+	if strings.TrimSpace(acceptHeader) == "" {
+		return offers[0]
+	}
+	var ranges = ParseAccept(acceptHeader)
+	var best string
+	var bestQuality float64
+	for _, offer := range offers {
+		var offerType = MediaType(offer)
+		// The quality of the most specific matching range is used.
+		var quality, specificity = 0.0, -1
+		for _, rng := range ranges {
+			if s := rng.match(offerType); s > specificity {
+				quality, specificity = rng.Quality, s
+			}
+		}
+		if quality > bestQuality {
+			best, bestQuality = offer, quality
+		}
+	}
+	return best
+}
+
+// MediaType returns the lowercased media type, without any parameters.
+//
+// For example: "application/json; charset=utf-8" returns "application/json".
+func MediaType(contentType string) string {
+	var mediaType, _, err = mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType, _, _ = strings.Cut(contentType, ";")
+		return strings.ToLower(strings.TrimSpace(mediaType))
+	}
+	return mediaType
+}
+
+// Accepts returns the offer which best matches the request's Accept header.
+//
+// Offers are given in order of preference.
+// If none of the offers are acceptable, an empty string is returned.
+//
+//	switch r.Accepts("application/json", "text/html") {
+//	case "application/json":
+//		...
+//	}
+func (r *Request) Accepts(offers ...string) string {
+	return Negotiate(r.Request.Header.Get("Accept"), offers...)
+}
+
+// ContentType returns the media type of the request body, without any parameters.
+func (r *Request) ContentType() string {
+	return MediaType(r.Request.Header.Get("Content-Type"))
+}
@@ -0,0 +1,133 @@
+package params
+
+import (
+	"encoding"
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	timeType          = reflect.TypeOf(time.Time{})
+	uuidType          = reflect.TypeOf(UUID{})
+	textUnmarshalType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// Bind sets the fields of the struct dest points to, from the URL parameters.
+//
+// The parameter of a field is named by its `param` tag, or matched case-insensitively to the field name.
+// Fields tagged with `param:"-"`, and fields without a parameter, are left unchanged.
+// Time fields are parsed with the layout in the `layout` tag, or TIME_LAYOUT.
+//
+//	var args struct {
+//		ID   int64     `param:"id"`
+//		Day  time.Time `param:"day" layout:"2006-01-02"`
+//		Slug string
+//	}
+//	err := params.Bind(r.URLParams, &args)
+func Bind(u URLParams, dest any) error {
+	var v = reflect.ValueOf(dest)
+	if v.Kind() != reflect.Pointer || v.Elem().Kind() != reflect.Struct {
+		return errors.New("params: Bind requires a pointer to a struct")
+	}
+	v = v.Elem()
+	var t = v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		var field = t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		var key, ok = u.fieldKey(field)
+		if !ok {
+			continue
+		}
+		if err := setField(v.Field(i), field, key, u[key]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Returns the parameter name of the field, if the parameter is present.
+func (u URLParams) fieldKey(field reflect.StructField) (string, bool) {
+	var tag = field.Tag.Get("param")
+	if tag == "-" {
+		return "", false
+	}
+	if tag != "" {
+		var _, ok = u[tag]
+		return tag, ok
+	}
+	for key := range u {
+		if strings.EqualFold(key, field.Name) {
+			return key, true
+		}
+	}
+	return "", false
+}
+
+func setField(v reflect.Value, field reflect.StructField, key, value string) error {
+	var fail = func(err error) error {
+		return &ParamError{Key: key, Value: value, Type: field.Type.String(), Err: err}
+	}
+
+	switch {
+	case field.Type == timeType:
+		var layout = field.Tag.Get("layout")
+		if layout == "" {
+			layout = TIME_LAYOUT
+		}
+		var t, err = time.Parse(layout, value)
+		if err != nil {
+			return fail(err)
+		}
+		v.Set(reflect.ValueOf(t))
+		return nil
+	case field.Type == uuidType:
+		var u, err = ParseUUID(value)
+		if err != nil {
+			return fail(err)
+		}
+		v.Set(reflect.ValueOf(u))
+		return nil
+	case reflect.PointerTo(field.Type).Implements(textUnmarshalType):
+		if err := v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(value)); err != nil {
+			return fail(err)
+		}
+		return nil
+	}
+
+	switch field.Type.Kind() {
+	case reflect.String:
+		v.SetString(value)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		var i, err = strconv.ParseInt(value, 10, field.Type.Bits())
+		if err != nil {
+			return fail(err)
+		}
+		v.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		var i, err = strconv.ParseUint(value, 10, field.Type.Bits())
+		if err != nil {
+			return fail(err)
+		}
+		v.SetUint(i)
+	case reflect.Float32, reflect.Float64:
+		var f, err = strconv.ParseFloat(value, field.Type.Bits())
+		if err != nil {
+			return fail(err)
+		}
+		v.SetFloat(f)
+	case reflect.Bool:
+		var b, err = strconv.ParseBool(value)
+		if err != nil {
+			return fail(err)
+		}
+		v.SetBool(b)
+	default:
+		return fail(errors.New("unsupported field type"))
+	}
+	return nil
+}
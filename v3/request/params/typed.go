@@ -0,0 +1,187 @@
+package params
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+// Layout used by Time and Bind, when no layout is given.
+var TIME_LAYOUT = "2006-01-02"
+
+// The parameter is not in the URL parameters.
+var ErrMissing = errors.New("missing parameter")
+
+// ParamError is returned when a parameter can not be parsed as the requested type.
+type ParamError struct {
+	Key   string
+	Value string
+	Type  string
+	Err   error
+}
+
+func (e *ParamError) Error() string {
+	if e.Err == ErrMissing {
+		return fmt.Sprintf("%s %q", ErrMissing, e.Key)
+	}
+	return fmt.Sprintf("parameter %q: %q is not a valid %s", e.Key, e.Value, e.Type)
+}
+
+func (e *ParamError) Unwrap() error {
+	return e.Err
+}
+
+// UUID is a parsed UUID, like 123e4567-e89b-12d3-a456-426614174000.
+type UUID [16]byte
+
+// String returns the UUID in its canonical form.
+func (u UUID) String() string {
+	var b = hex.EncodeToString(u[:])
+	return b[0:8] + "-" + b[8:12] + "-" + b[12:16] + "-" + b[16:20] + "-" + b[20:]
+}
+
+// ParseUUID parses a UUID in its canonical form.
+func ParseUUID(s string) (UUID, error) {
+	var u UUID
+	if len(s) != 36 || s[8] != '-' || s[13] != '-' || s[18] != '-' || s[23] != '-' {
+		return u, errors.New("invalid UUID length or format")
+	}
+	var b, err = hex.DecodeString(s[0:8] + s[9:13] + s[14:18] + s[19:23] + s[24:])
+	if err != nil {
+		return u, err
+	}
+	copy(u[:], b)
+	return u, nil
+}
+
+var slugRegex = regexp.MustCompile(`^[0-9a-zA-Z_-]+$`)
+
+// Returns the value of the key, or a ParamError if it is missing.
+func (u URLParams) lookup(key, typ string) (string, error) {
+	var v, ok = u[key]
+	if !ok {
+		return "", &ParamError{Key: key, Type: typ, Err: ErrMissing}
+	}
+	return v, nil
+}
+
+func parse[T any](u URLParams, key, typ string, f func(string) (T, error)) (T, error) {
+	var v, err = u.lookup(key, typ)
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	t, err := f(v)
+	if err != nil {
+		return t, &ParamError{Key: key, Value: v, Type: typ, Err: err}
+	}
+	return t, nil
+}
+
+func must[T any](v T, err error) T {
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
+// Int returns the parameter as an int.
+func (u URLParams) Int(key string) (int, error) {
+	return parse(u, key, "int", strconv.Atoi)
+}
+
+// Int64 returns the parameter as an int64.
+func (u URLParams) Int64(key string) (int64, error) {
+	return parse(u, key, "int64", func(s string) (int64, error) {
+		return strconv.ParseInt(s, 10, 64)
+	})
+}
+
+// Uint returns the parameter as an uint.
+func (u URLParams) Uint(key string) (uint, error) {
+	return parse(u, key, "uint", func(s string) (uint, error) {
+		var i, err = strconv.ParseUint(s, 10, 0)
+		return uint(i), err
+	})
+}
+
+// Float returns the parameter as a float64.
+func (u URLParams) Float(key string) (float64, error) {
+	return parse(u, key, "float", func(s string) (float64, error) {
+		return strconv.ParseFloat(s, 64)
+	})
+}
+
+// Bool returns the parameter as a bool, like strconv.ParseBool.
+func (u URLParams) Bool(key string) (bool, error) {
+	return parse(u, key, "bool", strconv.ParseBool)
+}
+
+// UUID returns the parameter as a UUID.
+func (u URLParams) UUID(key string) (UUID, error) {
+	return parse(u, key, "UUID", ParseUUID)
+}
+
+// Time returns the parameter as a time, parsed with the layout, or TIME_LAYOUT if no layout is given.
+func (u URLParams) Time(key string, layout ...string) (time.Time, error) {
+	var l = TIME_LAYOUT
+	if len(layout) > 0 {
+		l = layout[0]
+	}
+	return parse(u, key, "time", func(s string) (time.Time, error) {
+		return time.Parse(l, s)
+	})
+}
+
+// Slug returns the parameter if it only contains letters, digits, dashes and underscores.
+func (u URLParams) Slug(key string) (string, error) {
+	return parse(u, key, "slug", func(s string) (string, error) {
+		if !slugRegex.MatchString(s) {
+			return "", errors.New("invalid slug")
+		}
+		return s, nil
+	})
+}
+
+// MustInt is like Int, but panics if the parameter is missing or invalid.
+func (u URLParams) MustInt(key string) int {
+	return must(u.Int(key))
+}
+
+// MustInt64 is like Int64, but panics if the parameter is missing or invalid.
+func (u URLParams) MustInt64(key string) int64 {
+	return must(u.Int64(key))
+}
+
+// MustUint is like Uint, but panics if the parameter is missing or invalid.
+func (u URLParams) MustUint(key string) uint {
+	return must(u.Uint(key))
+}
+
+// MustFloat is like Float, but panics if the parameter is missing or invalid.
+func (u URLParams) MustFloat(key string) float64 {
+	return must(u.Float(key))
+}
+
+// MustBool is like Bool, but panics if the parameter is missing or invalid.
+func (u URLParams) MustBool(key string) bool {
+	return must(u.Bool(key))
+}
+
+// MustUUID is like UUID, but panics if the parameter is missing or invalid.
+func (u URLParams) MustUUID(key string) UUID {
+	return must(u.UUID(key))
+}
+
+// MustTime is like Time, but panics if the parameter is missing or invalid.
+func (u URLParams) MustTime(key string, layout ...string) time.Time {
+	return must(u.Time(key, layout...))
+}
+
+// MustSlug is like Slug, but panics if the parameter is missing or invalid.
+func (u URLParams) MustSlug(key string) string {
+	return must(u.Slug(key))
+}
@@ -0,0 +1,208 @@
+package params
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+var testParams = URLParams{
+	"int":   "-42",
+	"uint":  "42",
+	"float": "1.5",
+	"bool":  "true",
+	"uuid":  "123e4567-e89b-12d3-a456-426614174000",
+	"day":   "2023-01-31",
+	"month": "01/2023",
+	"slug":  "hello-world_2",
+	"bad":   "not valid!",
+	"big":   "99999999999999999999",
+}
+
+func TestTypedAccessors(t *testing.T) {
+	if v, err := testParams.Int("int"); err != nil || v != -42 {
+		t.Errorf("Int: got %v, %v", v, err)
+	}
+	if v, err := testParams.Int64("int"); err != nil || v != -42 {
+		t.Errorf("Int64: got %v, %v", v, err)
+	}
+	if v, err := testParams.Uint("uint"); err != nil || v != 42 {
+		t.Errorf("Uint: got %v, %v", v, err)
+	}
+	if v, err := testParams.Float("float"); err != nil || v != 1.5 {
+		t.Errorf("Float: got %v, %v", v, err)
+	}
+	if v, err := testParams.Bool("bool"); err != nil || !v {
+		t.Errorf("Bool: got %v, %v", v, err)
+	}
+	if v, err := testParams.UUID("uuid"); err != nil || v.String() != testParams["uuid"] {
+		t.Errorf("UUID: got %v, %v", v, err)
+	}
+	if v, err := testParams.Time("day"); err != nil || !v.Equal(time.Date(2023, 1, 31, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Time: got %v, %v", v, err)
+	}
+	if v, err := testParams.Time("month", "01/2006"); err != nil || v.Month() != time.January || v.Year() != 2023 {
+		t.Errorf("Time with layout: got %v, %v", v, err)
+	}
+	if v, err := testParams.Slug("slug"); err != nil || v != "hello-world_2" {
+		t.Errorf("Slug: got %v, %v", v, err)
+	}
+}
+
+func TestTypedAccessorErrors(t *testing.T) {
+	var accessors = map[string]func(key string) error{
+		"int":   func(key string) error { _, err := testParams.Int(key); return err },
+		"int64": func(key string) error { _, err := testParams.Int64(key); return err },
+		"uint":  func(key string) error { _, err := testParams.Uint(key); return err },
+		"float": func(key string) error { _, err := testParams.Float(key); return err },
+		"bool":  func(key string) error { _, err := testParams.Bool(key); return err },
+		"UUID":  func(key string) error { _, err := testParams.UUID(key); return err },
+		"time":  func(key string) error { _, err := testParams.Time(key); return err },
+		"slug":  func(key string) error { _, err := testParams.Slug(key); return err },
+	}
+	for typ, accessor := range accessors {
+		var err = accessor("missing")
+		if !errors.Is(err, ErrMissing) {
+			t.Errorf("%s: got error %v for a missing parameter, want ErrMissing", typ, err)
+		}
+
+		err = accessor("bad")
+		var paramErr *ParamError
+		if !errors.As(err, &paramErr) || errors.Is(err, ErrMissing) {
+			t.Errorf("%s: got error %v for an invalid parameter, want a ParamError", typ, err)
+			continue
+		}
+		if paramErr.Key != "bad" || paramErr.Value != "not valid!" || paramErr.Type != typ {
+			t.Errorf("%s: got %+v", typ, paramErr)
+		}
+	}
+
+	if _, err := testParams.Int64("big"); err == nil {
+		t.Error("Int64 should fail on overflow")
+	}
+	if _, err := testParams.Uint("int"); err == nil {
+		t.Error("Uint should fail on negative values")
+	}
+}
+
+func TestMustAccessors(t *testing.T) {
+	if testParams.MustInt("int") != -42 || testParams.MustUint("uint") != 42 || !testParams.MustBool("bool") {
+		t.Error("Must accessors should return the parsed values")
+	}
+
+	var musts = map[string]func(key string){
+		"MustInt":   func(key string) { testParams.MustInt(key) },
+		"MustInt64": func(key string) { testParams.MustInt64(key) },
+		"MustUint":  func(key string) { testParams.MustUint(key) },
+		"MustFloat": func(key string) { testParams.MustFloat(key) },
+		"MustBool":  func(key string) { testParams.MustBool(key) },
+		"MustUUID":  func(key string) { testParams.MustUUID(key) },
+		"MustTime":  func(key string) { testParams.MustTime(key) },
+		"MustSlug":  func(key string) { testParams.MustSlug(key) },
+	}
+	for name, must := range musts {
+		for _, key := range []string{"missing", "bad"} {
+			func() {
+				defer func() {
+					var r = recover()
+					if _, ok := r.(*ParamError); !ok {
+						t.Errorf("%s(%q): got panic %v, want a *ParamError", name, key, r)
+					}
+				}()
+				must(key)
+			}()
+		}
+	}
+}
+
+// Implements encoding.TextUnmarshaler.
+type upper string
+
+func (u *upper) UnmarshalText(text []byte) error {
+	if len(text) == 0 {
+		return errors.New("empty")
+	}
+	*u = upper(strings.ToUpper(string(text)))
+	return nil
+}
+
+func TestBind(t *testing.T) {
+	var args struct {
+		ID       int64     `param:"int"`
+		Uint     uint16    `param:"uint"`
+		Float    float32   `param:"float"`
+		Bool     bool      `param:"bool"`
+		UUID     UUID      `param:"uuid"`
+		Day      time.Time `param:"day"`
+		Month    time.Time `param:"month" layout:"01/2006"`
+		Slug     string
+		Skipped  string `param:"-"`
+		Absent   string `param:"absent"`
+		internal string
+	}
+	args.Absent = "unchanged"
+	var u = URLParams{}
+	for k, v := range testParams {
+		u[k] = v
+	}
+	u["SLUG"] = u["slug"]
+	delete(u, "slug")
+	u["skipped"] = "set"
+
+	if err := Bind(u, &args); err != nil {
+		t.Fatal(err)
+	}
+	if args.ID != -42 || args.Uint != 42 || args.Float != 1.5 || !args.Bool || args.UUID.String() != testParams["uuid"] {
+		t.Errorf("got %+v", args)
+	}
+	if args.Day.Day() != 31 || args.Month.Year() != 2023 {
+		t.Errorf("got times %v and %v", args.Day, args.Month)
+	}
+	if args.Slug != "hello-world_2" {
+		t.Errorf("fields should be matched case-insensitively, got %q", args.Slug)
+	}
+	if args.Skipped != "" || args.Absent != "unchanged" || args.internal != "" {
+		t.Errorf("fields without a parameter should be left unchanged, got %+v", args)
+	}
+
+	var text struct {
+		Value upper `param:"slug"`
+	}
+	if err := Bind(testParams, &text); err != nil || text.Value != "HELLO-WORLD_2" {
+		t.Errorf("TextUnmarshaler: got %q, %v", text.Value, err)
+	}
+	if err := Bind(URLParams{"slug": ""}, &text); !errors.As(err, new(*ParamError)) {
+		t.Errorf("TextUnmarshaler: got error %v, want a ParamError", err)
+	}
+}
+
+func TestBindErrors(t *testing.T) {
+	var tests = []struct {
+		name  string
+		value string
+		dest  any
+	}{
+		{"int8 overflow", "128", &struct{ V int8 }{}},
+		{"uint8 overflow", "256", &struct{ V uint8 }{}},
+		{"negative uint", "-1", &struct{ V uint }{}},
+		{"float32 overflow", "1e39", &struct{ V float32 }{}},
+		{"bool", "yes", &struct{ V bool }{}},
+		{"time", "31-01-2023", &struct{ V time.Time }{}},
+		{"UUID", "123", &struct{ V UUID }{}},
+		{"unsupported", "1", &struct{ V []int }{}},
+	}
+	for _, test := range tests {
+		var err = Bind(URLParams{"v": test.value}, test.dest)
+		var paramErr *ParamError
+		if !errors.As(err, &paramErr) || paramErr.Key != "v" || paramErr.Value != test.value {
+			t.Errorf("%s: got error %v, want a ParamError", test.name, err)
+		}
+	}
+
+	for _, dest := range []any{struct{}{}, new(int), nil} {
+		if err := Bind(URLParams{}, dest); err == nil {
+			t.Errorf("Bind(%T) should fail, it requires a pointer to a struct", dest)
+		}
+	}
+}
@@ -3,6 +3,7 @@ package request
 import (
 	"bytes"
 	"context"
+	"errors"
 	"net/http"
 	"net/url"
 	"strings"
@@ -30,6 +31,9 @@ type Request struct {
 	// URL Parameters set inside of the router.
 	URLParams params.URLParams
 
+	// The route which matched the request, set inside of the router.
+	Route RouteInfo
+
 	// Query parameters set inside of the router.
 	QueryParams url.Values
 
@@ -51,6 +55,18 @@ type Request struct {
 
 	// URL Func will be automatically set by the router.
 	URL func(method, name string) routevars.URLFormatter
+
+	// Reverse func will be automatically set by the router.
+	// It reverses a route by its full name, with positional arguments or a single map[string]any of named arguments.
+	Reverse func(name string, args ...any) (string, error)
+
+	// Error handler will be automatically set by the router.
+	// It is used to render errors returned by handlers.
+	ErrorHandler func(r *Request, err error)
+
+	// Forbidden handler will be automatically set by the router.
+	// It is called when the user does not meet the required policies.
+	ForbiddenHandler func(r *Request)
 }
 
 // Initialize a new request.
@@ -66,9 +82,24 @@ func NewRequest(writer writer.ClearableBufferedResponse, request *http.Request,
 	r.Data.Request.url = func(s string, i ...interface{}) string {
 		return r.URL("ALL", s).Format(i...)
 	}
+	r.Data.Request.reverse = func(name string, args ...any) (string, error) {
+		if r.Reverse == nil {
+			return "", errors.New("no URL resolver set for template request")
+		}
+		return r.Reverse(name, args...)
+	}
 	return r
 }
 
+// RouteInfo describes the route which matched a request.
+type RouteInfo struct {
+	// Full name of the route, like "api:users", empty if the route is unnamed.
+	Name string
+
+	// Path pattern of the route, like "/users/<<id:int>>".
+	Pattern string
+}
+
 // Write to the response.
 func (r *Request) Write(b []byte) (int, error) {
 	return r.Response.Write(b)
@@ -95,6 +126,29 @@ func (r *Request) Error(code int, err string) {
 	http.Error(r.Response, err, code)
 }
 
+// Handle an error with the error handler set by the router.
+//
+// If no error handler was set, a 500 Internal Server Error is written.
+func (r *Request) HandleError(err error) {
+	if r.ErrorHandler != nil {
+		r.ErrorHandler(r, err)
+		return
+	}
+	r.Error(http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+}
+
+// Respond that the user is not allowed to access the page,
+// with the forbidden handler set by the router.
+//
+// If no forbidden handler was set, a 403 Forbidden is written.
+func (r *Request) Forbidden() {
+	if r.ForbiddenHandler != nil {
+		r.ForbiddenHandler(r)
+		return
+	}
+	r.Error(http.StatusForbidden, http.StatusText(http.StatusForbidden))
+}
+
 // Get the request method.
 func (r *Request) Method() string {
 	return r.Request.Method
@@ -0,0 +1,166 @@
+package response
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/Nigel2392/router/v3/request"
+)
+
+// Template to render when an error occurs for a HTML request.
+//
+// The template receives the default request data, with the following keys set:
+// "Error" (the error message) and "StatusCode" (the HTTP status code).
+//
+// If empty, or if the template manager is not set, a plain text error is written.
+var ERROR_TEMPLATE string
+
+// HTTPError is an error with a HTTP status code.
+type HTTPError struct {
+	// HTTP status code.
+	StatusCode int
+
+	// Message shown to the client.
+	Message string
+
+	// The error that caused this error, never shown to the client.
+	Err error
+}
+
+// Create a new HTTPError
+//
+// If the message is empty, the status text is used.
+func NewHTTPError(statusCode int, message string, err ...error) *HTTPError {
+	if message == "" {
+		message = http.StatusText(statusCode)
+	}
+	var e = &HTTPError{StatusCode: statusCode, Message: message}
+	if len(err) > 0 {
+		e.Err = err[0]
+	}
+	return e
+}
+
+func (e *HTTPError) Error() string {
+	return e.Message
+}
+
+func (e *HTTPError) Unwrap() error {
+	return e.Err
+}
+
+// StatusCode returns the HTTP status code for the error.
+//
+// Errors which do not carry a status code result in a 500 Internal Server Error.
+func StatusCode(err error) int {
+	var jsonErr *JSONError
+	if errors.As(err, &jsonErr) && jsonErr.StatusCode != 0 {
+		return jsonErr.StatusCode
+	}
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) && httpErr.StatusCode != 0 {
+		return httpErr.StatusCode
+	}
+	var problem *Problem
+	if errors.As(err, &problem) && problem.Status != 0 {
+		return problem.Status
+	}
+	return http.StatusInternalServerError
+}
+
+// Returns the message which is safe to show to the client.
+//
+// Only messages of errors created in this package are shown,
+// any other error results in the status text.
+func publicMessage(err error, statusCode int) string {
+	var jsonErr *JSONError
+	if errors.As(err, &jsonErr) {
+		return jsonErr.Message
+	}
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) {
+		return httpErr.Message
+	}
+	var problem *Problem
+	if errors.As(err, &problem) {
+		return problem.Error()
+	}
+	return http.StatusText(statusCode)
+}
+
+// Error writes the error to the response.
+//
+// The format is decided by the request's Accept header:
+//   - JSON clients receive a JSONError, or a problem document for a *Problem
+//     (or for any error, if PROBLEM_DETAILS is true)
+//   - HTML clients receive the rendered ERROR_TEMPLATE
+//   - All other clients receive a plain text error
+//
+// Any buffered response is cleared first.
+func Error(r *request.Request, err error) {
+	var statusCode = StatusCode(err)
+	var message = publicMessage(err, statusCode)
+
+	r.Response.Clear()
+
+	var problem *Problem
+	if errors.As(err, &problem) {
+		// Headers like Allow are set for every format.
+		problem.writeHeaders(r)
+	}
+
+	switch preferredErrorFormat(r) {
+	case "json":
+		if problem == nil && PROBLEM_DETAILS {
+			problem = NewProblem(statusCode, "").WithError(err)
+			if message != problem.Title {
+				problem.Detail = message
+			}
+		}
+		if problem != nil {
+			if problem.Write(r) == nil {
+				return
+			}
+			r.Response.Clear()
+			break
+		}
+		var jsonErr *JSONError
+		if !errors.As(err, &jsonErr) {
+			jsonErr = NewJsonError(message, statusCode, err)
+		}
+		r.Response.WriteHeader(statusCode)
+		if jsonErr.Write(r) == nil {
+			return
+		}
+		r.Response.Clear()
+	case "html":
+		if ERROR_TEMPLATE != "" && TEMPLATE_MANAGER != nil {
+			r.Data.Set("Error", message)
+			r.Data.Set("StatusCode", statusCode)
+			r.Response.Header().Set("Content-Type", "text/html; charset=utf-8")
+			r.Response.WriteHeader(statusCode)
+			if Render(r, ERROR_TEMPLATE) == nil {
+				return
+			}
+			r.Response.Clear()
+		}
+	}
+	http.Error(r.Response, message, statusCode)
+}
+
+// Decide which format to render the error in.
+func preferredErrorFormat(r *request.Request) string {
+	if r.Request.Header.Get("Accept") == "" {
+		if r.ContentType() == MIMEJSON {
+			return "json"
+		}
+		return "text"
+	}
+	switch r.Accepts(MIMEText, MIMEJSON, MIMEProblemJSON, MIMEHTML) {
+	case MIMEJSON, MIMEProblemJSON:
+		return "json"
+	case MIMEHTML:
+		return "html"
+	}
+	return "text"
+}
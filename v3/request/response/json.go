@@ -109,8 +109,8 @@ func JsonEncode(r *request.Request, data interface{}, status ...ResponseStatus)
 
 // Decoode json from a request, into any.
 func JsonDecode(r *request.Request, data interface{}) error {
-	// Check header
-	if r.Request.Header.Get("Content-Type") != "application/json" {
+	// Check header, parameters like charset are allowed.
+	if r.ContentType() != MIMEJSON {
 		return errors.New("Content-Type is not application/json")
 	}
 	return json.NewDecoder(r.Request.Body).Decode(data)
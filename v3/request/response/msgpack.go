@@ -0,0 +1,433 @@
+package response
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// A small MessagePack implementation, so no dependency is needed.
+//
+// Structs are encoded as maps, using the same field names as encoding/json.
+// Time values are encoded as RFC 3339 strings.
+
+// MsgpackMarshal encodes the value as MessagePack.
+func MsgpackMarshal(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := msgpackEncode(&buf, reflect.ValueOf(v)); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// MsgpackUnmarshal decodes MessagePack data into the value.
+//
+// The data is first decoded into generic values,
+// which are then converted with encoding/json's rules.
+func MsgpackUnmarshal(data []byte, v any) error {
+	var generic, err = msgpackDecode(bytes.NewReader(data), 0)
+	if err != nil {
+		return err
+	}
+	jsonData, err := json.Marshal(generic)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(jsonData, v)
+}
+
+// Maximum nesting depth of arrays and maps decoded by MsgpackUnmarshal.
+var MSGPACK_MAX_DEPTH = 100
+
+var timeType = reflect.TypeOf(time.Time{})
+
+func msgpackEncode(buf *bytes.Buffer, v reflect.Value) error {
+	if !v.IsValid() {
+		buf.WriteByte(0xc0)
+		return nil
+	}
+	if v.Type() == timeType {
+		return msgpackEncode(buf, reflect.ValueOf(v.Interface().(time.Time).Format(time.RFC3339Nano)))
+	}
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			buf.WriteByte(0xc0)
+			return nil
+		}
+		return msgpackEncode(buf, v.Elem())
+	case reflect.Bool:
+		if v.Bool() {
+			buf.WriteByte(0xc3)
+		} else {
+			buf.WriteByte(0xc2)
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		msgpackInt(buf, v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		msgpackUint(buf, v.Uint())
+	case reflect.Float32:
+		buf.WriteByte(0xca)
+		binary.Write(buf, binary.BigEndian, math.Float32bits(float32(v.Float())))
+	case reflect.Float64:
+		buf.WriteByte(0xcb)
+		binary.Write(buf, binary.BigEndian, math.Float64bits(v.Float()))
+	case reflect.String:
+		var s = v.String()
+		msgpackHeader(buf, len(s), 0xa0, 32, 0xd9, 0xda, 0xdb)
+		buf.WriteString(s)
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			buf.WriteByte(0xc0)
+			return nil
+		}
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			var b = make([]byte, v.Len())
+			reflect.Copy(reflect.ValueOf(b), v)
+			msgpackHeader(buf, len(b), 0, 0, 0xc4, 0xc5, 0xc6)
+			buf.Write(b)
+			return nil
+		}
+		msgpackHeader(buf, v.Len(), 0x90, 16, 0, 0xdc, 0xdd)
+		for i := 0; i < v.Len(); i++ {
+			if err := msgpackEncode(buf, v.Index(i)); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		if v.IsNil() {
+			buf.WriteByte(0xc0)
+			return nil
+		}
+		var keys = v.MapKeys()
+		// Sort the keys, so the output is deterministic.
+		sort.Slice(keys, func(i, j int) bool {
+			return fmt.Sprint(keys[i].Interface()) < fmt.Sprint(keys[j].Interface())
+		})
+		msgpackHeader(buf, len(keys), 0x80, 16, 0, 0xde, 0xdf)
+		for _, k := range keys {
+			if err := msgpackEncode(buf, k); err != nil {
+				return err
+			}
+			if err := msgpackEncode(buf, v.MapIndex(k)); err != nil {
+				return err
+			}
+		}
+	case reflect.Struct:
+		var fields = msgpackFields(v)
+		msgpackHeader(buf, len(fields), 0x80, 16, 0, 0xde, 0xdf)
+		for _, f := range fields {
+			msgpackEncode(buf, reflect.ValueOf(f.name))
+			if err := msgpackEncode(buf, f.value); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("msgpack: unsupported type %s", v.Type())
+	}
+	return nil
+}
+
+type msgpackField struct {
+	name  string
+	value reflect.Value
+}
+
+// Returns the exported fields of the struct, named like encoding/json would.
+func msgpackFields(v reflect.Value) []msgpackField {
+	var fields = make([]msgpackField, 0, v.NumField())
+	var t = v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		var sf = t.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+		var name, opts, _ = strings.Cut(sf.Tag.Get("json"), ",")
+		if name == "-" && opts == "" {
+			continue
+		}
+		if name == "" {
+			name = sf.Name
+		}
+		var fv = v.Field(i)
+		if strings.Contains(opts, "omitempty") && fv.IsZero() {
+			continue
+		}
+		fields = append(fields, msgpackField{name: name, value: fv})
+	}
+	return fields
+}
+
+func msgpackInt(buf *bytes.Buffer, i int64) {
+	switch {
+	case i >= 0:
+		msgpackUint(buf, uint64(i))
+	case i >= -32:
+		buf.WriteByte(byte(i))
+	case i >= math.MinInt8:
+		buf.WriteByte(0xd0)
+		buf.WriteByte(byte(i))
+	case i >= math.MinInt16:
+		buf.WriteByte(0xd1)
+		binary.Write(buf, binary.BigEndian, int16(i))
+	case i >= math.MinInt32:
+		buf.WriteByte(0xd2)
+		binary.Write(buf, binary.BigEndian, int32(i))
+	default:
+		buf.WriteByte(0xd3)
+		binary.Write(buf, binary.BigEndian, i)
+	}
+}
+
+func msgpackUint(buf *bytes.Buffer, u uint64) {
+	switch {
+	case u <= 127:
+		buf.WriteByte(byte(u))
+	case u <= math.MaxUint8:
+		buf.WriteByte(0xcc)
+		buf.WriteByte(byte(u))
+	case u <= math.MaxUint16:
+		buf.WriteByte(0xcd)
+		binary.Write(buf, binary.BigEndian, uint16(u))
+	case u <= math.MaxUint32:
+		buf.WriteByte(0xce)
+		binary.Write(buf, binary.BigEndian, uint32(u))
+	default:
+		buf.WriteByte(0xcf)
+		binary.Write(buf, binary.BigEndian, u)
+	}
+}
+
+// Write the header for a string, binary, array or map of the given length.
+//
+// fix is the prefix of the fixed size format, which is used when the length is below fixMax.
+// A zero code means the format does not exist for that type.
+func msgpackHeader(buf *bytes.Buffer, n int, fix byte, fixMax int, code8, code16, code32 byte) {
+	switch {
+	case fixMax > 0 && n < fixMax:
+		buf.WriteByte(fix | byte(n))
+	case code8 != 0 && n <= math.MaxUint8:
+		buf.WriteByte(code8)
+		buf.WriteByte(byte(n))
+	case n <= math.MaxUint16:
+		buf.WriteByte(code16)
+		binary.Write(buf, binary.BigEndian, uint16(n))
+	default:
+		buf.WriteByte(code32)
+		binary.Write(buf, binary.BigEndian, uint32(n))
+	}
+}
+
+var (
+	errMsgpackTruncated = errors.New("msgpack: unexpected end of data")
+	errMsgpackDepth     = errors.New("msgpack: maximum nesting depth exceeded")
+	errMsgpackTooLarge  = errors.New("msgpack: data is larger than MAX_DECODE_SIZE")
+)
+
+// Decode a single value, depth is the number of arrays and maps it is nested in.
+func msgpackDecode(r *bytes.Reader, depth int) (any, error) {
+	var c, err = r.ReadByte()
+	if err != nil {
+		return nil, errMsgpackTruncated
+	}
+	switch {
+	case c <= 0x7f:
+		return int64(c), nil
+	case c >= 0xe0:
+		return int64(int8(c)), nil
+	case c&0xf0 == 0x80:
+		return msgpackDecodeMap(r, int(c&0x0f), depth)
+	case c&0xf0 == 0x90:
+		return msgpackDecodeArray(r, int(c&0x0f), depth)
+	case c&0xe0 == 0xa0:
+		return msgpackReadString(r, int(c&0x1f))
+	}
+	switch c {
+	case 0xc0:
+		return nil, nil
+	case 0xc2:
+		return false, nil
+	case 0xc3:
+		return true, nil
+	case 0xc4, 0xc5, 0xc6:
+		var n, err = msgpackReadLength(r, c-0xc4)
+		if err != nil {
+			return nil, err
+		}
+		return msgpackRead(r, n)
+	case 0xca:
+		var f float32
+		if err := msgpackBinary(r, &f); err != nil {
+			return nil, err
+		}
+		return float64(f), nil
+	case 0xcb:
+		var f float64
+		if err := msgpackBinary(r, &f); err != nil {
+			return nil, err
+		}
+		return f, nil
+	case 0xcc:
+		var u uint8
+		if err := msgpackBinary(r, &u); err != nil {
+			return nil, err
+		}
+		return uint64(u), nil
+	case 0xcd:
+		var u uint16
+		if err := msgpackBinary(r, &u); err != nil {
+			return nil, err
+		}
+		return uint64(u), nil
+	case 0xce:
+		var u uint32
+		if err := msgpackBinary(r, &u); err != nil {
+			return nil, err
+		}
+		return uint64(u), nil
+	case 0xcf:
+		var u uint64
+		if err := msgpackBinary(r, &u); err != nil {
+			return nil, err
+		}
+		return u, nil
+	case 0xd0:
+		var i int8
+		if err := msgpackBinary(r, &i); err != nil {
+			return nil, err
+		}
+		return int64(i), nil
+	case 0xd1:
+		var i int16
+		if err := msgpackBinary(r, &i); err != nil {
+			return nil, err
+		}
+		return int64(i), nil
+	case 0xd2:
+		var i int32
+		if err := msgpackBinary(r, &i); err != nil {
+			return nil, err
+		}
+		return int64(i), nil
+	case 0xd3:
+		var i int64
+		if err := msgpackBinary(r, &i); err != nil {
+			return nil, err
+		}
+		return i, nil
+	case 0xd9, 0xda, 0xdb:
+		var n, err = msgpackReadLength(r, c-0xd9)
+		if err != nil {
+			return nil, err
+		}
+		return msgpackReadString(r, n)
+	case 0xdc, 0xdd:
+		var n, err = msgpackReadLength(r, c-0xdc+1)
+		if err != nil {
+			return nil, err
+		}
+		return msgpackDecodeArray(r, n, depth)
+	case 0xde, 0xdf:
+		var n, err = msgpackReadLength(r, c-0xde+1)
+		if err != nil {
+			return nil, err
+		}
+		return msgpackDecodeMap(r, n, depth)
+	}
+	return nil, fmt.Errorf("msgpack: unsupported format 0x%02x", c)
+}
+
+// Read a length of 1, 2 or 4 bytes, for size 0, 1 and 2 respectively.
+func msgpackReadLength(r *bytes.Reader, size byte) (int, error) {
+	switch size {
+	case 0:
+		var n uint8
+		if err := msgpackBinary(r, &n); err != nil {
+			return 0, err
+		}
+		return int(n), nil
+	case 1:
+		var n uint16
+		if err := msgpackBinary(r, &n); err != nil {
+			return 0, err
+		}
+		return int(n), nil
+	default:
+		var n uint32
+		if err := msgpackBinary(r, &n); err != nil {
+			return 0, err
+		}
+		return int(n), nil
+	}
+}
+
+func msgpackBinary(r *bytes.Reader, v any) error {
+	if err := binary.Read(r, binary.BigEndian, v); err != nil {
+		return errMsgpackTruncated
+	}
+	return nil
+}
+
+func msgpackRead(r *bytes.Reader, n int) ([]byte, error) {
+	if n > r.Len() {
+		return nil, errMsgpackTruncated
+	}
+	var b = make([]byte, n)
+	if _, err := io.ReadFull(r, b); err != nil {
+		return nil, errMsgpackTruncated
+	}
+	return b, nil
+}
+
+func msgpackReadString(r *bytes.Reader, n int) (string, error) {
+	var b, err = msgpackRead(r, n)
+	return string(b), err
+}
+
+func msgpackDecodeArray(r *bytes.Reader, n int, depth int) ([]any, error) {
+	if depth >= MSGPACK_MAX_DEPTH {
+		return nil, errMsgpackDepth
+	}
+	if n > r.Len() {
+		return nil, errMsgpackTruncated
+	}
+	var arr = make([]any, n)
+	for i := range arr {
+		var v, err = msgpackDecode(r, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		arr[i] = v
+	}
+	return arr, nil
+}
+
+func msgpackDecodeMap(r *bytes.Reader, n int, depth int) (map[string]any, error) {
+	if depth >= MSGPACK_MAX_DEPTH {
+		return nil, errMsgpackDepth
+	}
+	if n > r.Len() {
+		return nil, errMsgpackTruncated
+	}
+	var m = make(map[string]any, n)
+	for i := 0; i < n; i++ {
+		var k, err = msgpackDecode(r, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		v, err := msgpackDecode(r, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		m[fmt.Sprint(k)] = v
+	}
+	return m, nil
+}
@@ -0,0 +1,190 @@
+package response
+
+import (
+	"bytes"
+	"errors"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Nigel2392/router/v3/request"
+	"github.com/Nigel2392/router/v3/request/writer"
+)
+
+type msgpackItem struct {
+	Name     string            `json:"name"`
+	Count    int               `json:"count"`
+	Price    float64           `json:"price"`
+	Ratio    float32           `json:"ratio"`
+	Active   bool              `json:"active"`
+	Tags     []string          `json:"tags"`
+	Meta     map[string]string `json:"meta,omitempty"`
+	Data     []byte            `json:"data"`
+	Created  time.Time         `json:"created"`
+	Parent   *msgpackItem      `json:"parent"`
+	Ignored  string            `json:"-"`
+	internal string
+}
+
+func TestMsgpackRoundTrip(t *testing.T) {
+	var values = []any{
+		nil, true, false,
+		0, 1, 127, 128, 255, 256, 65535, 65536, math.MaxUint32, uint64(math.MaxUint32) + 1, uint64(math.MaxInt64),
+		-1, -32, -33, -128, -129, -32768, -32769, math.MinInt32, int64(math.MinInt32) - 1, int64(math.MinInt64),
+		1.5, -0.25, math.MaxFloat64,
+		"", "hello", strings.Repeat("a", 31), strings.Repeat("b", 32), strings.Repeat("c", 256), strings.Repeat("d", 65536),
+		[]any{}, []any{1.0, "two", nil, []any{true}},
+		map[string]any{}, map[string]any{"a": 1.0, "b": map[string]any{"c": "d"}},
+	}
+	for _, v := range values {
+		var data, err = MsgpackMarshal(v)
+		if err != nil {
+			t.Errorf("marshal %v: %v", v, err)
+			continue
+		}
+		var got any
+		if err := MsgpackUnmarshal(data, &got); err != nil {
+			t.Errorf("unmarshal %v: %v", v, err)
+			continue
+		}
+		// Values are converted with encoding/json's rules, so numbers become float64.
+		var want = v
+		if f, ok := toFloat(v); ok {
+			want = f
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("round trip: got %#v, want %#v", got, want)
+		}
+	}
+}
+
+func toFloat(v any) (float64, bool) {
+	var rv = reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int64:
+		return float64(rv.Int()), true
+	case reflect.Uint64:
+		return float64(rv.Uint()), true
+	case reflect.Float64:
+		return rv.Float(), true
+	}
+	return 0, false
+}
+
+func TestMsgpackRoundTripStruct(t *testing.T) {
+	var item = msgpackItem{
+		Name:     "item",
+		Count:    -300,
+		Price:    12.5,
+		Ratio:    0.5,
+		Active:   true,
+		Tags:     []string{"a", "b"},
+		Meta:     map[string]string{"k": "v"},
+		Data:     []byte{0, 1, 2},
+		Created:  time.Date(2023, 4, 5, 6, 7, 8, 9, time.UTC),
+		Parent:   &msgpackItem{Name: "parent", Tags: []string{}},
+		Ignored:  "ignored",
+		internal: "internal",
+	}
+	var data, err = MsgpackMarshal(item)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got msgpackItem
+	if err := MsgpackUnmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+	// Binary data is decoded as a string, which encoding/json decodes as base64.
+	item.Data = nil
+	got.Data = nil
+	item.Ignored, item.internal = "", ""
+	if !reflect.DeepEqual(got, item) {
+		t.Errorf("got %+v, want %+v", got, item)
+	}
+}
+
+func TestMsgpackMalformed(t *testing.T) {
+	var nested = func(prefix byte, depth int) []byte {
+		return append(bytes.Repeat([]byte{prefix}, depth), 0xc0)
+	}
+	var tests = []struct {
+		name string
+		data []byte
+		err  error
+	}{
+		{"empty", []byte{}, errMsgpackTruncated},
+		{"truncated float32", []byte{0xca, 0x00}, errMsgpackTruncated},
+		{"truncated float64", []byte{0xcb, 0x00, 0x00}, errMsgpackTruncated},
+		{"truncated uint16", []byte{0xcd, 0x00}, errMsgpackTruncated},
+		{"truncated int64", []byte{0xd3, 0x00}, errMsgpackTruncated},
+		{"truncated string", []byte{0xa5, 'a', 'b'}, errMsgpackTruncated},
+		{"truncated str8 length", []byte{0xd9}, errMsgpackTruncated},
+		{"truncated binary", []byte{0xc4, 0x05, 0x00}, errMsgpackTruncated},
+		{"truncated array", []byte{0x93, 0x01}, errMsgpackTruncated},
+		{"huge array length", []byte{0xdd, 0xff, 0xff, 0xff, 0xff}, errMsgpackTruncated},
+		{"truncated map value", []byte{0x81, 0xa1, 'a'}, errMsgpackTruncated},
+		{"huge map length", []byte{0xdf, 0xff, 0xff, 0xff, 0xff}, errMsgpackTruncated},
+		{"deep arrays", nested(0x91, MSGPACK_MAX_DEPTH+1), errMsgpackDepth},
+		{"deep maps", append(bytes.Repeat([]byte{0x81, 0xa1, 'a'}, MSGPACK_MAX_DEPTH+1), 0xc0), errMsgpackDepth},
+		{"unsupported format", []byte{0xc1}, nil},
+		{"extension", []byte{0xd4, 0x01, 0x00}, nil},
+	}
+	for _, test := range tests {
+		var v any
+		var err = MsgpackUnmarshal(test.data, &v)
+		if err == nil {
+			t.Errorf("%s: expected an error", test.name)
+			continue
+		}
+		if test.err != nil && !errors.Is(err, test.err) {
+			t.Errorf("%s: got error %v, want %v", test.name, err, test.err)
+		}
+	}
+
+	var v any
+	if err := MsgpackUnmarshal(nested(0x91, MSGPACK_MAX_DEPTH), &v); err != nil {
+		t.Errorf("nesting up to MSGPACK_MAX_DEPTH should be allowed: %v", err)
+	}
+}
+
+func TestDecodeBodyLimit(t *testing.T) {
+	var limit = MAX_DECODE_SIZE
+	MAX_DECODE_SIZE = 64
+	defer func() { MAX_DECODE_SIZE = limit }()
+
+	var decode = func(contentType string, body []byte) error {
+		var rq = httptest.NewRequest("POST", "/", bytes.NewReader(body))
+		rq.Header.Set("Content-Type", contentType)
+		var r = request.NewRequest(writer.NewClearable(httptest.NewRecorder()), rq, nil)
+		var v any
+		return Decode(r, &v)
+	}
+
+	var small, _ = MsgpackMarshal([]string{"a", "b"})
+	var large, _ = MsgpackMarshal(strings.Repeat("a", 100))
+	var tests = []struct {
+		contentType string
+		body        []byte
+		status      int
+	}{
+		{MIMEMsgpack, small, 0},
+		{MIMEMsgpack, large, http.StatusRequestEntityTooLarge},
+		{MIMEJSON, []byte(`"a"`), 0},
+		{MIMEJSON, []byte(`"` + strings.Repeat("a", 100) + `"`), http.StatusRequestEntityTooLarge},
+		{"application/unknown", small, http.StatusUnsupportedMediaType},
+	}
+	for _, test := range tests {
+		var err = decode(test.contentType, test.body)
+		var httpErr *HTTPError
+		switch {
+		case test.status == 0 && err != nil:
+			t.Errorf("%s: unexpected error %v", test.contentType, err)
+		case test.status != 0 && (!errors.As(err, &httpErr) || httpErr.StatusCode != test.status):
+			t.Errorf("%s: got error %v, want status %d", test.contentType, err, test.status)
+		}
+	}
+}
@@ -0,0 +1,182 @@
+package response
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+
+	"github.com/Nigel2392/router/v3/request"
+)
+
+// Supported media types.
+const (
+	MIMEJSON    = "application/json"
+	MIMEXML     = "application/xml"
+	MIMETextXML = "text/xml"
+	MIMEHTML    = "text/html"
+	MIMEText    = "text/plain"
+	MIMEMsgpack = "application/msgpack"
+)
+
+// Encoder writes data to the response in a specific format.
+type Encoder func(w io.Writer, data any) error
+
+// Decoder reads a request body into a value.
+type Decoder func(r io.Reader, v any) error
+
+// Maximum size of a request body read by Decode, in bytes.
+//
+// Larger bodies result in a 413 Request Entity Too Large HTTPError.
+var MAX_DECODE_SIZE int64 = 10 << 20
+
+type codecRegistry struct {
+	// Encoders in order of preference.
+	encoderTypes []string
+	encoders     map[string]Encoder
+	decoders     map[string]Decoder
+	mu           sync.RWMutex
+}
+
+var codecs = &codecRegistry{
+	encoders: make(map[string]Encoder),
+	decoders: make(map[string]Decoder),
+}
+
+func init() {
+	RegisterEncoder(MIMEJSON, func(w io.Writer, data any) error {
+		return json.NewEncoder(w).Encode(data)
+	})
+	RegisterEncoder(MIMEXML, func(w io.Writer, data any) error {
+		return xml.NewEncoder(w).Encode(data)
+	})
+	RegisterEncoder(MIMEText, func(w io.Writer, data any) error {
+		_, err := fmt.Fprint(w, data)
+		return err
+	})
+	RegisterEncoder(MIMEMsgpack, func(w io.Writer, data any) error {
+		var b, err = MsgpackMarshal(data)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(b)
+		return err
+	})
+
+	RegisterDecoder(MIMEJSON, func(r io.Reader, v any) error {
+		return json.NewDecoder(r).Decode(v)
+	})
+	RegisterDecoder(MIMEXML, func(r io.Reader, v any) error {
+		return xml.NewDecoder(r).Decode(v)
+	})
+	RegisterDecoder(MIMETextXML, func(r io.Reader, v any) error {
+		return xml.NewDecoder(r).Decode(v)
+	})
+	RegisterDecoder(MIMEMsgpack, func(r io.Reader, v any) error {
+		var b, err = io.ReadAll(io.LimitReader(r, MAX_DECODE_SIZE+1))
+		if err != nil {
+			return err
+		}
+		if int64(len(b)) > MAX_DECODE_SIZE {
+			return errMsgpackTooLarge
+		}
+		return MsgpackUnmarshal(b, v)
+	})
+}
+
+// RegisterEncoder registers an encoder for a media type, which can be used by Negotiate.
+//
+// Newly registered media types are offered after the existing ones.
+// Registering an existing media type replaces its encoder.
+func RegisterEncoder(mediaType string, enc Encoder) {
+	codecs.mu.Lock()
+	defer codecs.mu.Unlock()
+	mediaType = request.MediaType(mediaType)
+	if _, ok := codecs.encoders[mediaType]; !ok {
+		codecs.encoderTypes = append(codecs.encoderTypes, mediaType)
+	}
+	codecs.encoders[mediaType] = enc
+}
+
+// RegisterDecoder registers a decoder for a media type, which can be used by Decode.
+func RegisterDecoder(mediaType string, dec Decoder) {
+	codecs.mu.Lock()
+	defer codecs.mu.Unlock()
+	codecs.decoders[request.MediaType(mediaType)] = dec
+}
+
+// Negotiate writes the data in the format which best matches the request's Accept header.
+//
+// JSON, XML, plain text and MessagePack are supported by default,
+// more formats can be added with RegisterEncoder.
+//
+// If templateName is not empty, HTML is offered as well.
+// The template is rendered with the data set on r.Data under the "Data" key,
+// if the data is a map[string]any, every key is set on r.Data instead.
+//
+// If none of the formats are acceptable, a 406 Not Acceptable HTTPError is returned.
+func Negotiate(r *request.Request, data any, templateName string) error {
+	codecs.mu.RLock()
+	var offers = make([]string, 0, len(codecs.encoderTypes)+1)
+	offers = append(offers, codecs.encoderTypes...)
+	codecs.mu.RUnlock()
+
+	// Browsers explicitly ask for HTML, so it does not need to be the first offer.
+	if templateName != "" {
+		offers = append(offers[:1], append([]string{MIMEHTML}, offers[1:]...)...)
+	}
+
+	var mediaType = r.Accepts(offers...)
+	request.AddHeader(r.Response, "Vary", "Accept")
+	switch mediaType {
+	case "":
+		return NewHTTPError(http.StatusNotAcceptable, "")
+	case MIMEHTML:
+		if m, ok := data.(map[string]any); ok {
+			for k, v := range m {
+				r.Data.Set(k, v)
+			}
+		} else {
+			r.Data.Set("Data", data)
+		}
+		r.Response.Header().Set("Content-Type", "text/html; charset=utf-8")
+		return Render(r, templateName)
+	}
+
+	codecs.mu.RLock()
+	var enc = codecs.encoders[mediaType]
+	codecs.mu.RUnlock()
+
+	var contentType = mediaType
+	if mediaType == MIMEText || mediaType == MIMEJSON || mediaType == MIMEXML {
+		contentType += "; charset=utf-8"
+	}
+	r.Response.Header().Set("Content-Type", contentType)
+	return enc(r.Response, data)
+}
+
+// Decode the request body into the value, with the decoder for the request's Content-Type.
+//
+// JSON, XML and MessagePack are supported by default,
+// more formats can be added with RegisterDecoder.
+//
+// If the Content-Type is not supported, a 415 Unsupported Media Type HTTPError is returned.
+// If the body is larger than MAX_DECODE_SIZE, a 413 Request Entity Too Large HTTPError is returned.
+func Decode(r *request.Request, v any) error {
+	var mediaType = r.ContentType()
+	codecs.mu.RLock()
+	var dec, ok = codecs.decoders[mediaType]
+	codecs.mu.RUnlock()
+	if !ok {
+		return NewHTTPError(http.StatusUnsupportedMediaType, "Unsupported Content-Type: "+mediaType)
+	}
+	var err = dec(http.MaxBytesReader(r.Response, r.Request.Body, MAX_DECODE_SIZE), v)
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) || errors.Is(err, errMsgpackTooLarge) {
+		return NewHTTPError(http.StatusRequestEntityTooLarge, "")
+	}
+	return err
+}
@@ -0,0 +1,199 @@
+package response
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/Nigel2392/router/v3/request"
+)
+
+// Media type of problem documents, as described in RFC 9457.
+const MIMEProblemJSON = "application/problem+json"
+
+// Render every error as a problem document for JSON clients,
+// instead of only errors of type *Problem.
+var PROBLEM_DETAILS bool
+
+// Problem is a problem details document, as described in RFC 9457.
+//
+// It implements the error interface, so it can be returned from a HandlerE.
+type Problem struct {
+	// URI reference which identifies the problem type.
+	// Defaults to "about:blank", which means the title is the status text.
+	Type string `json:"type,omitempty"`
+
+	// Short summary of the problem type.
+	Title string `json:"title,omitempty"`
+
+	// HTTP status code.
+	Status int `json:"status,omitempty"`
+
+	// Explanation specific to this occurrence of the problem.
+	Detail string `json:"detail,omitempty"`
+
+	// URI reference which identifies this occurrence of the problem.
+	Instance string `json:"instance,omitempty"`
+
+	// Extension members, added to the top level of the document.
+	Extensions map[string]any `json:"-"`
+
+	// Headers to set on the response, for example Allow or Retry-After.
+	Headers http.Header `json:"-"`
+
+	// The error that caused this problem, never shown to the client.
+	Err error `json:"-"`
+}
+
+// NewProblem creates a new problem with the status text as title.
+func NewProblem(status int, detail string) *Problem {
+	return &Problem{
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: detail,
+	}
+}
+
+func (p *Problem) Error() string {
+	if p.Detail != "" {
+		return p.Detail
+	}
+	return p.Title
+}
+
+func (p *Problem) Unwrap() error {
+	return p.Err
+}
+
+// With sets an extension member.
+func (p *Problem) With(key string, value any) *Problem {
+	if p.Extensions == nil {
+		p.Extensions = make(map[string]any)
+	}
+	p.Extensions[key] = value
+	return p
+}
+
+// WithError sets the error that caused the problem.
+func (p *Problem) WithError(err error) *Problem {
+	p.Err = err
+	return p
+}
+
+// WithHeader sets a header which is written with the problem.
+func (p *Problem) WithHeader(key, value string) *Problem {
+	if p.Headers == nil {
+		p.Headers = make(http.Header)
+	}
+	p.Headers.Set(key, value)
+	return p
+}
+
+func (p *Problem) MarshalJSON() ([]byte, error) {
+	var m = make(map[string]any, len(p.Extensions)+5)
+	for k, v := range p.Extensions {
+		m[k] = v
+	}
+	if p.Type != "" {
+		m["type"] = p.Type
+	}
+	if p.Title != "" {
+		m["title"] = p.Title
+	}
+	if p.Status != 0 {
+		m["status"] = p.Status
+	}
+	if p.Detail != "" {
+		m["detail"] = p.Detail
+	}
+	if p.Instance != "" {
+		m["instance"] = p.Instance
+	}
+	return json.Marshal(m)
+}
+
+// Write the problem document to the response.
+//
+// A problem without a status is written as a 500 Internal Server Error, like StatusCode reports.
+func (p *Problem) Write(r *request.Request) error {
+	var data, err = json.Marshal(p)
+	if err != nil {
+		return err
+	}
+	p.writeHeaders(r)
+	r.Response.Header().Set("Content-Type", MIMEProblemJSON)
+	r.Response.WriteHeader(StatusCode(p))
+	r.Response.Write(data)
+	return nil
+}
+
+func (p *Problem) writeHeaders(r *request.Request) {
+	for k, v := range p.Headers {
+		r.Response.Header()[k] = v
+	}
+}
+
+// FieldError is a single invalid field of a validation problem.
+type FieldError struct {
+	// Name of the field, or a JSON pointer to it.
+	Field string `json:"field"`
+
+	// Why the field is invalid.
+	Message string `json:"message"`
+}
+
+// Common problems.
+
+func BadRequest(detail string) *Problem {
+	return NewProblem(http.StatusBadRequest, detail)
+}
+
+func Unauthorized(detail string) *Problem {
+	return NewProblem(http.StatusUnauthorized, detail)
+}
+
+func Forbidden(detail string) *Problem {
+	return NewProblem(http.StatusForbidden, detail)
+}
+
+func NotFound(detail string) *Problem {
+	return NewProblem(http.StatusNotFound, detail)
+}
+
+// MethodNotAllowed sets the Allow header to the allowed methods.
+func MethodNotAllowed(detail string, allowed ...string) *Problem {
+	var p = NewProblem(http.StatusMethodNotAllowed, detail)
+	if len(allowed) > 0 {
+		p.WithHeader("Allow", strings.Join(allowed, ", "))
+	}
+	return p
+}
+
+func Conflict(detail string) *Problem {
+	return NewProblem(http.StatusConflict, detail)
+}
+
+func UnsupportedMediaType(detail string) *Problem {
+	return NewProblem(http.StatusUnsupportedMediaType, detail)
+}
+
+// ValidationProblem returns a 422 Unprocessable Entity problem,
+// with the invalid fields in the "errors" extension member.
+func ValidationProblem(detail string, fields ...FieldError) *Problem {
+	if fields == nil {
+		fields = make([]FieldError, 0)
+	}
+	return NewProblem(http.StatusUnprocessableEntity, detail).With("errors", fields)
+}
+
+func TooManyRequests(detail string) *Problem {
+	return NewProblem(http.StatusTooManyRequests, detail)
+}
+
+func InternalServerError(detail string) *Problem {
+	return NewProblem(http.StatusInternalServerError, detail)
+}
+
+func ServiceUnavailable(detail string) *Problem {
+	return NewProblem(http.StatusServiceUnavailable, detail)
+}
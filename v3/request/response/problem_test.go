@@ -0,0 +1,117 @@
+package response
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Nigel2392/router/v3/request"
+	"github.com/Nigel2392/router/v3/request/writer"
+)
+
+// Writes the error with Error, and returns the finalized response.
+func writeError(accept, contentType string, err error) *httptest.ResponseRecorder {
+	var rec = httptest.NewRecorder()
+	var rq = httptest.NewRequest("GET", "/", nil)
+	if accept != "" {
+		rq.Header.Set("Accept", accept)
+	}
+	if contentType != "" {
+		rq.Header.Set("Content-Type", contentType)
+	}
+	var r = request.NewRequest(writer.NewClearable(rec), rq, nil)
+	Error(r, err)
+	r.Response.Finalize()
+	return rec
+}
+
+func TestProblemWithoutStatus(t *testing.T) {
+	var p = &Problem{Title: "Something went wrong"}
+	if StatusCode(p) != http.StatusInternalServerError {
+		t.Fatalf("got status code %d, want 500", StatusCode(p))
+	}
+
+	var rec = httptest.NewRecorder()
+	var r = request.NewRequest(writer.NewClearable(rec), httptest.NewRequest("GET", "/", nil), nil)
+	if err := p.Write(r); err != nil {
+		t.Fatal(err)
+	}
+	r.Response.Finalize()
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("Write: got status %d, want 500", rec.Code)
+	}
+
+	rec = writeError(MIMEProblemJSON, "", p)
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("Error: got status %d, want 500", rec.Code)
+	}
+}
+
+func TestProblemNegotiation(t *testing.T) {
+	var problem = NotFound("no such user").With("id", 1.0)
+	var tests = []struct {
+		name        string
+		accept      string
+		contentType string
+		err         error
+		details     bool
+		status      int
+		mime        string
+	}{
+		{"problem+json", MIMEProblemJSON, "", problem, false, 404, MIMEProblemJSON},
+		{"json", MIMEJSON, "", problem, false, 404, MIMEProblemJSON},
+		{"json with parameters", "application/json; charset=utf-8", "", problem, false, 404, MIMEProblemJSON},
+		{"json body without accept", "", MIMEJSON, problem, false, 404, MIMEProblemJSON},
+		{"text", MIMEText, "", problem, false, 404, MIMEText},
+		{"html without template", MIMEHTML, "", problem, false, 404, MIMEText},
+		{"preferred over json", "text/plain, application/json;q=0.5", "", problem, false, 404, MIMEText},
+		{"other error", MIMEJSON, "", errors.New("secret"), false, 500, MIMEJSON},
+		{"other error with problem details", MIMEJSON, "", errors.New("secret"), true, 500, MIMEProblemJSON},
+		{"other error with problem details, text", MIMEText, "", errors.New("secret"), true, 500, MIMEText},
+	}
+	for _, test := range tests {
+		PROBLEM_DETAILS = test.details
+		var rec = writeError(test.accept, test.contentType, test.err)
+		PROBLEM_DETAILS = false
+
+		if rec.Code != test.status {
+			t.Errorf("%s: got status %d, want %d", test.name, rec.Code, test.status)
+		}
+		if mime := request.MediaType(rec.Header().Get("Content-Type")); mime != test.mime {
+			t.Errorf("%s: got content type %q, want %q", test.name, mime, test.mime)
+		}
+		if strings.Contains(rec.Body.String(), "secret") {
+			t.Errorf("%s: the message of an unknown error should not be shown: %s", test.name, rec.Body)
+		}
+		if test.mime != MIMEProblemJSON {
+			continue
+		}
+		var doc map[string]any
+		if err := json.Unmarshal(rec.Body.Bytes(), &doc); err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		if doc["status"] != float64(test.status) || doc["title"] != http.StatusText(test.status) {
+			t.Errorf("%s: got document %v", test.name, doc)
+		}
+		if test.err == problem && (doc["detail"] != "no such user" || doc["id"] != 1.0) {
+			t.Errorf("%s: got document %v", test.name, doc)
+		}
+	}
+}
+
+func TestProblemHeaders(t *testing.T) {
+	var problem = MethodNotAllowed("", "GET", "HEAD")
+	for _, accept := range []string{MIMEProblemJSON, MIMEText, MIMEHTML} {
+		var rec = writeError(accept, "", problem)
+		if rec.Code != http.StatusMethodNotAllowed {
+			t.Errorf("%s: got status %d, want 405", accept, rec.Code)
+		}
+		if allow := rec.Header().Get("Allow"); allow != "GET, HEAD" {
+			t.Errorf("%s: got Allow %q, want %q", accept, allow, "GET, HEAD")
+		}
+	}
+}
@@ -4,19 +4,35 @@ import (
 	"bytes"
 	"encoding/base64"
 	"encoding/gob"
+	"errors"
 	"html/template"
 )
 
 type TemplateRequest struct {
-	url  func(string, ...interface{}) string
-	User User
-	Next string
+	url     func(string, ...interface{}) string
+	reverse func(name string, args ...any) (string, error)
+	User    User
+	Next    string
 }
 
 func (tr *TemplateRequest) URL(path string, args ...interface{}) string {
 	return tr.url(path, args...)
 }
 
+// Reverse returns the URL of the route with the given name, with Router.Reverse.
+//
+// Arguments are either positional, or a single map[string]any of named arguments,
+// and are checked against the types of the path variables:
+//
+//	{{.Request.Reverse "user:detail" 5}}
+//	{{.Request.Reverse "user:detail" (dict "id" 5)}}
+func (tr *TemplateRequest) Reverse(name string, args ...any) (string, error) {
+	if tr.reverse == nil {
+		return "", errors.New("no URL resolver set for template request")
+	}
+	return tr.reverse(name, args...)
+}
+
 func init() {
 	gob.Register(Messages{})
 }
@@ -0,0 +1,33 @@
+package request
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Nigel2392/router/v3/request/writer"
+)
+
+func TestTemplateRequestReverse(t *testing.T) {
+	var r = NewRequest(writer.NewClearable(httptest.NewRecorder()), httptest.NewRequest("GET", "/", nil), nil)
+	if _, err := r.Data.Request.Reverse("index"); err == nil {
+		t.Error("Reverse without a URL resolver should fail")
+	}
+
+	var gotName string
+	var gotArgs []any
+	r.Reverse = func(name string, args ...any) (string, error) {
+		gotName, gotArgs = name, args
+		return "/users/5", nil
+	}
+	var got, err = r.Data.Request.Reverse("user:detail", 5)
+	if err != nil || got != "/users/5" {
+		t.Errorf("got %q, %v, want /users/5", got, err)
+	}
+	if gotName != "user:detail" || len(gotArgs) != 1 || gotArgs[0] != 5 {
+		t.Errorf("Reverse was called with %q %v", gotName, gotArgs)
+	}
+
+	if _, err := (&TemplateRequest{}).Reverse("index"); err == nil {
+		t.Error("Reverse without a URL resolver should fail")
+	}
+}
@@ -0,0 +1,37 @@
+package writer
+
+import "net/http"
+
+// StatusRecorder records the status code written to the response.
+//
+// It is used by middleware which reports the status, like metrics and tracing.
+type StatusRecorder struct {
+	ClearableBufferedResponse
+	status int
+}
+
+// NewStatusRecorder wraps the response.
+func NewStatusRecorder(w ClearableBufferedResponse) *StatusRecorder {
+	return &StatusRecorder{ClearableBufferedResponse: w}
+}
+
+// Status returns the status code written to the response,
+// or 200 if no status code was written.
+func (r *StatusRecorder) Status() int {
+	if r.status == 0 {
+		return http.StatusOK
+	}
+	return r.status
+}
+
+func (r *StatusRecorder) WriteHeader(code int) {
+	if r.status == 0 {
+		r.status = code
+	}
+	r.ClearableBufferedResponse.WriteHeader(code)
+}
+
+func (r *StatusRecorder) Clear() {
+	r.status = 0
+	r.ClearableBufferedResponse.Clear()
+}
@@ -0,0 +1,288 @@
+package router
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/Nigel2392/routevars"
+)
+
+var (
+	// No route has the given name.
+	ErrRouteNotFound = errors.New("route not found")
+
+	// An argument of the route's path was not given.
+	ErrMissingArgument = errors.New("missing argument")
+
+	// An argument does not match the type of the path variable.
+	ErrInvalidArgument = errors.New("invalid argument")
+
+	// An argument was given which is not a variable of the route's path.
+	ErrUnknownArgument = errors.New("unknown argument")
+)
+
+// Index of full route names, like "api:users:detail", to routes.
+//
+// Lookups only load the pointer, the lock is only taken to build the index.
+type routeIndex struct {
+	index atomic.Pointer[nameIndex]
+	mu    sync.Mutex
+}
+
+type nameIndex struct {
+	routes  map[string]*Route
+	version uint64
+}
+
+// Lookup returns the route with the given full name, like "api:users:detail".
+//
+// Route names are indexed once, and indexed again after routes were added.
+func (r *Router) Lookup(name string) (*Route, bool) {
+	var route, ok = r.names().routes[name]
+	return route, ok
+}
+
+func (r *Router) names() *nameIndex {
+	var version = r.version.Load()
+	if idx := r.index.index.Load(); idx != nil && idx.version == version {
+		return idx
+	}
+	r.index.mu.Lock()
+	defer r.index.mu.Unlock()
+	if idx := r.index.index.Load(); idx != nil && idx.version == version {
+		return idx
+	}
+	var idx = &nameIndex{routes: make(map[string]*Route), version: version}
+	r.Walk(func(route *Route, depth int) {
+		var name = route.FullName()
+		if _, ok := idx.routes[name]; name != "" && !ok {
+			idx.routes[name] = route
+		}
+	})
+	r.index.index.Store(idx)
+	return idx
+}
+
+// Reverse returns the path of the route with the given full name,
+// formatted with the named arguments, and with the query appended.
+//
+// Routes of routers added with Mount are reversed with the name of the mount, like "admin:users".
+// The paths of a mounted router include the prefix where it is mounted.
+//
+// Arguments are checked against the types of the path variables.
+//
+//	r.Reverse("blog:post", map[string]any{"id": 5}, url.Values{"page": {"2"}})
+//	// /blog/5?page=2
+func (r *Router) Reverse(name string, params map[string]any, query url.Values) (string, error) {
+	var route, ok = r.Lookup(name)
+	if !ok {
+		if path, mounted, err := r.reverseMounted(name, params, query); mounted {
+			return path, err
+		}
+		return "", fmt.Errorf("%w: %q", ErrRouteNotFound, name)
+	}
+	var path, err = route.Reverse(params)
+	if err != nil {
+		return "", fmt.Errorf("route %q: %w", name, err)
+	}
+	path = r.MountPoint() + path
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+	return path, nil
+}
+
+// Reverses the route with positional arguments, in the order of the path variables,
+// or with a single map[string]any of named arguments.
+//
+// It is set on requests, to reverse routes from templates.
+func (r *Router) reverseArgs(name string, args ...any) (string, error) {
+	if len(args) == 1 {
+		if named, ok := args[0].(map[string]any); ok {
+			return r.Reverse(name, named, nil)
+		}
+	}
+	var route, ok = r.Lookup(name)
+	if !ok {
+		for _, m := range r.mounts {
+			if m.router != nil && m.name != "" && strings.HasPrefix(name, m.name+":") {
+				return m.router.reverseArgs(strings.TrimPrefix(name, m.name+":"), args...)
+			}
+		}
+		return "", fmt.Errorf("%w: %q", ErrRouteNotFound, name)
+	}
+	var vars = route.variables()
+	if len(args) != len(vars) {
+		return "", fmt.Errorf("route %q: expected %d arguments, got %d", name, len(vars), len(args))
+	}
+	var named = make(map[string]any, len(vars))
+	for i, v := range vars {
+		named[v] = args[i]
+	}
+	return r.Reverse(name, named, nil)
+}
+
+// Returns the names of the route's path variables, in order.
+func (r *Route) variables() []string {
+	var vars = make([]string, 0)
+	for _, part := range strings.Split(string(r.Path), "/") {
+		if name, _, ok := pathVariable(part); ok {
+			vars = append(vars, name)
+		}
+	}
+	return vars
+}
+
+// AbsoluteURL is like Reverse, but prefixes the path with the router's BaseURL.
+//
+// Routes added with Router.Host use the scheme of the BaseURL, or https if it is not set,
+// and their host pattern formatted with the arguments.
+func (r *Router) AbsoluteURL(name string, params map[string]any, query url.Values) (string, error) {
+	var path, err = r.Reverse(name, params, query)
+	if err != nil {
+		return "", err
+	}
+	var route, _ = r.Lookup(name)
+	if host := route.hostPattern(); host != nil {
+		var h, err = host.format(params)
+		if err != nil {
+			return "", fmt.Errorf("route %q: %w", name, err)
+		}
+		var scheme = "https"
+		if u, err := url.Parse(r.BaseURL); err == nil && u.Scheme != "" {
+			scheme = u.Scheme
+		}
+		return scheme + "://" + h + path, nil
+	}
+	if r.BaseURL == "" {
+		return "", errors.New("router: BaseURL is not set")
+	}
+	return strings.TrimSuffix(r.BaseURL, "/") + path, nil
+}
+
+// FullName returns the names of the route's parents and the route itself, delimited by a colon.
+//
+// Unnamed parents are skipped, an unnamed route has no full name.
+// Names inherited from the parent are not part of the full name.
+func (r *Route) FullName() string {
+	if r.name == "" || r.inheritedName {
+		return ""
+	}
+	var names = make([]string, 0, 4)
+	for _, route := range r.lineage() {
+		if route.name != "" && !route.inheritedName {
+			names = append(names, route.name)
+		}
+	}
+	return strings.Join(names, ":")
+}
+
+// Reverse formats the route's path with named arguments.
+//
+// Arguments are checked against the types of the path variables.
+// The variables of the route's host pattern may be given, but are not part of the path.
+func (r *Route) Reverse(params map[string]any) (string, error) {
+	var used = 0
+	var parts = strings.Split(string(r.Path), "/")
+	for i, part := range parts {
+		var name, typ, ok = pathVariable(part)
+		if !ok {
+			continue
+		}
+		var v, given = params[name]
+		if !given {
+			return "", fmt.Errorf("%w %q", ErrMissingArgument, name)
+		}
+		used++
+		var value = fmt.Sprint(v)
+		if t, custom := lookupType(typ); custom && !t.match(value) || !custom && !typeRegex(typ).MatchString(value) {
+			return "", fmt.Errorf("%w %q: %q is not of type %s", ErrInvalidArgument, name, value, typ)
+		}
+		parts[i] = escapePathValue(value)
+	}
+	if used != len(params) {
+		for name := range params {
+			if !r.hasVariable(name) {
+				return "", fmt.Errorf("%w %q", ErrUnknownArgument, name)
+			}
+		}
+	}
+	return strings.Join(parts, "/"), nil
+}
+
+func (r *Route) hasVariable(name string) bool {
+	if host := r.hostPattern(); host != nil && host.hasVariable(name) {
+		return true
+	}
+	for _, part := range strings.Split(string(r.Path), "/") {
+		if n, _, ok := pathVariable(part); ok && n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Parse a path variable like <<id:int>>.
+//
+// A variable without a type, like <<id>>, uses its name as type, like routevars does.
+func pathVariable(part string) (name, typ string, ok bool) {
+	if !strings.HasPrefix(part, routevars.RT_PATH_VAR_PREFIX) || !strings.HasSuffix(part, routevars.RT_PATH_VAR_SUFFIX) {
+		return "", "", false
+	}
+	part = strings.TrimSuffix(strings.TrimPrefix(part, routevars.RT_PATH_VAR_PREFIX), routevars.RT_PATH_VAR_SUFFIX)
+	name, typ, ok = strings.Cut(part, routevars.RT_PATH_VAR_DELIM)
+	if !ok {
+		typ = name
+	}
+	return name, typ, true
+}
+
+var typeRegexes sync.Map
+
+// Returns the anchored regex of a path variable type.
+func typeRegex(typ string) *regexp.Regexp {
+	if re, ok := typeRegexes.Load(typ); ok {
+		return re.(*regexp.Regexp)
+	}
+	var pattern string
+	var lower = strings.ToLower(typ)
+	switch {
+	case strings.HasPrefix(lower, "raw(") && strings.HasSuffix(lower, ")"):
+		// Lowercased, the same way routevars matches it.
+		pattern = lower[4 : len(lower)-1]
+	case typ == routevars.NameInt:
+		pattern = routevars.RT_PATH_REGEX_NUM
+	case typ == routevars.NameSlug:
+		pattern = routevars.RT_PATH_REGEX_SLUG
+	case typ == routevars.NameUUID:
+		pattern = routevars.RT_PATH_REGEX_UUID
+	case typ == routevars.NameAny:
+		pattern = routevars.RT_PATH_REGEX_ANY
+	case typ == routevars.NameHex:
+		pattern = routevars.RT_PATH_REGEX_HEX
+	case typ == routevars.NameAlphaNum:
+		pattern = routevars.RT_PATH_REGEX_ALPHANUMERIC
+	default:
+		pattern = routevars.RT_PATH_REGEX_STR
+	}
+	var re, err = regexp.Compile("^(?:" + pattern + ")$")
+	if err != nil {
+		re = regexp.MustCompile("^$")
+	}
+	typeRegexes.Store(typ, re)
+	return re
+}
+
+// Escape a value for use in a path, keeping slashes for values matching multiple segments.
+func escapePathValue(value string) string {
+	var segments = strings.Split(value, "/")
+	for i, s := range segments {
+		segments[i] = url.PathEscape(s)
+	}
+	return strings.Join(segments, "/")
+}
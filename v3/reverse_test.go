@@ -0,0 +1,94 @@
+package router
+
+import (
+	"errors"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Nigel2392/router/v3/request"
+)
+
+func TestReverseArgs(t *testing.T) {
+	var r = NewRouter(true)
+	r.Get("/", HandleFunc(func(r *request.Request) {}), "index")
+	var user = r.Group("/users", "user")
+	user.Get("/<<id:int>>", HandleFunc(func(r *request.Request) {}), "detail")
+	user.Get("/<<id:int>>/posts/<<slug:string>>", HandleFunc(func(r *request.Request) {}), "post")
+	var admin = NewRouter(true)
+	admin.Get("/users/<<id:int>>", HandleFunc(func(r *request.Request) {}), "user")
+	if err := r.Mount("/admin", admin, "admin"); err != nil {
+		t.Fatal(err)
+	}
+
+	var tests = []struct {
+		name string
+		args []any
+		want string
+		err  error
+	}{
+		{"index", nil, "/", nil},
+		{"user:detail", []any{5}, "/users/5", nil},
+		{"user:detail", []any{map[string]any{"id": 5}}, "/users/5", nil},
+		{"user:post", []any{5, "hello"}, "/users/5/posts/hello", nil},
+		{"user:post", []any{map[string]any{"slug": "hello", "id": 5}}, "/users/5/posts/hello", nil},
+		{"admin:user", []any{5}, "/admin/users/5", nil},
+		{"user:post", []any{map[string]any{"id": 5}}, "", ErrMissingArgument},
+		{"user:detail", []any{map[string]any{"id": 5, "page": 2}}, "", ErrUnknownArgument},
+		{"user:detail", []any{"abc"}, "", ErrInvalidArgument},
+		{"admin:user", []any{"abc"}, "", ErrInvalidArgument},
+		{"user:detail", nil, "", nil},
+		{"missing", nil, "", ErrRouteNotFound},
+	}
+	for _, test := range tests {
+		var got, err = r.reverseArgs(test.name, test.args...)
+		if test.want == "" && err == nil || test.err != nil && !errors.Is(err, test.err) {
+			t.Errorf("reverseArgs(%q, %v): got error %v, want %v", test.name, test.args, err, test.err)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("reverseArgs(%q, %v) = %q, want %q", test.name, test.args, got, test.want)
+		}
+	}
+}
+
+func TestTemplateReverseUsesRouter(t *testing.T) {
+	var r = NewRouter(true)
+	var got string
+	var err error
+	r.Get("/users/<<id:int>>", HandleFunc(func(req *request.Request) {
+		got, err = req.Data.Request.Reverse("user", 7)
+		if _, typeErr := req.Data.Request.Reverse("user", "abc"); !errors.Is(typeErr, ErrInvalidArgument) {
+			t.Errorf("got error %v for an invalid argument, want ErrInvalidArgument", typeErr)
+		}
+	}), "user")
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/users/1", nil))
+	if err != nil || got != "/users/7" {
+		t.Errorf("got %q, %v, want /users/7", got, err)
+	}
+}
+
+func TestInheritedNames(t *testing.T) {
+	var r = NewRouter(true)
+	var api = r.Group("/api", "api")
+	var unnamed = api.Get("/a", HandleFunc(func(r *request.Request) {})).(*Route)
+	api.Get("/b", HandleFunc(func(r *request.Request) {}), "api")
+
+	// Unnamed children keep the name of their group.
+	if unnamed.Name() != "api" {
+		t.Errorf("got name %q, want the inherited name %q", unnamed.Name(), "api")
+	}
+	if url := r.URL(ALL, "api:api"); url != "/api/a" {
+		t.Errorf("URL(api:api) = %q, want /api/a", url)
+	}
+
+	// Inherited names are not indexed.
+	if unnamed.FullName() != "" {
+		t.Errorf("got full name %q, want none", unnamed.FullName())
+	}
+	if err := r.Validate(); err != nil {
+		t.Errorf("Validate: %v", err)
+	}
+	if path, err := r.Reverse("api:api", nil, nil); err != nil || path != "/api/b" {
+		t.Errorf("Reverse(api:api) = %q, %v, want /api/b", path, err)
+	}
+}
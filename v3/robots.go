@@ -0,0 +1,99 @@
+package router
+
+import (
+	"strings"
+
+	"github.com/Nigel2392/router/v3/request"
+)
+
+// Robots sets X-Robots-Tag directives, like "noindex" or "nofollow", for the route and all its children.
+//
+// Directives for a specific crawler are prefixed with its name, like "googlebot: noindex".
+func (r *Route) Robots(directives ...string) Registrar {
+	r.robotsTags = append(r.robotsTags, directives...)
+	r.invalidateChains()
+	return r
+}
+
+// Disallow excludes the route, and all its children, from crawling in a generated robots.txt.
+func (r *Route) Disallow() Registrar {
+	r.disallow = true
+	return r
+}
+
+// RobotsTags returns the X-Robots-Tag directives of the route's parents and the route itself.
+func (r *Route) RobotsTags() []string {
+	var tags = make([]string, 0)
+	var seen = make(map[string]bool)
+	for _, route := range r.lineage() {
+		for _, tag := range route.robotsTags {
+			if !seen[tag] {
+				seen[tag] = true
+				tags = append(tags, tag)
+			}
+		}
+	}
+	return tags
+}
+
+// Disallowed reports whether the route, or one of its parents, is excluded from crawling.
+func (r *Route) Disallowed() bool {
+	for route := r; route != nil; route = route.parent {
+		if route.disallow {
+			return true
+		}
+	}
+	return false
+}
+
+// Directives which take a value after a colon, and are not a crawler's name.
+var robotsValueDirectives = map[string]bool{
+	"unavailable_after": true,
+	"max-snippet":       true,
+	"max-image-preview": true,
+	"max-video-preview": true,
+}
+
+// RobotsTag returns middleware which sets the X-Robots-Tag header.
+//
+// Directives for a specific crawler, like "googlebot: noindex", are set as a separate header,
+// a crawler prefix applies to the whole value of a header.
+func RobotsTag(directives ...string) Middleware {
+	var values = robotsTagValues(directives)
+	return func(next Handler) Handler {
+		return HandleFunc(func(r *request.Request) {
+			for _, value := range values {
+				r.Response.Header().Add("X-Robots-Tag", value)
+			}
+			next.ServeHTTP(r)
+		})
+	}
+}
+
+// Groups the directives by crawler, and returns one header value per crawler.
+func robotsTagValues(directives []string) []string {
+	var agents = make([]string, 0)
+	var groups = make(map[string][]string)
+	for _, directive := range directives {
+		var agent string
+		if name, value, ok := strings.Cut(directive, ":"); ok {
+			name = strings.TrimSpace(name)
+			if !robotsValueDirectives[strings.ToLower(name)] {
+				agent, directive = name, strings.TrimSpace(value)
+			}
+		}
+		if _, ok := groups[agent]; !ok {
+			agents = append(agents, agent)
+		}
+		groups[agent] = append(groups[agent], directive)
+	}
+	var values = make([]string, 0, len(agents))
+	for _, agent := range agents {
+		var value = strings.Join(groups[agent], ", ")
+		if agent != "" {
+			value = agent + ": " + value
+		}
+		values = append(values, value)
+	}
+	return values
+}
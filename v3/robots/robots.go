@@ -3,7 +3,10 @@ package robots
 import (
 	"bytes"
 	"strconv"
+	"strings"
+	"sync"
 
+	"github.com/Nigel2392/router/v3"
 	"github.com/Nigel2392/router/v3/request"
 )
 
@@ -13,40 +16,146 @@ type Listing struct {
 	Disallow   []string
 	UserAgent  string
 	CrawlDelay int
+	// Query parameters which do not change the page, like "ref /articles/".
+	CleanParam []string
 }
 
 // Options for generating the robots.txt file.
 type Options struct {
 	Rules   []*Listing
 	SiteMap string
+	// Additional sitemaps, see sitemap.Sitemap.Robots.
+	SiteMaps []string
+	// Preferred host of the site.
+	Host string
+	// Routes marked with Route.Disallow are disallowed for every user agent.
+	Router *router.Router
 }
 
 // Robots returns a handler that generates a robots.txt file.
+//
+// The file is generated on the first request, and cached.
+// Changes made to the options or routes afterwards are not reflected.
 func Robots(options *Options) func(r *request.Request) {
+	var once sync.Once
+	var content []byte
 	return func(r *request.Request) {
-		var buffer bytes.Buffer
-		for i, listing := range options.Rules {
-			if listing.UserAgent == "" {
-				listing.UserAgent = "*"
-			}
-			buffer.WriteString("User-agent: " + listing.UserAgent + "\n")
-			for _, allow := range listing.Allow {
-				buffer.WriteString("Allow: " + allow + "\n")
-			}
-			for _, disallow := range listing.Disallow {
-				buffer.WriteString("Disallow: " + disallow + "\n")
-			}
-			if listing.CrawlDelay > 0 {
-				buffer.WriteString("Crawl-delay: " + strconv.Itoa(listing.CrawlDelay) + "\n")
-			}
-			if i < len(options.Rules)-1 {
-				buffer.WriteString("\n")
+		once.Do(func() {
+			content = Generate(options)
+		})
+		r.Response.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		r.Response.Write(content)
+	}
+}
+
+// Generate the robots.txt file.
+func Generate(options *Options) []byte {
+	var buffer bytes.Buffer
+	var rules = withDisallowed(options.Rules, disallowedPaths(options.Router))
+	for i, listing := range rules {
+		var userAgent = listing.UserAgent
+		if userAgent == "" {
+			userAgent = "*"
+		}
+		buffer.WriteString("User-agent: " + userAgent + "\n")
+		for _, allow := range listing.Allow {
+			buffer.WriteString("Allow: " + allow + "\n")
+		}
+		for _, disallow := range listing.Disallow {
+			buffer.WriteString("Disallow: " + disallow + "\n")
+		}
+		if listing.CrawlDelay > 0 {
+			buffer.WriteString("Crawl-delay: " + strconv.Itoa(listing.CrawlDelay) + "\n")
+		}
+		for _, param := range listing.CleanParam {
+			buffer.WriteString("Clean-param: " + param + "\n")
+		}
+		if i < len(rules)-1 {
+			buffer.WriteString("\n")
+		}
+	}
+	var siteMaps = options.SiteMaps
+	if options.SiteMap != "" {
+		siteMaps = append([]string{options.SiteMap}, siteMaps...)
+	}
+	if len(rules) > 0 && (len(siteMaps) > 0 || options.Host != "") {
+		buffer.WriteString("\n")
+	}
+	if options.Host != "" {
+		buffer.WriteString("Host: " + options.Host + "\n")
+	}
+	for _, siteMap := range siteMaps {
+		buffer.WriteString("Sitemap: " + siteMap + "\n")
+	}
+	return buffer.Bytes()
+}
+
+// Adds the disallowed paths to the rules for every user agent.
+//
+// They are merged into the first "*" listing, if any, since crawlers only read one group.
+// The listings of the options are not modified.
+func withDisallowed(rules []*Listing, disallowed []string) []*Listing {
+	if len(disallowed) == 0 {
+		return rules
+	}
+	for i, listing := range rules {
+		if listing.UserAgent != "" && listing.UserAgent != "*" {
+			continue
+		}
+		var merged = *listing
+		merged.Disallow = append([]string{}, listing.Disallow...)
+		for _, p := range disallowed {
+			if !contains(merged.Disallow, p) {
+				merged.Disallow = append(merged.Disallow, p)
 			}
 		}
-		if options.SiteMap != "" {
-			buffer.WriteString("\nSitemap: " + options.SiteMap + "\n")
+		rules = append([]*Listing{}, rules...)
+		rules[i] = &merged
+		return rules
+	}
+	return append([]*Listing{{UserAgent: "*", Disallow: disallowed}}, rules...)
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
 		}
-		r.Response.Header().Set("Content-Type", "text/plain")
-		r.Response.Write(buffer.Bytes())
 	}
+	return false
+}
+
+// Returns the paths of the disallowed routes.
+//
+// Paths with variables are disallowed up to the first variable.
+func disallowedPaths(r *router.Router) []string {
+	if r == nil {
+		return nil
+	}
+	var paths = make([]string, 0)
+	var seen = make(map[string]bool)
+	r.Walk(func(route *router.Route, depth int) {
+		if !route.Disallowed() || route.Parent() != nil && route.Parent().Disallowed() {
+			return
+		}
+		var p = string(route.Path)
+		if i := strings.Index(p, "<<"); i >= 0 {
+			p = p[:i]
+		}
+		if p == "" {
+			p = "/"
+		}
+		if !seen[p] {
+			seen[p] = true
+			paths = append(paths, p)
+		}
+	})
+	return paths
+}
+
+// Tag returns middleware which sets the X-Robots-Tag header, like "noindex" or "nofollow".
+//
+// Use Route.Robots to set the directives of a single route.
+func Tag(directives ...string) router.Middleware {
+	return router.RobotsTag(directives...)
 }
@@ -0,0 +1,82 @@
+package robots
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Nigel2392/router/v3"
+	"github.com/Nigel2392/router/v3/request"
+	"github.com/Nigel2392/router/v3/request/writer"
+)
+
+func TestGenerateMergesDisallowedRoutes(t *testing.T) {
+	var r = router.NewRouter(true)
+	r.Group("/admin", "admin").Disallow()
+	r.Get("/drafts/<<id:int>>", router.HandleFunc(func(r *request.Request) {}), "draft").Disallow()
+
+	var all = &Listing{UserAgent: "*", Allow: []string{"/"}, Disallow: []string{"/admin"}}
+	var options = &Options{
+		Rules: []*Listing{
+			{UserAgent: "Googlebot", Disallow: []string{"/private"}},
+			all,
+		},
+		Router: r,
+	}
+	var want = strings.Join([]string{
+		"User-agent: Googlebot",
+		"Disallow: /private",
+		"",
+		"User-agent: *",
+		"Allow: /",
+		"Disallow: /admin",
+		"Disallow: /drafts/",
+		"",
+	}, "\n")
+	if got := string(Generate(options)); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+	if len(all.Disallow) != 1 {
+		t.Errorf("the listing of the options was modified: %v", all.Disallow)
+	}
+
+	// Without a "*" listing, one is added for the disallowed routes.
+	options.Rules = options.Rules[:1]
+	if got := string(Generate(options)); !strings.HasPrefix(got, "User-agent: *\nDisallow: /admin\nDisallow: /drafts/\n\nUser-agent: Googlebot\n") {
+		t.Errorf("got:\n%s", got)
+	}
+	if strings.Count(string(Generate(options)), "User-agent: *") != 1 {
+		t.Error("there should be a single group for every user agent")
+	}
+}
+
+func TestRobotsIsCached(t *testing.T) {
+	var options = &Options{
+		Rules:   []*Listing{{Disallow: []string{"/admin"}}},
+		SiteMap: "https://example.com/sitemap.xml",
+	}
+	var handler = Robots(options)
+	var serve = func() string {
+		var w = httptest.NewRecorder()
+		var rq = request.NewRequest(writer.NewClearable(w), httptest.NewRequest("GET", "/robots.txt", nil), nil)
+		handler(rq)
+		rq.Response.Finalize()
+		if ct := w.Header().Get("Content-Type"); ct != "text/plain; charset=utf-8" {
+			t.Errorf("got content type %q", ct)
+		}
+		return w.Body.String()
+	}
+
+	var want = "User-agent: *\nDisallow: /admin\n\nSitemap: https://example.com/sitemap.xml\n"
+	if got := serve(); got != want {
+		t.Fatalf("got:\n%s\nwant:\n%s", got, want)
+	}
+	options.Rules[0].Disallow = append(options.Rules[0].Disallow, "/private")
+	options.Host = "example.com"
+	if got := serve(); got != want {
+		t.Errorf("the file should be generated once, got:\n%s", got)
+	}
+	if got := string(Generate(options)); got == want {
+		t.Error("Generate should not be cached")
+	}
+}
@@ -0,0 +1,27 @@
+package router
+
+import (
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/Nigel2392/router/v3/request"
+)
+
+func TestRobotsTagPerUserAgent(t *testing.T) {
+	var r = NewRouter(true)
+	var group = r.Group("/drafts", "drafts").Robots("noindex", "googlebot: nofollow")
+	group.Get("/<<id:int>>", HandleFunc(func(r *request.Request) {}), "draft").
+		Robots("max-snippet:20", "googlebot: noarchive", "bingbot: noindex", "unavailable_after: 25 Jun 2030 15:00:00 PST")
+
+	var w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/drafts/1", nil))
+	var want = []string{
+		"noindex, max-snippet:20, unavailable_after: 25 Jun 2030 15:00:00 PST",
+		"googlebot: nofollow, noarchive",
+		"bingbot: noindex",
+	}
+	if got := w.Header().Values("X-Robots-Tag"); !reflect.DeepEqual(got, want) {
+		t.Errorf("got X-Robots-Tag %q, want %q", got, want)
+	}
+}
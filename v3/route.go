@@ -18,10 +18,23 @@ type Route struct {
 	Method            string
 	Path              routevars.URLFormatter
 	HandlerFunc       Handler
-	middleware        []Middleware
+	middleware        []namedMiddleware
+	skipMiddleware    []string
+	policies          Policies
+	robotsTags        []string
+	disallow          bool
+	host              *hostPattern
+	constraints       []constraint
+	parent            *Route
 	children          []*Route
 	middlewareEnabled bool
 	name              string
+	inheritedName     bool
+	chain             routeChain
+	// Router of a top level route, nil for child routes.
+	owner *Router
+	// File and line where the route was registered.
+	source string
 }
 
 // Return the name of the route
@@ -30,6 +43,11 @@ func (r *Route) Name() string {
 	return r.name
 }
 
+// Parent returns the group the route belongs to, or nil for top level routes.
+func (r *Route) Parent() *Route {
+	return r.parent
+}
+
 // Returns the formatted URL for this route.
 //
 // If no arguments are provided it will return the path as it is set on the route.
@@ -38,7 +56,7 @@ func (r *Route) Format(args ...any) string {
 	if len(args) <= 0 {
 		return string(r.Path)
 	}
-	return r.Path.Format(args)
+	return r.Path.Format(args...)
 }
 
 // URL matches a URL for the given names delimited by a colon.
@@ -89,30 +107,44 @@ func (r *Route) url(method string, parts []string) routevars.URLFormatter {
 
 // HandleFunc registers a new route with the given path and method.
 func (r *Route) HandleFunc(method, path string, handler Handler, name ...string) Registrar {
-	var n = r.name
+	// Unnamed routes inherit the name of their parent, which is not used to reverse them.
+	var n, inherited = r.name, true
 	if len(name) > 0 {
-		n = name[0]
+		n, inherited = name[0], false
 	}
 	path = string(r.Path) + path
 	var child = &Route{
+		source:            registeredAt(),
 		Method:            method,
 		Path:              routevars.URLFormatter(path),
 		HandlerFunc:       handler,
-		middleware:        r.middleware,
-		middlewareEnabled: r.middlewareEnabled,
+		parent:            r,
+		middlewareEnabled: true,
 		name:              n,
+		inheritedName:     inherited,
 	}
 	r.children = append(r.children, child)
+	r.invalidateChains()
 	return child
 }
 
-// Disable the router's middlewares for this route, and all its children
-// It will however still run the route's own middlewares.
+// Disable all middleware for this route, and all its children.
+//
+// This skips the router's middleware, the middleware of parent groups, and the route's own middleware.
+// The policies of the route are still checked.
 func (r *Route) DisableMiddleware() {
 	r.middlewareEnabled = false
-	for _, child := range r.children {
-		child.DisableMiddleware()
-	}
+	r.invalidateChains()
+}
+
+// Skip the named middleware for this route, and all its children.
+//
+// This can be used to skip global middleware, or middleware of a parent group.
+// Only middleware registered with UseNamed can be skipped.
+func (r *Route) SkipMiddleware(names ...string) Registrar {
+	r.skipMiddleware = append(r.skipMiddleware, names...)
+	r.invalidateChains()
+	return r
 }
 
 // Handle is a convenience method that wraps the http.Handler in a HandleFunc
@@ -162,65 +194,145 @@ func (r *Route) Any(path string, handler Handler, name ...string) Registrar {
 
 // Group creates a new group of routes
 func (r *Route) Group(path string, name string, middlewares ...Middleware) Registrar {
-	if len(middlewares) == 0 {
-		middlewares = make([]Middleware, 0)
-	}
-	middlewares = append(middlewares, r.middleware...)
 	var route = &Route{
+		source:            registeredAt(),
 		Path:              r.Path + routevars.URLFormatter(path),
-		middleware:        middlewares,
-		middlewareEnabled: r.middlewareEnabled,
+		middleware:        nameMiddlewares(middlewares),
+		parent:            r,
+		middlewareEnabled: true,
 		name:              name,
 	}
 	r.children = append([]*Route{route}, r.children...)
+	r.invalidateChains()
 	return route
 }
 
 // Add a group to the route
 func (r *Route) AddGroup(group Registrar) {
 	var g = group.(*Route)
-	if g.middleware == nil {
-		g.middleware = make([]Middleware, 0)
-	}
-	g.middleware = append(g.middleware, r.middleware...)
-	g.Path = r.Path + g.Path
-	g.middlewareEnabled = r.middlewareEnabled
-	for _, child := range g.children {
-		WalkRoutes(child, func(route *Route, i int) {
-			route.Path = r.Path + route.Path
-			route.middleware = append(route.middleware, g.middleware...)
-		})
-	}
+	g.parent = r
+	WalkRoutes(g, func(route *Route, i int) {
+		route.Path = r.Path + route.Path
+	})
 	r.children = append([]*Route{g}, r.children...)
+	r.invalidateChains()
 }
 
 // Match checks if the given path matches the route
 func (r *Route) Match(method, path string) (bool, *Route, params.URLParams) {
-	if r.Method != ALL && r.HandlerFunc == nil {
-		if r.Method != method && r.HandlerFunc != nil {
-			return false, nil, nil
+	var route, vars, _ = r.match(method, anyHost, path, nil, nil)
+	return route != nil, route, vars
+}
+
+// Returns the route which matches the given method, host and path.
+//
+// If the request is not nil, the constraints of the routes are checked after the path matched.
+// The methods of routes which match the path and constraints, but not the method, are appended to allowed.
+func (r *Route) match(method, host, path string, rq *http.Request, allowed []string) (*Route, params.URLParams, []string) {
+	var hostVars params.URLParams
+	if r.host != nil && host != anyHost {
+		var ok bool
+		if hostVars, ok = r.host.match(host); !ok {
+			return nil, nil, allowed
 		}
 	}
 	if r.HandlerFunc != nil {
-		var ok, vars = r.Path.Match(path)
-		if ok {
-			return true, r, vars
+		var ok, vars = r.matchPath(path)
+		if ok && r.meets(rq) {
+			if matchesMethod(r.Method, method) {
+				return r, mergeVars(vars, hostVars), allowed
+			}
+			allowed = appendMethod(allowed, r.Method)
+			if r.Method == GET {
+				allowed = appendMethod(allowed, HEAD)
+			}
 		}
 	}
+	if len(r.children) > 0 && !r.meets(rq) {
+		return nil, nil, allowed
+	}
 	for _, child := range r.children {
-		if ok, route, vars := child.Match(method, path); ok {
-			return ok, route, vars
+		var match *Route
+		var vars params.URLParams
+		if match, vars, allowed = child.match(method, host, path, rq, allowed); match != nil {
+			return match, mergeVars(vars, hostVars), allowed
+		}
+	}
+	return nil, nil, allowed
+}
+
+// Adds the host variables to the path variables.
+func mergeVars(vars, hostVars params.URLParams) params.URLParams {
+	if len(hostVars) == 0 {
+		return vars
+	}
+	if vars == nil {
+		vars = make(params.URLParams, len(hostVars))
+	}
+	for k, v := range hostVars {
+		if _, ok := vars[k]; !ok {
+			vars[k] = v
 		}
 	}
-	return false, nil, nil
+	return vars
+}
+
+// Reports whether a route registered for routeMethod serves requests with the method.
+//
+// Routes for ALL methods, and groups, match every method. GET routes also match HEAD requests.
+func matchesMethod(routeMethod, method string) bool {
+	return routeMethod == ALL || routeMethod == "" || routeMethod == method || routeMethod == GET && method == HEAD
 }
 
-// Use adds middleware to the route
+func appendMethod(methods []string, method string) []string {
+	for _, m := range methods {
+		if m == method {
+			return methods
+		}
+	}
+	return append(methods, method)
+}
+
+// Use adds middleware to the route, and all its children.
 func (r *Route) Use(middlewares ...Middleware) {
-	r.middleware = append(r.middleware, middlewares...)
-	for _, child := range r.children {
-		child.Use(middlewares...)
+	r.middleware = append(r.middleware, nameMiddlewares(middlewares)...)
+	r.invalidateChains()
+}
+
+// UseNamed adds named middleware to the route, and all its children.
+//
+// Child routes can skip it with SkipMiddleware.
+func (r *Route) UseNamed(name string, middleware Middleware) {
+	r.middleware = append(r.middleware, namedMiddleware{name: name, middleware: middleware})
+	r.invalidateChains()
+}
+
+// Require adds policies which a user must meet to access the route, and all its children.
+func (r *Route) Require(policies ...*Policy) Registrar {
+	r.policies = append(r.policies, policies...)
+	r.invalidateChains()
+	return r
+}
+
+// Returns the route's parents and the route itself, starting at the outermost parent.
+func (r *Route) lineage() []*Route {
+	var lineage = make([]*Route, 0, 4)
+	for route := r; route != nil; route = route.parent {
+		lineage = append(lineage, route)
+	}
+	for i, j := 0, len(lineage)-1; i < j; i, j = i+1, j-1 {
+		lineage[i], lineage[j] = lineage[j], lineage[i]
+	}
+	return lineage
+}
+
+// Returns the policies of the route's parents and the route itself.
+func (r *Route) allPolicies() Policies {
+	var policies = make(Policies, 0)
+	for _, route := range r.lineage() {
+		policies = append(policies, route.policies...)
 	}
+	return policies
 }
 
 // Call a route handler with the given request.
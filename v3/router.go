@@ -2,12 +2,15 @@ package router
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"net/http"
 	"strings"
+	"sync/atomic"
 
 	"github.com/Nigel2392/router/v3/request"
 	"github.com/Nigel2392/router/v3/request/params"
+	"github.com/Nigel2392/router/v3/request/response"
 	"github.com/Nigel2392/router/v3/request/writer"
 	"github.com/Nigel2392/routevars"
 )
@@ -61,6 +64,36 @@ type Registrar interface {
 	// Use adds middleware to the router.
 	Use(middlewares ...Middleware)
 
+	// UseNamed adds named middleware, which child routes can skip with SkipMiddleware.
+	UseNamed(name string, middleware Middleware)
+
+	// SkipMiddleware skips the named global or parent middleware for the routes.
+	SkipMiddleware(names ...string) Registrar
+
+	// Require adds policies which a user must meet to access the routes.
+	Require(policies ...*Policy) Registrar
+
+	// Robots sets X-Robots-Tag directives for the routes.
+	Robots(directives ...string) Registrar
+
+	// Disallow excludes the routes from crawling in a generated robots.txt.
+	Disallow() Registrar
+
+	// Header only matches the routes if the request has the header with the value.
+	Header(name, value string) Registrar
+
+	// Query only matches the routes if the query parameter is present, with one of the values if given.
+	Query(name string, values ...string) Registrar
+
+	// NoQuery only matches the routes if the query parameter is absent.
+	NoQuery(name string) Registrar
+
+	// Scheme only matches the routes for requests with one of the schemes.
+	Scheme(schemes ...string) Registrar
+
+	// MatchFunc only matches the routes if the predicate returns true.
+	MatchFunc(predicate func(rq *http.Request) bool) Registrar
+
 	// Group creates a new router URL group
 	Group(path string, name string, middlewares ...Middleware) Registrar
 
@@ -83,15 +116,49 @@ type Registrar interface {
 	Format(args ...any) string
 }
 
+// Getter registers GET routes, it is implemented by Registrar and *Router.
+//
+// Packages which register their own routes, like health and metrics, accept it.
+type Getter interface {
+	Get(path string, handler Handler, name ...string) Registrar
+}
+
 // Variable map passed to the route.
 type Vars map[string]string
 
 // Router is the main router struct
 // It takes care of dispatching requests to the correct route
 type Router struct {
-	NotFoundHandler   Handler
+	// Called when no route matches the request.
+	// If nil, a 404 Not Found problem is passed to the ErrorHandler.
+	NotFoundHandler Handler
+	// Called when a route matches the path, but not the method.
+	// The Allow header is set before it is called, and again after it returns.
+	// If nil, a 405 Method Not Allowed problem is passed to the ErrorHandler.
+	MethodNotAllowedHandler Handler
+	// Called when the user does not meet the policies of a route.
+	// If nil, a 403 Forbidden response is written.
+	ForbiddenHandler Handler
+	// Called with errors returned by a HandlerE.
+	// If nil, response.Error is used.
+	ErrorHandler func(r *request.Request, err error)
+	// Scheme and host used by AbsoluteURL, like "https://example.com".
+	BaseURL string
+	// Validate the routes when requests are served.
+	// While the routes are invalid, every request fails,
+	// the ValidationError is passed to the ErrorHandler and logged once.
+	// The routes are only validated again after they changed.
+	// Use server.Options.Validate to validate before the server starts.
+	ValidateOnServe   bool
+	validation        validation
 	routes            []*Route
-	middleware        []Middleware
+	mounts            []*mount
+	mountParent       *Router
+	mountPrefix       string
+	index             routeIndex
+	version           atomic.Uint64
+	middleware        []namedMiddleware
+	policies          Policies
 	skipTrailingSlash bool
 }
 
@@ -112,7 +179,7 @@ func (r *Router) String() string {
 
 // NewRouter creates a new router
 func NewRouter(skipTrailingSlash bool) *Router {
-	var r = &Router{routes: make([]*Route, 0), middleware: make([]Middleware, 0), skipTrailingSlash: skipTrailingSlash}
+	var r = &Router{routes: make([]*Route, 0), middleware: make([]namedMiddleware, 0), skipTrailingSlash: skipTrailingSlash}
 	return r
 }
 
@@ -156,13 +223,14 @@ func (r *Router) URLFormat(name string, args ...interface{}) string {
 
 // HandleFunc registers a new route with the given path and method.
 func (r *Router) HandleFunc(method, path string, handler Handler, name ...string) Registrar {
-	var route = &Route{Method: method, Path: routevars.URLFormatter(path), HandlerFunc: handler, middlewareEnabled: true}
+	var route = &Route{Method: method, Path: routevars.URLFormatter(path), HandlerFunc: handler, middlewareEnabled: true, source: registeredAt(), owner: r}
 
 	if len(name) > 0 {
 		route.name = name[0]
 	}
 
 	r.routes = append(r.routes, route)
+	r.invalidateChains()
 	return route
 }
 
@@ -212,72 +280,159 @@ func (r *Router) Any(path string, handler Handler, name ...string) Registrar {
 }
 
 // Use adds middleware to the router.
+//
+// Global middleware is run before the middleware of groups and routes.
 func (r *Router) Use(middlewares ...Middleware) {
-	r.middleware = append(r.middleware, middlewares...)
+	r.middleware = append(r.middleware, nameMiddlewares(middlewares)...)
+	r.invalidateChains()
+}
+
+// UseNamed adds named middleware to the router.
+//
+// Routes can skip it with SkipMiddleware.
+func (r *Router) UseNamed(name string, middleware Middleware) {
+	r.middleware = append(r.middleware, namedMiddleware{name: name, middleware: middleware})
+	r.invalidateChains()
+}
+
+// Require adds policies which a user must meet to access any route of the router.
+//
+// It returns the router, like Registrar.Require returns the route.
+func (r *Router) Require(policies ...*Policy) *Router {
+	r.policies = append(r.policies, policies...)
+	r.invalidateChains()
+	return r
 }
 
 // Group creates a new router URL group
 func (r *Router) Group(path string, name string, middlewares ...Middleware) Registrar {
-	var route = &Route{Path: routevars.URLFormatter(path), middlewareEnabled: true, name: name}
+	var route = &Route{Path: routevars.URLFormatter(path), middleware: nameMiddlewares(middlewares), middlewareEnabled: true, name: name, source: registeredAt(), owner: r}
 	r.routes = append(r.routes, route)
+	r.invalidateChains()
 	return route
 }
 
 // Addgroup adds a group of routes to the router
 func (r *Router) AddGroup(group Registrar) {
-	r.routes = append(r.routes, group.(*Route))
+	var g = group.(*Route)
+	g.owner = r
+	r.routes = append(r.routes, g)
+	r.invalidateChains()
+}
+
+// Walk calls f for every route, and its depth in the route tree.
+func (r *Router) Walk(f func(route *Route, depth int)) {
+	for _, route := range r.routes {
+		WalkRoutes(route, f)
+	}
 }
 
 // Match returns the route that matches the given method and path.
 func (r *Router) Match(method, path string) (bool, *Route, params.URLParams) {
+	var route, vars, _ = r.match(method, anyHost, path, nil)
+	return route != nil, route, vars
+}
+
+// MatchRequest returns the route that matches the request.
+//
+// Unlike Match, it checks the host patterns and constraints of the routes.
+func (r *Router) MatchRequest(rq *http.Request) (bool, *Route, params.URLParams) {
+	var route, vars, _ = r.match(rq.Method, requestHost(rq), rq.URL.Path, rq)
+	return route != nil, route, vars
+}
+
+// Returns the matching route, or the methods allowed for the path if no route matches.
+//
+// Routes bound to a host are tried before the routes of every host,
+// so a route like r.Get("/") does not shadow the same path of a host registered later.
+func (r *Router) match(method, host, path string, rq *http.Request) (*Route, params.URLParams, []string) {
+	var allowed []string
+	for _, hostRoutes := range [2]bool{true, false} {
+		for _, route := range r.routes {
+			if (route.host != nil) != hostRoutes {
+				continue
+			}
+			var match *Route
+			var vars params.URLParams
+			if match, vars, allowed = route.match(method, host, path, rq, allowed); match != nil {
+				return match, vars, nil
+			}
+		}
+	}
+	return nil, nil, allowed
+}
+
+// Returns the routes in the order they are matched; routes bound to a host first.
+func (r *Router) matchOrder() []*Route {
+	var routes = make([]*Route, 0, len(r.routes))
 	for _, route := range r.routes {
-		if ok, newRoute, vars := route.Match(method, path); ok {
-			return ok, newRoute, vars
+		if route.host != nil {
+			routes = append(routes, route)
 		}
 	}
-	return false, nil, nil
+	for _, route := range r.routes {
+		if route.host == nil {
+			routes = append(routes, route)
+		}
+	}
+	return routes
 }
 
 // ServeHTTP dispatches the request to the handler whose
 // pattern matches the request URL.
 func (r *Router) ServeHTTP(w http.ResponseWriter, rq *http.Request) {
 
+	if r.ValidateOnServe {
+		if err := r.validated(); err != nil {
+			var req = r.newRequest(w, rq, nil)
+			defer req.Response.Finalize()
+			req.HandleError(err)
+			return
+		}
+	}
+
 	if r.skipTrailingSlash && len(rq.URL.Path) > 1 && rq.URL.Path[len(rq.URL.Path)-1] == '/' {
 		rq.URL.Path = rq.URL.Path[:len(rq.URL.Path)-1]
 	}
 
-	var ok, newRoute, vars = r.Match(rq.Method, rq.URL.Path)
-	if !ok {
-		if r.NotFoundHandler != nil {
-			var resp = writer.NewClearable(w)
-			defer resp.Finalize()
-			r.NotFoundHandler.ServeHTTP(request.NewRequest(resp, rq, nil))
+	var newRoute, vars, allowed = r.match(rq.Method, requestHost(rq), rq.URL.Path, rq)
+
+	// Mounts serve the paths which are not matched by any route.
+	if newRoute == nil && len(allowed) == 0 {
+		if m := r.matchMount(rq.URL.Path); m != nil {
+			var req = r.newRequest(w, rq, nil)
+			req.Route = request.RouteInfo{Name: m.name, Pattern: m.prefix}
+			defer req.Response.Finalize()
+			r.handler(m.route).ServeHTTP(req)
 			return
 		}
-		http.NotFound(w, rq)
-		return
 	}
 
-	// Create a new handler
-	var handler Handler = newRoute.HandlerFunc
-
-	// Run the route middleware
-	if newRoute.middlewareEnabled && len(newRoute.middleware) > 0 {
-		for i := len(newRoute.middleware) - 1; i >= 0; i-- {
-			handler = newRoute.middleware[i](handler)
+	if newRoute == nil {
+		var req = r.newRequest(w, rq, nil)
+		defer req.Response.Finalize()
+		switch {
+		case len(allowed) > 0 && r.MethodNotAllowedHandler != nil:
+			req.Response.Header().Set("Allow", strings.Join(allowed, ", "))
+			r.MethodNotAllowedHandler.ServeHTTP(req)
+			// The handler may have cleared the headers, for example with Request.Error.
+			req.Response.Header().Set("Allow", strings.Join(allowed, ", "))
+		case len(allowed) > 0:
+			req.HandleError(response.MethodNotAllowed("", allowed...))
+		case r.NotFoundHandler != nil:
+			r.NotFoundHandler.ServeHTTP(req)
+		default:
+			req.HandleError(response.NotFound(""))
 		}
+		return
 	}
 
-	// Only run the global middleware if the
-	// route has middleware enabled
-	if newRoute.middlewareEnabled && len(r.middleware) > 0 {
-		for i := len(r.middleware) - 1; i >= 0; i-- {
-			handler = r.middleware[i](handler)
-		}
-	}
+	// Get the handler, wrapped in the route's middleware chain.
+	var handler = r.handler(newRoute)
 
 	// Initialize a new request.
-	var req = request.NewRequest(writer.NewClearable(w), rq, vars)
+	var req = r.newRequest(w, rq, vars)
+	req.Route = request.RouteInfo{Name: newRoute.FullName(), Pattern: string(newRoute.Path)}
 
 	// Defer the response finalization
 	//
@@ -285,50 +440,48 @@ func (r *Router) ServeHTTP(w http.ResponseWriter, rq *http.Request) {
 	// just buffering it.
 	defer req.Response.Finalize()
 
+	// Serve the request
+	handler.ServeHTTP(req)
+}
+
+// Initialize a new request, with the router's functions set.
+func (r *Router) newRequest(w http.ResponseWriter, rq *http.Request, vars params.URLParams) *request.Request {
+	var req = request.NewRequest(writer.NewClearable(w), rq, vars)
+
 	// Set up a function to fetch routes, from any path inside a request.
 	req.URL = r.URL
+	req.Reverse = r.reverseArgs
+	if prefix := r.MountPoint(); prefix != "" {
+		req.URL = func(method, name string) routevars.URLFormatter {
+			if url := r.URL(method, name); url != "" {
+				return routevars.URLFormatter(prefix) + url
+			}
+			return ""
+		}
+	}
 
-	// Serve the request
-	handler.ServeHTTP(req)
+	// Set up the error handler for handlers returning errors.
+	req.ErrorHandler = r.ErrorHandler
+	if req.ErrorHandler == nil {
+		req.ErrorHandler = response.Error
+	}
+
+	// Set up the forbidden handler for requests which do not meet the policies.
+	req.ForbiddenHandler = r.forbidden
+
+	if hook, ok := rq.Context().Value(requestHookKey{}).(func(*request.Request)); ok {
+		hook(req)
+	}
+	return req
 }
 
-//	var replacer = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", "\"", "&quot;", "'", "&apos;")
-//
-//	// SafePath escapes the path for XML
-//	func safePath(path string) string {
-//		return replacer.Replace(path)
-//	}
-
-//	// SiteMap returns a ready to use XML sitemap
-//	func (r *Router) SiteMap() []byte {
-//		var maxDepth int
-//		for _, route := range r.routes {
-//			walkRoutes(route, 1, func(route *Route, depth int) {
-//				if depth > maxDepth {
-//					maxDepth = depth
-//				}
-//			})
-//		}
-//
-//		var priority = func(depth int) float64 {
-//			return 1.0 - (float64(depth) / float64(maxDepth))
-//		}
-//
-//		var buffer bytes.Buffer
-//		buffer.WriteString("<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n")
-//		buffer.WriteString("	<urlset xmlns=\"http://www.sitemaps.org/schemas/sitemap/0.9\">\n")
-//		for _, route := range r.routes {
-//			WalkRoutes(route, func(route *Route, depth int) {
-//				var d = priority(depth)
-//				if route.HandlerFunc != nil {
-//					buffer.WriteString("		<url>\n")
-//					buffer.WriteString("			<loc>" + safePath(string(route.Path)) + "</loc>\n")
-//					buffer.WriteString("			<priority>" + fmt.Sprintf("%.2f", d) + "</priority>\n")
-//					buffer.WriteString("		</url>\n")
-//				}
-//			})
-//		}
-//		buffer.WriteString(`	</urlset>`)
+type requestHookKey struct{}
+
+// WithRequestHook returns a copy of the context with a hook,
+// which is called with every request the router creates for an http.Request with the context,
+// before any middleware runs.
 //
-//		return buffer.Bytes()
-//	}
+// It is used by routertest to set the session and user of a request, without changing the router.
+func WithRequestHook(ctx context.Context, hook func(r *request.Request)) context.Context {
+	return context.WithValue(ctx, requestHookKey{}, hook)
+}
@@ -0,0 +1,162 @@
+package routertest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/Nigel2392/router/v3/request"
+)
+
+// Response is the recorded response of a request.
+type Response struct {
+	Recorder *httptest.ResponseRecorder
+
+	// The request as created by the router.
+	//
+	// This is nil if the request could not be built.
+	// A mounted router creates a new request, the innermost request is recorded.
+	Request *request.Request
+
+	// Error building the request.
+	Err error
+}
+
+// Status returns the status code of the response.
+func (r *Response) Status() int {
+	return r.Recorder.Code
+}
+
+// Header returns a header of the response.
+func (r *Response) Header(key string) string {
+	return r.Recorder.Header().Get(key)
+}
+
+// Body returns the response body.
+func (r *Response) Body() string {
+	return r.Recorder.Body.String()
+}
+
+// JSON decodes the response body into the value.
+func (r *Response) JSON(v any) error {
+	return json.Unmarshal(r.Recorder.Body.Bytes(), v)
+}
+
+// JSONPath returns the value at a dot separated path in the JSON body.
+//
+// Array elements are selected by index, like "users.0.name".
+func (r *Response) JSONPath(path string) (any, error) {
+	var v any
+	if err := r.JSON(&v); err != nil {
+		return nil, err
+	}
+	if path == "" {
+		return v, nil
+	}
+	for _, key := range strings.Split(path, ".") {
+		switch node := v.(type) {
+		case map[string]any:
+			var ok bool
+			if v, ok = node[key]; !ok {
+				return nil, fmt.Errorf("routertest: no key %q in JSON path %q", key, path)
+			}
+		case []any:
+			var i, err = strconv.Atoi(key)
+			if err != nil || i < 0 || i >= len(node) {
+				return nil, fmt.Errorf("routertest: no index %q in JSON path %q", key, path)
+			}
+			v = node[i]
+		default:
+			return nil, fmt.Errorf("routertest: cannot select %q in JSON path %q", key, path)
+		}
+	}
+	return v, nil
+}
+
+// Data returns the template data of the request.
+func (r *Response) Data() *request.TemplateData {
+	if r.Request == nil {
+		return nil
+	}
+	return r.Request.Data
+}
+
+// AssertStatus fails the test if the status code is not the expected one.
+func (r *Response) AssertStatus(t testing.TB, status int) {
+	t.Helper()
+	r.assertBuilt(t)
+	if r.Status() != status {
+		t.Errorf("expected status %d, got %d", status, r.Status())
+	}
+}
+
+// AssertHeader fails the test if the header does not have the expected value.
+func (r *Response) AssertHeader(t testing.TB, key, value string) {
+	t.Helper()
+	r.assertBuilt(t)
+	if got := r.Header(key); got != value {
+		t.Errorf("expected header %s to be %q, got %q", key, value, got)
+	}
+}
+
+// AssertBodyContains fails the test if the body does not contain the string.
+func (r *Response) AssertBodyContains(t testing.TB, s string) {
+	t.Helper()
+	r.assertBuilt(t)
+	if !strings.Contains(r.Body(), s) {
+		t.Errorf("expected body to contain %q, got %q", s, r.Body())
+	}
+}
+
+// AssertJSONPath fails the test if the value at the JSON path is not equal to the expected value.
+//
+// The expected value is compared after a JSON round trip, so 5 equals the decoded float64 5.
+func (r *Response) AssertJSONPath(t testing.TB, path string, expected any) {
+	t.Helper()
+	r.assertBuilt(t)
+	var got, err = r.JSONPath(path)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if !jsonEqual(got, expected) {
+		t.Errorf("expected JSON path %q to be %v, got %v", path, expected, got)
+	}
+}
+
+// AssertData fails the test if the template data does not have the expected value for the key.
+func (r *Response) AssertData(t testing.TB, key string, expected any) {
+	t.Helper()
+	r.assertBuilt(t)
+	var data = r.Data()
+	if data == nil || !data.Has(key) {
+		t.Errorf("expected template data to have key %q", key)
+		return
+	}
+	if got := data.Get(key); !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected template data %q to be %v, got %v", key, expected, got)
+	}
+}
+
+func (r *Response) assertBuilt(t testing.TB) {
+	t.Helper()
+	if r.Err != nil {
+		t.Fatalf("request could not be built: %v", r.Err)
+	}
+}
+
+func jsonEqual(got, expected any) bool {
+	var b, err = json.Marshal(expected)
+	if err != nil {
+		return false
+	}
+	var normalized any
+	if err := json.Unmarshal(b, &normalized); err != nil {
+		return false
+	}
+	return reflect.DeepEqual(got, normalized)
+}
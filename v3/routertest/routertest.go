@@ -0,0 +1,254 @@
+// Package routertest makes in-process requests to a router, without opening sockets.
+//
+//	func TestIndex(t *testing.T) {
+//		var client = routertest.New(app.Router())
+//		var res = client.Get("/").User(adminUser).Do()
+//		res.AssertStatus(t, http.StatusOK)
+//		res.AssertData(t, "Title", "Home")
+//
+//		res = client.Post("/api/users").JSON(map[string]any{"name": "john"}).Do()
+//		res.AssertStatus(t, http.StatusCreated)
+//		res.AssertJSONPath(t, "user.name", "john")
+//	}
+package routertest
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+
+	"github.com/Nigel2392/router/v3"
+	"github.com/Nigel2392/router/v3/auth"
+	"github.com/Nigel2392/router/v3/request"
+)
+
+// Client makes requests to a router.
+type Client struct {
+	router *router.Router
+
+	// Headers sent with every request.
+	Header http.Header
+
+	// Cookies sent with every request.
+	Cookies []*http.Cookie
+}
+
+// New creates a client for the router.
+//
+// The router is not changed, the session and user of a RequestBuilder
+// are set on the request before any of the router's middleware runs.
+func New(r *router.Router) *Client {
+	return &Client{router: r, Header: make(http.Header)}
+}
+
+// Sets the session and user of the builder on the request, and records the request.
+func (b *RequestBuilder) inject(r *request.Request) {
+	if b.session != nil {
+		r.Session = b.session
+	}
+	if b.user != nil {
+		auth.SetUser(r, b.user)
+	}
+	b.res.Request = r
+}
+
+// Request starts building a request with the given method and path.
+func (c *Client) Request(method, path string) *RequestBuilder {
+	var b = &RequestBuilder{
+		client: c,
+		method: method,
+		path:   path,
+		header: c.Header.Clone(),
+		query:  make(url.Values),
+	}
+	b.cookies = append(b.cookies, c.Cookies...)
+	return b
+}
+
+func (c *Client) Get(path string) *RequestBuilder {
+	return c.Request(http.MethodGet, path)
+}
+
+func (c *Client) Post(path string) *RequestBuilder {
+	return c.Request(http.MethodPost, path)
+}
+
+func (c *Client) Put(path string) *RequestBuilder {
+	return c.Request(http.MethodPut, path)
+}
+
+func (c *Client) Patch(path string) *RequestBuilder {
+	return c.Request(http.MethodPatch, path)
+}
+
+func (c *Client) Delete(path string) *RequestBuilder {
+	return c.Request(http.MethodDelete, path)
+}
+
+// File to upload in a multipart body.
+type File struct {
+	Field    string
+	Filename string
+	Content  []byte
+}
+
+// RequestBuilder builds a single request.
+type RequestBuilder struct {
+	client  *Client
+	method  string
+	path    string
+	header  http.Header
+	query   url.Values
+	cookies []*http.Cookie
+	body    io.Reader
+	session request.Session
+	user    request.User
+	err     error
+
+	// Response of the request being sent.
+	res *Response
+}
+
+// Header sets a request header.
+func (b *RequestBuilder) Header(key, value string) *RequestBuilder {
+	b.header.Set(key, value)
+	return b
+}
+
+// Query adds a query parameter.
+func (b *RequestBuilder) Query(key, value string) *RequestBuilder {
+	b.query.Add(key, value)
+	return b
+}
+
+// Cookie adds a cookie to the request.
+func (b *RequestBuilder) Cookie(cookie *http.Cookie) *RequestBuilder {
+	b.cookies = append(b.cookies, cookie)
+	return b
+}
+
+// Body sets the raw request body.
+func (b *RequestBuilder) Body(contentType string, body []byte) *RequestBuilder {
+	b.header.Set("Content-Type", contentType)
+	b.body = bytes.NewReader(body)
+	return b
+}
+
+// JSON encodes the value as the request body.
+func (b *RequestBuilder) JSON(v any) *RequestBuilder {
+	var data, err = json.Marshal(v)
+	if err != nil {
+		b.err = err
+		return b
+	}
+	return b.Body("application/json", data)
+}
+
+// Form sets an URL encoded form as the request body.
+func (b *RequestBuilder) Form(values url.Values) *RequestBuilder {
+	return b.Body("application/x-www-form-urlencoded", []byte(values.Encode()))
+}
+
+// Multipart sets a multipart form as the request body, with the fields and files.
+func (b *RequestBuilder) Multipart(values url.Values, files ...File) *RequestBuilder {
+	var buf bytes.Buffer
+	var w = multipart.NewWriter(&buf)
+	for key, vals := range values {
+		for _, v := range vals {
+			if err := w.WriteField(key, v); err != nil {
+				b.err = err
+				return b
+			}
+		}
+	}
+	for _, f := range files {
+		var part, err = w.CreateFormFile(f.Field, f.Filename)
+		if err == nil {
+			_, err = part.Write(f.Content)
+		}
+		if err != nil {
+			b.err = err
+			return b
+		}
+	}
+	if err := w.Close(); err != nil {
+		b.err = err
+		return b
+	}
+	return b.Body(w.FormDataContentType(), buf.Bytes())
+}
+
+// Session sets the session of the request.
+//
+// It is set before the router's middleware runs,
+// middleware which sets the session replaces it.
+func (b *RequestBuilder) Session(session request.Session) *RequestBuilder {
+	b.session = session
+	return b
+}
+
+// User sets the user of the request, and the user of the template data.
+//
+// It is set before the router's middleware runs,
+// middleware which sets the user replaces it.
+func (b *RequestBuilder) User(user request.User) *RequestBuilder {
+	b.user = user
+	return b
+}
+
+// Build returns the http.Request, without sending it.
+func (b *RequestBuilder) Build() (*http.Request, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+	var target = b.path
+	if len(b.query) > 0 {
+		var sep = "?"
+		if strings.Contains(target, "?") {
+			sep = "&"
+		}
+		target += sep + b.query.Encode()
+	}
+	var req, err = http.NewRequest(b.method, target, b.body)
+	if err != nil {
+		b.err = err
+		return nil, err
+	}
+	// Look like a request received by a server.
+	req.RequestURI = req.URL.RequestURI()
+	req.RemoteAddr = "192.0.2.1:1234"
+	if req.Body == nil {
+		req.Body = http.NoBody
+	}
+	if req.Host == "" {
+		req.Host = "example.com"
+	}
+	for key, values := range b.header {
+		req.Header[key] = values
+	}
+	for _, cookie := range b.cookies {
+		req.AddCookie(cookie)
+	}
+	return req, nil
+}
+
+// Do sends the request to the router, and returns the recorded response.
+//
+// Errors building the request are recorded on the response.
+func (b *RequestBuilder) Do() *Response {
+	var res = &Response{Recorder: httptest.NewRecorder()}
+	var req, err = b.Build()
+	if err != nil {
+		res.Err = err
+		return res
+	}
+	b.res = res
+	req = req.WithContext(router.WithRequestHook(req.Context(), b.inject))
+	b.client.router.ServeHTTP(res.Recorder, req)
+	return res
+}
@@ -0,0 +1,218 @@
+package routertest_test
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/Nigel2392/router/v3"
+	"github.com/Nigel2392/router/v3/request"
+	"github.com/Nigel2392/router/v3/routertest"
+)
+
+type testUser struct {
+	name  string
+	admin bool
+}
+
+func (u *testUser) IsAuthenticated() bool                     { return true }
+func (u *testUser) IsAdmin() bool                             { return u.admin }
+func (u *testUser) HasPermissions(permissions ...string) bool { return u.admin }
+
+func newRouter() *router.Router {
+	var r = router.NewRouter(true)
+	r.Get("/echo", router.HandleFunc(func(r *request.Request) {
+		r.Response.Header().Set("X-Method", r.Method())
+		r.Data.Set("Title", "Echo")
+		var body, _ = io.ReadAll(r.Request.Body)
+		var cookie, _ = r.Request.Cookie("theme")
+		var theme string
+		if cookie != nil {
+			theme = cookie.Value
+		}
+		json.NewEncoder(r).Encode(map[string]any{
+			"query":   r.QueryParams.Get("q"),
+			"header":  r.Request.Header.Get("X-Test"),
+			"type":    r.Request.Header.Get("Content-Type"),
+			"body":    string(body),
+			"theme":   theme,
+			"host":    r.Request.Host,
+			"list":    []string{"a", "b"},
+			"session": r.Session != nil && r.Session.Get("key") == "value",
+		})
+	}))
+	r.Post("/echo", router.HandleFunc(func(r *request.Request) {
+		var body, _ = io.ReadAll(r.Request.Body)
+		r.Response.Header().Set("Content-Type", r.Request.Header.Get("Content-Type"))
+		r.Response.WriteHeader(http.StatusCreated)
+		r.Write(body)
+	}))
+	r.Get("/whoami", router.HandleFunc(func(r *request.Request) {
+		if u, ok := r.User.(*testUser); ok {
+			r.WriteString(u.name)
+			return
+		}
+		r.WriteString("anonymous")
+	})).Require(router.AdminOnly())
+	return r
+}
+
+func TestBuilder(t *testing.T) {
+	var client = routertest.New(newRouter())
+	client.Header.Set("X-Test", "client")
+	client.Cookies = append(client.Cookies, &http.Cookie{Name: "theme", Value: "dark"})
+
+	var res = client.Get("/echo").
+		Query("q", "search").
+		Session(routertest.NewSession(map[string]any{"key": "value"})).
+		Do()
+	res.AssertStatus(t, http.StatusOK)
+	res.AssertHeader(t, "X-Method", "GET")
+	res.AssertJSONPath(t, "query", "search")
+	res.AssertJSONPath(t, "header", "client")
+	res.AssertJSONPath(t, "theme", "dark")
+	res.AssertJSONPath(t, "host", "example.com")
+	res.AssertJSONPath(t, "session", true)
+	res.AssertJSONPath(t, "list.1", "b")
+	res.AssertData(t, "Title", "Echo")
+
+	res = client.Get("/echo?q=a").Query("q", "b").Header("X-Test", "builder").Do()
+	res.AssertJSONPath(t, "query", "a")
+	res.AssertJSONPath(t, "header", "builder")
+	res.AssertJSONPath(t, "session", false)
+
+	res = client.Post("/echo").JSON(map[string]any{"name": "john"}).Do()
+	res.AssertStatus(t, http.StatusCreated)
+	res.AssertHeader(t, "Content-Type", "application/json")
+	res.AssertJSONPath(t, "name", "john")
+
+	res = client.Post("/echo").Form(url.Values{"name": {"john"}}).Do()
+	res.AssertBodyContains(t, "name=john")
+
+	res = client.Post("/echo").Multipart(url.Values{"name": {"john"}}, routertest.File{
+		Field: "file", Filename: "a.txt", Content: []byte("file content"),
+	}).Do()
+	res.AssertStatus(t, http.StatusCreated)
+	res.AssertBodyContains(t, "file content")
+	res.AssertBodyContains(t, `name="name"`)
+
+	res = client.Delete("/echo").Do()
+	res.AssertStatus(t, http.StatusMethodNotAllowed)
+	if res.Request == nil || res.Request.Route.Pattern != "" {
+		t.Error("a request which matched no route should be recorded without a route")
+	}
+}
+
+func TestInjectWithoutMiddleware(t *testing.T) {
+	var r = router.NewRouter(true)
+	var route = r.Get("/plain", router.HandleFunc(func(r *request.Request) {
+		if r.Data.Request.User != r.User {
+			r.WriteString("template user not set")
+			return
+		}
+		if u, ok := r.User.(*testUser); ok && r.Session != nil {
+			r.WriteString(u.name + " " + r.Session.Get("key").(string))
+		}
+	}))
+	route.(*router.Route).DisableMiddleware()
+	var other = r.Get("/other", router.HandleFunc(func(r *request.Request) {}))
+
+	// Creating two clients does not change the router.
+	routertest.New(r)
+	var client = routertest.New(r)
+	if chain := r.Chain(other.(*router.Route)); len(chain) != 0 {
+		t.Errorf("New changed the middleware chain: %v", chain)
+	}
+
+	var res = client.Get("/plain").
+		User(&testUser{name: "john"}).
+		Session(routertest.NewSession(map[string]any{"key": "value"})).
+		Do()
+	res.AssertStatus(t, http.StatusOK)
+	res.AssertBodyContains(t, "john value")
+	if res.Request == nil || res.Request.Route.Pattern != "/plain" {
+		t.Error("the request was not recorded")
+	}
+}
+
+func TestBuilderUser(t *testing.T) {
+	var client = routertest.New(newRouter())
+
+	var res = client.Get("/whoami").Do()
+	res.AssertStatus(t, http.StatusForbidden)
+
+	res = client.Get("/whoami").User(&testUser{name: "john", admin: true}).Do()
+	res.AssertStatus(t, http.StatusOK)
+	res.AssertBodyContains(t, "john")
+
+	res = client.Get("/whoami").User(&testUser{name: "jane"}).Do()
+	res.AssertStatus(t, http.StatusForbidden)
+}
+
+func TestBuildErrors(t *testing.T) {
+	var client = routertest.New(newRouter())
+
+	var tests = []*routertest.RequestBuilder{
+		client.Get("/%zz"),
+		client.Request("BAD METHOD", "/echo"),
+		client.Post("/echo").JSON(func() {}),
+	}
+	for i, b := range tests {
+		if _, err := b.Build(); err == nil {
+			t.Errorf("%d: expected an error building the request", i)
+		}
+		var res = b.Do()
+		if res.Err == nil {
+			t.Errorf("%d: expected the error to be recorded on the response", i)
+		}
+		if res.Request != nil || res.Status() != http.StatusOK || res.Body() != "" {
+			t.Errorf("%d: the request should not have been sent", i)
+		}
+	}
+}
+
+// Records the failures of the assertions.
+type recordingT struct {
+	testing.TB
+	errors int
+	fatal  bool
+}
+
+func (t *recordingT) Helper()                        {}
+func (t *recordingT) Errorf(format string, a ...any) { t.errors++ }
+func (t *recordingT) Error(a ...any)                 { t.errors++ }
+func (t *recordingT) Fatalf(format string, a ...any) { t.errors++; t.fatal = true }
+
+func TestAssertionsFail(t *testing.T) {
+	var client = routertest.New(newRouter())
+	var res = client.Get("/echo").Do()
+
+	var tests = []struct {
+		name   string
+		assert func(t testing.TB)
+	}{
+		{"status", func(t testing.TB) { res.AssertStatus(t, http.StatusNotFound) }},
+		{"header", func(t testing.TB) { res.AssertHeader(t, "X-Method", "POST") }},
+		{"body", func(t testing.TB) { res.AssertBodyContains(t, "missing") }},
+		{"json path value", func(t testing.TB) { res.AssertJSONPath(t, "query", "other") }},
+		{"json path missing", func(t testing.TB) { res.AssertJSONPath(t, "missing.key", "") }},
+		{"json path index", func(t testing.TB) { res.AssertJSONPath(t, "list.5", "") }},
+		{"data value", func(t testing.TB) { res.AssertData(t, "Title", "Other") }},
+		{"data missing", func(t testing.TB) { res.AssertData(t, "Missing", nil) }},
+	}
+	for _, test := range tests {
+		var rt = &recordingT{TB: t}
+		test.assert(rt)
+		if rt.errors != 1 {
+			t.Errorf("%s: got %d failures, want 1", test.name, rt.errors)
+		}
+	}
+
+	var rt = &recordingT{TB: t}
+	client.Get("/%zz").Do().AssertStatus(rt, http.StatusOK)
+	if !rt.fatal {
+		t.Error("asserting on a request which was not built should be fatal")
+	}
+}
@@ -0,0 +1,54 @@
+package routertest
+
+import "sync"
+
+// Session is an in-memory request.Session, for injecting sessions into requests.
+type Session struct {
+	values map[string]any
+	mu     sync.RWMutex
+}
+
+// NewSession creates a session with the given values.
+func NewSession(values map[string]any) *Session {
+	var s = &Session{values: make(map[string]any, len(values))}
+	for k, v := range values {
+		s.values[k] = v
+	}
+	return s
+}
+
+func (s *Session) Set(key string, value any) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.values[key] = value
+}
+
+func (s *Session) Get(key string) any {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.values[key]
+}
+
+func (s *Session) Exists(key string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	_, ok := s.values[key]
+	return ok
+}
+
+func (s *Session) Delete(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.values, key)
+}
+
+func (s *Session) Destroy() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.values = make(map[string]any)
+	return nil
+}
+
+func (s *Session) RenewToken() error {
+	return nil
+}
@@ -0,0 +1,16 @@
+//go:build go1.24
+// +build go1.24
+
+package server
+
+import "net/http"
+
+// Serve HTTP/2 over cleartext connections, next to HTTP/1.
+func enableH2C(srv *http.Server) error {
+	var protocols = new(http.Protocols)
+	protocols.SetHTTP1(true)
+	protocols.SetHTTP2(true)
+	protocols.SetUnencryptedHTTP2(true)
+	srv.Protocols = protocols
+	return nil
+}
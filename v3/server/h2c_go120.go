@@ -0,0 +1,13 @@
+//go:build !go1.24
+// +build !go1.24
+
+package server
+
+import (
+	"errors"
+	"net/http"
+)
+
+func enableH2C(srv *http.Server) error {
+	return errors.New("server: H2C requires Go 1.24 or newer")
+}
@@ -0,0 +1,306 @@
+// Package server runs a router with sane timeouts, graceful shutdown and lifecycle hooks.
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/Nigel2392/router/v3"
+)
+
+// Default timeouts, used when the options do not set them.
+var (
+	READ_TIMEOUT        = 15 * time.Second
+	READ_HEADER_TIMEOUT = 5 * time.Second
+	WRITE_TIMEOUT       = 30 * time.Second
+	IDLE_TIMEOUT        = 120 * time.Second
+
+	// How long open connections may take to finish after a shutdown started.
+	SHUTDOWN_TIMEOUT = 30 * time.Second
+)
+
+// Hook is run when the server starts or shuts down.
+type Hook func(ctx context.Context) error
+
+type Options struct {
+	// Address to listen on, like ":8080", or "unix:/run/app.sock" for a unix socket.
+	// Ignored if a Listener is set.
+	Addr string
+
+	// Listener to serve on, instead of listening on Addr.
+	Listener net.Listener
+
+	// Serve TLS with the certificate and key files, or the TLS config.
+	CertFile  string
+	KeyFile   string
+	TLSConfig *tls.Config
+
+	// Serve HTTP/2 without TLS, this requires Go 1.24 or newer.
+	H2C bool
+
+	// Timeouts of the server, the defaults are used when zero.
+	ReadTimeout       time.Duration
+	ReadHeaderTimeout time.Duration
+	WriteTimeout      time.Duration
+	IdleTimeout       time.Duration
+	ShutdownTimeout   time.Duration
+
+	// Time to wait after readiness was flipped to failing, before connections are drained.
+	// This gives load balancers the time to stop sending traffic.
+	DrainDelay time.Duration
+
+	// Signals which start a graceful shutdown, SIGINT and SIGTERM if empty.
+	Signals []os.Signal
+
+	// Validate the router's routes before the server starts.
+	Validate bool
+}
+
+// Server serves a router, and shuts it down gracefully.
+type Server struct {
+	Router  *router.Router
+	Options Options
+
+	// The underlying server, available after the server started.
+	HTTP *http.Server
+
+	onStart    []Hook
+	onShutdown []Hook
+	ready      atomic.Bool
+	stopping   atomic.Bool
+	listener   net.Listener
+	shutdown   sync.Once
+	err        error
+	mu         sync.Mutex
+}
+
+// New returns a server for the router.
+func New(r *router.Router, options Options) *Server {
+	return &Server{Router: r, Options: options}
+}
+
+// OnStart adds hooks which are run before the server starts serving.
+//
+// If a hook fails, the server does not start.
+func (s *Server) OnStart(hooks ...Hook) {
+	s.onStart = append(s.onStart, hooks...)
+}
+
+// OnShutdown adds hooks which are run after all connections were drained, in reverse order.
+func (s *Server) OnShutdown(hooks ...Hook) {
+	s.onShutdown = append(s.onShutdown, hooks...)
+}
+
+// Ready reports whether the server is serving, and not shutting down.
+func (s *Server) Ready() bool {
+	return s.ready.Load()
+}
+
+// SetReady sets the readiness of the server, for example while a cache is warming up.
+//
+// A server which is shutting down never becomes ready again.
+func (s *Server) SetReady(ready bool) {
+	s.mu.Lock()
+	s.ready.Store(ready && !s.stopping.Load())
+	s.mu.Unlock()
+}
+
+// ShuttingDown reports whether a shutdown started.
+func (s *Server) ShuttingDown() bool {
+	return s.stopping.Load()
+}
+
+// ListenAndServe serves until one of the signals is received, then shuts down gracefully.
+//
+// A second signal is not caught, and stops the process while it is shutting down.
+func (s *Server) ListenAndServe() error {
+	var signals = s.Options.Signals
+	if len(signals) == 0 {
+		signals = []os.Signal{syscall.SIGINT, syscall.SIGTERM}
+	}
+	var ctx, stop = signal.NotifyContext(context.Background(), signals...)
+	defer stop()
+	go func() {
+		// Restore the default behaviour once the shutdown starts,
+		// so a second signal stops the process while connections are drained.
+		<-ctx.Done()
+		stop()
+	}()
+	return s.Serve(ctx)
+}
+
+// Serve serves until the context is done, then shuts down gracefully.
+//
+// It returns http.ErrServerClosed if a shutdown started before the server was serving.
+func (s *Server) Serve(ctx context.Context) error {
+	if s.stopping.Load() {
+		return http.ErrServerClosed
+	}
+	if err := s.start(ctx); err != nil {
+		return err
+	}
+
+	var errs = make(chan error, 1)
+	go func() {
+		errs <- s.serve()
+	}()
+
+	select {
+	case err := <-errs:
+		// The server stopped on its own, still run the shutdown hooks.
+		s.SetReady(false)
+		return errors.Join(err, s.Shutdown(context.Background()))
+	case <-ctx.Done():
+		return s.Shutdown(context.Background())
+	}
+}
+
+// Shutdown flips readiness to failing, waits for the drain delay,
+// and drains open connections within the shutdown timeout.
+// The drain delay is cut short when the context is done.
+// The shutdown hooks are run afterwards.
+//
+// It is safe to call Shutdown more than once, later calls return the result of the first.
+// A server which was shut down before it started can not be started anymore.
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.shutdown.Do(func() {
+		// Serve sets the server under the same lock,
+		// so it either sees the shutdown or the server is drained below.
+		s.mu.Lock()
+		s.stopping.Store(true)
+		s.ready.Store(false)
+		var srv = s.HTTP
+		s.mu.Unlock()
+
+		if s.Options.DrainDelay > 0 {
+			var timer = time.NewTimer(s.Options.DrainDelay)
+			select {
+			case <-timer.C:
+			case <-ctx.Done():
+				timer.Stop()
+			}
+		}
+
+		var timeout = s.Options.ShutdownTimeout
+		if timeout == 0 {
+			timeout = SHUTDOWN_TIMEOUT
+		}
+		var drainCtx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+
+		var errs []error
+		if srv != nil {
+			if err := srv.Shutdown(drainCtx); err != nil {
+				errs = append(errs, fmt.Errorf("server: drain: %w", err))
+				srv.Close()
+			}
+		}
+		for i := len(s.onShutdown) - 1; i >= 0; i-- {
+			if err := s.onShutdown[i](drainCtx); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		s.err = errors.Join(errs...)
+	})
+	return s.err
+}
+
+// Validates the router, runs the start hooks and listens.
+func (s *Server) start(ctx context.Context) error {
+	if s.Options.Validate {
+		if err := s.Router.Validate(); err != nil {
+			return err
+		}
+	}
+
+	var srv = &http.Server{
+		Handler:           s.Router,
+		TLSConfig:         s.Options.TLSConfig,
+		ReadTimeout:       orDefault(s.Options.ReadTimeout, READ_TIMEOUT),
+		ReadHeaderTimeout: orDefault(s.Options.ReadHeaderTimeout, READ_HEADER_TIMEOUT),
+		WriteTimeout:      orDefault(s.Options.WriteTimeout, WRITE_TIMEOUT),
+		IdleTimeout:       orDefault(s.Options.IdleTimeout, IDLE_TIMEOUT),
+	}
+	if s.Options.H2C {
+		if err := enableH2C(srv); err != nil {
+			return err
+		}
+	}
+
+	for _, hook := range s.onStart {
+		if err := hook(ctx); err != nil {
+			return fmt.Errorf("server: start hook: %w", err)
+		}
+	}
+
+	var listener, err = s.listen()
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stopping.Load() {
+		listener.Close()
+		return http.ErrServerClosed
+	}
+	s.HTTP = srv
+	s.listener = listener
+	s.ready.Store(true)
+	return nil
+}
+
+func (s *Server) listen() (net.Listener, error) {
+	if s.Options.Listener != nil {
+		return s.Options.Listener, nil
+	}
+	if path, ok := strings.CutPrefix(s.Options.Addr, "unix:"); ok {
+		// Remove a socket left behind by a previous process.
+		if info, err := os.Stat(path); err == nil && info.Mode()&os.ModeSocket != 0 {
+			os.Remove(path)
+		}
+		return net.Listen("unix", path)
+	}
+	return net.Listen("tcp", s.Options.Addr)
+}
+
+func (s *Server) serve() error {
+	var err error
+	if s.Options.CertFile != "" || s.Options.TLSConfig != nil {
+		err = s.HTTP.ServeTLS(s.listener, s.Options.CertFile, s.Options.KeyFile)
+	} else {
+		err = s.HTTP.Serve(s.listener)
+	}
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
+}
+
+// Addr returns the address the server listens on, available after the server started.
+func (s *Server) Addr() net.Addr {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.listener == nil {
+		return nil
+	}
+	return s.listener.Addr()
+}
+
+func orDefault(d, def time.Duration) time.Duration {
+	if d == 0 {
+		return def
+	}
+	return d
+}
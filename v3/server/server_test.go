@@ -0,0 +1,77 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/Nigel2392/router/v3"
+)
+
+func TestShutdownBeforeServe(t *testing.T) {
+	var s = New(router.NewRouter(true), Options{Addr: "127.0.0.1:0"})
+	if err := s.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	var done = make(chan error, 1)
+	go func() {
+		done <- s.Serve(context.Background())
+	}()
+	select {
+	case err := <-done:
+		if !errors.Is(err, http.ErrServerClosed) {
+			t.Errorf("Serve after Shutdown: got %v, want http.ErrServerClosed", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Serve did not return after Shutdown")
+	}
+	if s.Ready() {
+		t.Error("server is ready after Shutdown")
+	}
+}
+
+func TestServeAndShutdown(t *testing.T) {
+	var s = New(router.NewRouter(true), Options{Addr: "127.0.0.1:0"})
+	var ready = make(chan struct{})
+	s.OnStart(func(ctx context.Context) error {
+		close(ready)
+		return nil
+	})
+	var ctx, cancel = context.WithCancel(context.Background())
+	var done = make(chan error, 1)
+	go func() {
+		done <- s.Serve(ctx)
+	}()
+	<-ready
+	for i := 0; i < 100 && !s.Ready(); i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if !s.Ready() {
+		t.Fatal("server did not become ready")
+	}
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	s.SetReady(true)
+	if s.Ready() {
+		t.Error("server became ready after Shutdown")
+	}
+}
+
+func TestShutdownContextCutsDrainDelay(t *testing.T) {
+	var s = New(router.NewRouter(true), Options{Addr: "127.0.0.1:0", DrainDelay: time.Hour})
+	var ctx, cancel = context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	var done = make(chan error, 1)
+	go func() {
+		done <- s.Shutdown(ctx)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Shutdown waited for the drain delay after the context was done")
+	}
+}
@@ -0,0 +1,74 @@
+package sitemap
+
+import (
+	"bytes"
+	"compress/gzip"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/Nigel2392/router/v3"
+	"github.com/Nigel2392/router/v3/request"
+	"github.com/Nigel2392/router/v3/robots"
+)
+
+// Route returns a GET route which serves the sitemap on its Path.
+func (s *Sitemap) Route(name string) router.Registrar {
+	return router.NewRoute(router.GET, s.path(), name, s.ServeHTTP)
+}
+
+// ServeHTTP writes the sitemap.
+//
+// If there are more URLs than fit in a single file, a sitemap index is written,
+// which lists the pages of the sitemap with a "page" query parameter.
+//
+// The response is gzipped for clients which accept it.
+func (s *Sitemap) ServeHTTP(r *request.Request) {
+	var urls, err = s.URLs()
+	if err != nil {
+		r.HandleError(err)
+		return
+	}
+
+	var buf bytes.Buffer
+	var pages = s.Pages(urls)
+	var page = r.QueryParams.Get("page")
+	switch {
+	case page != "":
+		var n, _ = strconv.Atoi(page)
+		var chunk, pageErr = s.Page(urls, n)
+		if pageErr != nil {
+			r.Error(http.StatusNotFound, http.StatusText(http.StatusNotFound))
+			return
+		}
+		err = WriteURLSet(&buf, chunk)
+	case pages > 1:
+		var locations = make([]string, 0, pages)
+		for i := 1; i <= pages; i++ {
+			locations = append(locations, s.PageURL(i))
+		}
+		err = WriteIndex(&buf, locations)
+	default:
+		err = WriteURLSet(&buf, urls)
+	}
+	if err != nil {
+		r.HandleError(err)
+		return
+	}
+
+	r.Response.Header().Set("Content-Type", "application/xml; charset=utf-8")
+	r.Response.Header().Add("Vary", "Accept-Encoding")
+	if !strings.Contains(r.Request.Header.Get("Accept-Encoding"), "gzip") {
+		r.Response.Write(buf.Bytes())
+		return
+	}
+	r.Response.Header().Set("Content-Encoding", "gzip")
+	var zw = gzip.NewWriter(r.Response)
+	zw.Write(buf.Bytes())
+	zw.Close()
+}
+
+// Robots adds the sitemap's URL to the robots.txt options.
+func (s *Sitemap) Robots(options *robots.Options) {
+	options.SiteMaps = append(options.SiteMaps, s.URL())
+}
@@ -0,0 +1,217 @@
+// Package sitemap generates XML sitemaps from named routes.
+//
+//	var sm = sitemap.New("https://example.com")
+//	sm.Add(r.Get("/about", aboutHandler, "about"), sitemap.Options{ChangeFreq: sitemap.Monthly, Priority: 0.5})
+//	sm.AddProvider(r.Get("/blog/<<slug:slug>>", postHandler, "post"), sitemap.Options{ChangeFreq: sitemap.Weekly},
+//		func(route *router.Route) ([]*sitemap.URL, error) {
+//			var urls = make([]*sitemap.URL, 0)
+//			for _, post := range posts {
+//				urls = append(urls, &sitemap.URL{Loc: route.Format(post.Slug), LastMod: post.Updated})
+//			}
+//			return urls, nil
+//		},
+//	)
+//	r.AddGroup(sm.Route("sitemap"))
+package sitemap
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Nigel2392/router/v3"
+)
+
+// Maximum number of URLs in a single sitemap file, as defined by the sitemap protocol.
+const MAX_URLS = 50000
+
+// How often the content of a page is likely to change.
+type ChangeFreq string
+
+const (
+	Always  ChangeFreq = "always"
+	Hourly  ChangeFreq = "hourly"
+	Daily   ChangeFreq = "daily"
+	Weekly  ChangeFreq = "weekly"
+	Monthly ChangeFreq = "monthly"
+	Yearly  ChangeFreq = "yearly"
+	Never   ChangeFreq = "never"
+)
+
+// Options of a route in the sitemap.
+//
+// They are used as defaults for the URLs of a provider.
+type Options struct {
+	ChangeFreq ChangeFreq
+	// Priority between 0.0 and 1.0, zero is omitted.
+	Priority float64
+	LastMod  time.Time
+}
+
+// Image in the image sitemap extension.
+type Image struct {
+	Loc string
+}
+
+// News article in the news sitemap extension.
+type News struct {
+	PublicationName     string
+	PublicationLanguage string
+	PublicationDate     time.Time
+	Title               string
+}
+
+// URL is a single entry of the sitemap.
+type URL struct {
+	// Location of the page.
+	// Relative locations are prefixed with the sitemap's base URL.
+	Loc        string
+	LastMod    time.Time
+	ChangeFreq ChangeFreq
+	Priority   float64
+	Images     []Image
+	News       *News
+}
+
+// Provider lists the URLs of a parameterised route,
+// usually by calling route.Format for every object.
+type Provider func(route *router.Route) ([]*URL, error)
+
+type entry struct {
+	route    *router.Route
+	options  Options
+	provider Provider
+}
+
+// Sitemap collects the routes which should be listed.
+type Sitemap struct {
+	// Scheme and host of the site, like "https://example.com".
+	BaseURL string
+
+	// Path the sitemap is served on, defaults to "/sitemap.xml".
+	Path string
+
+	// Maximum URLs per sitemap file, defaults to MAX_URLS.
+	// If there are more URLs, a sitemap index is served instead.
+	MaxURLs int
+
+	entries []entry
+	mu      sync.RWMutex
+}
+
+// New creates a new sitemap for the site at the base URL.
+func New(baseURL string) *Sitemap {
+	return &Sitemap{
+		BaseURL: strings.TrimSuffix(baseURL, "/"),
+		Path:    "/sitemap.xml",
+		MaxURLs: MAX_URLS,
+	}
+}
+
+// Add a route without parameters to the sitemap.
+//
+// Returns the route, so it can be chained.
+func (s *Sitemap) Add(route router.Registrar, options Options) router.Registrar {
+	return s.add(route, options, nil)
+}
+
+// AddProvider adds a parameterised route to the sitemap,
+// its URLs are listed by the provider.
+//
+// Returns the route, so it can be chained.
+func (s *Sitemap) AddProvider(route router.Registrar, options Options, provider Provider) router.Registrar {
+	return s.add(route, options, provider)
+}
+
+func (s *Sitemap) add(route router.Registrar, options Options, provider Provider) router.Registrar {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries = append(s.entries, entry{route: route.(*router.Route), options: options, provider: provider})
+	return route
+}
+
+// URL returns the absolute URL of the sitemap.
+func (s *Sitemap) URL() string {
+	return s.BaseURL + s.path()
+}
+
+// URLs returns every URL of the sitemap, with absolute locations.
+func (s *Sitemap) URLs() ([]*URL, error) {
+	s.mu.RLock()
+	var entries = append([]entry(nil), s.entries...)
+	s.mu.RUnlock()
+
+	var urls = make([]*URL, 0, len(entries))
+	for _, e := range entries {
+		if e.provider == nil {
+			urls = append(urls, s.withDefaults(&URL{Loc: e.route.Format()}, e.options))
+			continue
+		}
+		var provided, err = e.provider(e.route)
+		if err != nil {
+			return nil, fmt.Errorf("sitemap: provider of route %q: %w", e.route.Name(), err)
+		}
+		for _, u := range provided {
+			urls = append(urls, s.withDefaults(u, e.options))
+		}
+	}
+	return urls, nil
+}
+
+// Copy the URL with an absolute location, and the options as defaults.
+func (s *Sitemap) withDefaults(u *URL, options Options) *URL {
+	var c = *u
+	if !strings.Contains(c.Loc, "://") {
+		c.Loc = s.BaseURL + "/" + strings.TrimPrefix(c.Loc, "/")
+	}
+	if c.ChangeFreq == "" {
+		c.ChangeFreq = options.ChangeFreq
+	}
+	if c.Priority == 0 {
+		c.Priority = options.Priority
+	}
+	if c.LastMod.IsZero() {
+		c.LastMod = options.LastMod
+	}
+	return &c
+}
+
+// Pages returns the number of sitemap files needed for the URLs.
+func (s *Sitemap) Pages(urls []*URL) int {
+	var max = s.maxURLs()
+	return (len(urls) + max - 1) / max
+}
+
+// Page returns the URLs of a sitemap file, starting at 1.
+func (s *Sitemap) Page(urls []*URL, page int) ([]*URL, error) {
+	var max = s.maxURLs()
+	if page < 1 || page > s.Pages(urls) {
+		return nil, errors.New("sitemap: page out of range")
+	}
+	var end = page * max
+	if end > len(urls) {
+		end = len(urls)
+	}
+	return urls[(page-1)*max : end], nil
+}
+
+// PageURL returns the absolute URL of a sitemap file in the index.
+func (s *Sitemap) PageURL(page int) string {
+	return fmt.Sprintf("%s?page=%d", s.URL(), page)
+}
+
+func (s *Sitemap) maxURLs() int {
+	if s.MaxURLs <= 0 || s.MaxURLs > MAX_URLS {
+		return MAX_URLS
+	}
+	return s.MaxURLs
+}
+
+func (s *Sitemap) path() string {
+	if s.Path == "" {
+		return "/sitemap.xml"
+	}
+	return "/" + strings.TrimPrefix(s.Path, "/")
+}
@@ -0,0 +1,251 @@
+package sitemap
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/xml"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Nigel2392/router/v3"
+	"github.com/Nigel2392/router/v3/request"
+	"github.com/Nigel2392/router/v3/robots"
+)
+
+type testURLSet struct {
+	URLs []struct {
+		Loc        string `xml:"loc"`
+		LastMod    string `xml:"lastmod"`
+		ChangeFreq string `xml:"changefreq"`
+		Priority   string `xml:"priority"`
+	} `xml:"url"`
+}
+
+type testIndex struct {
+	Sitemaps []struct {
+		Loc string `xml:"loc"`
+	} `xml:"sitemap"`
+}
+
+var noop = router.HandleFunc(func(r *request.Request) {})
+
+// Returns a sitemap with the number of post URLs, served by a router.
+func newTestSitemap(posts int) (*Sitemap, *router.Router) {
+	var r = router.NewRouter(true)
+	var sm = New("https://example.com/")
+	sm.Add(r.Get("/about", noop, "about"), Options{ChangeFreq: Monthly, Priority: 0.5})
+	sm.AddProvider(r.Get("/posts/<<id:int>>", noop, "post"), Options{ChangeFreq: Weekly},
+		func(route *router.Route) ([]*URL, error) {
+			var urls = make([]*URL, 0, posts)
+			for i := 1; i <= posts; i++ {
+				urls = append(urls, &URL{Loc: route.Format(i)})
+			}
+			return urls, nil
+		},
+	)
+	r.AddGroup(sm.Route("sitemap"))
+	return sm, r
+}
+
+func get(t *testing.T, r *router.Router, path string, header ...string) *httptest.ResponseRecorder {
+	t.Helper()
+	var rq = httptest.NewRequest("GET", path, nil)
+	for i := 0; i+1 < len(header); i += 2 {
+		rq.Header.Set(header[i], header[i+1])
+	}
+	var w = httptest.NewRecorder()
+	r.ServeHTTP(w, rq)
+	return w
+}
+
+func decode(t *testing.T, data []byte, v any) {
+	t.Helper()
+	if err := xml.Unmarshal(data, v); err != nil {
+		t.Fatalf("%v: %s", err, data)
+	}
+}
+
+func TestSingleSitemap(t *testing.T) {
+	var _, r = newTestSitemap(2)
+	var w = get(t, r, "/sitemap.xml")
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "application/xml; charset=utf-8" {
+		t.Fatalf("got status %d, content type %q", w.Code, w.Header().Get("Content-Type"))
+	}
+	var set testURLSet
+	decode(t, w.Body.Bytes(), &set)
+	if len(set.URLs) != 3 {
+		t.Fatalf("got %d URLs, want 3", len(set.URLs))
+	}
+	var about, post = set.URLs[0], set.URLs[2]
+	if about.Loc != "https://example.com/about" || about.ChangeFreq != "monthly" || about.Priority != "0.5" {
+		t.Errorf("got %+v", about)
+	}
+	if post.Loc != "https://example.com/posts/2" || post.ChangeFreq != "weekly" || post.Priority != "" {
+		t.Errorf("provided URLs should use the options as defaults, got %+v", post)
+	}
+	if w := get(t, r, "/sitemap.xml?page=2"); w.Code != http.StatusNotFound {
+		t.Errorf("got status %d for a page of a single sitemap, want 404", w.Code)
+	}
+}
+
+func TestSitemapIndex(t *testing.T) {
+	var sm, r = newTestSitemap(MAX_URLS)
+	var urls, err = sm.URLs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(urls) != MAX_URLS+1 || sm.Pages(urls) != 2 {
+		t.Fatalf("got %d URLs in %d pages, want %d in 2", len(urls), sm.Pages(urls), MAX_URLS+1)
+	}
+
+	var index testIndex
+	decode(t, get(t, r, "/sitemap.xml").Body.Bytes(), &index)
+	if len(index.Sitemaps) != 2 || index.Sitemaps[0].Loc != "https://example.com/sitemap.xml?page=1" || index.Sitemaps[1].Loc != sm.PageURL(2) {
+		t.Fatalf("got index %+v", index)
+	}
+
+	var first, second testURLSet
+	decode(t, get(t, r, "/sitemap.xml?page=1").Body.Bytes(), &first)
+	decode(t, get(t, r, "/sitemap.xml?page=2").Body.Bytes(), &second)
+	if len(first.URLs) != MAX_URLS || len(second.URLs) != 1 {
+		t.Errorf("got pages of %d and %d URLs, want %d and 1", len(first.URLs), len(second.URLs), MAX_URLS)
+	}
+	if second.URLs[0].Loc != "https://example.com/posts/"+strconv.Itoa(MAX_URLS) {
+		t.Errorf("got %q as the last URL", second.URLs[0].Loc)
+	}
+
+	for _, page := range []string{"0", "3", "-1", "abc"} {
+		if w := get(t, r, "/sitemap.xml?page="+page); w.Code != http.StatusNotFound {
+			t.Errorf("page %s: got status %d, want 404", page, w.Code)
+		}
+	}
+}
+
+func TestPages(t *testing.T) {
+	var sm = New("https://example.com")
+	sm.MaxURLs = 2
+	var urls = make([]*URL, 5)
+	for i := range urls {
+		urls[i] = &URL{Loc: strconv.Itoa(i)}
+	}
+	if sm.Pages(urls) != 3 || sm.Pages(nil) != 0 {
+		t.Errorf("got %d pages for 5 URLs, %d for none", sm.Pages(urls), sm.Pages(nil))
+	}
+	var tests = []struct {
+		page int
+		locs string
+	}{
+		{1, "0,1"},
+		{2, "2,3"},
+		{3, "4"},
+	}
+	for _, test := range tests {
+		var chunk, err = sm.Page(urls, test.page)
+		if err != nil {
+			t.Errorf("page %d: %v", test.page, err)
+			continue
+		}
+		var locs = make([]string, len(chunk))
+		for i, u := range chunk {
+			locs[i] = u.Loc
+		}
+		if strings.Join(locs, ",") != test.locs {
+			t.Errorf("page %d: got %v, want %s", test.page, locs, test.locs)
+		}
+	}
+	for _, page := range []int{0, 4} {
+		if _, err := sm.Page(urls, page); err == nil {
+			t.Errorf("page %d should be out of range", page)
+		}
+	}
+
+	sm.MaxURLs = MAX_URLS + 1
+	if sm.Pages(urls) != 1 {
+		t.Error("MaxURLs above MAX_URLS should be capped")
+	}
+}
+
+func TestGzip(t *testing.T) {
+	var _, r = newTestSitemap(2)
+	var w = get(t, r, "/sitemap.xml", "Accept-Encoding", "gzip, deflate")
+	if w.Header().Get("Content-Encoding") != "gzip" || w.Header().Get("Vary") != "Accept-Encoding" {
+		t.Fatalf("got Content-Encoding %q, Vary %q", w.Header().Get("Content-Encoding"), w.Header().Get("Vary"))
+	}
+	var zr, err = gzip.NewReader(w.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plain := get(t, r, "/sitemap.xml").Body.Bytes(); !bytes.Equal(data, plain) {
+		t.Errorf("the gzipped sitemap differs:\n%s\n%s", data, plain)
+	}
+}
+
+func TestFiles(t *testing.T) {
+	var sm, _ = newTestSitemap(3)
+	sm.MaxURLs = 2
+	var files, err = sm.Files(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 3 {
+		t.Fatalf("got %d files, want the index and 2 pages", len(files))
+	}
+	var zr *gzip.Reader
+	if zr, err = gzip.NewReader(bytes.NewReader(files["sitemap.xml.gz"])); err != nil {
+		t.Fatal(err)
+	}
+	var data, _ = io.ReadAll(zr)
+	var index testIndex
+	decode(t, data, &index)
+	if len(index.Sitemaps) != 2 || index.Sitemaps[1].Loc != "https://example.com/sitemap-2.xml.gz" {
+		t.Errorf("got index %+v", index)
+	}
+}
+
+func TestProviderError(t *testing.T) {
+	var r = router.NewRouter(true)
+	var sm = New("https://example.com")
+	sm.AddProvider(r.Get("/posts/<<id:int>>", noop, "post"), Options{}, func(route *router.Route) ([]*URL, error) {
+		return nil, errors.New("database down")
+	})
+	r.AddGroup(sm.Route("sitemap"))
+	if w := get(t, r, "/sitemap.xml"); w.Code != http.StatusInternalServerError || strings.Contains(w.Body.String(), "database") {
+		t.Errorf("got status %d, body %q", w.Code, w.Body)
+	}
+}
+
+func TestRobots(t *testing.T) {
+	var sm = New("https://example.com")
+	var options = &robots.Options{}
+	sm.Robots(options)
+	if len(options.SiteMaps) != 1 || options.SiteMaps[0] != "https://example.com/sitemap.xml" {
+		t.Errorf("got sitemaps %v", options.SiteMaps)
+	}
+}
+
+func TestLastMod(t *testing.T) {
+	var r = router.NewRouter(true)
+	var sm = New("https://example.com")
+	var day = time.Date(2023, 1, 31, 12, 0, 0, 0, time.UTC)
+	sm.Add(r.Get("/", noop, "index"), Options{LastMod: day})
+	var buf bytes.Buffer
+	var urls, _ = sm.URLs()
+	if err := WriteURLSet(&buf, urls); err != nil {
+		t.Fatal(err)
+	}
+	var set testURLSet
+	decode(t, buf.Bytes(), &set)
+	if set.URLs[0].LastMod != "2023-01-31T12:00:00Z" || set.URLs[0].Loc != "https://example.com/" {
+		t.Errorf("got %+v", set.URLs[0])
+	}
+}
@@ -0,0 +1,164 @@
+package sitemap
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/xml"
+	"io"
+	"path"
+	"strconv"
+	"time"
+)
+
+const (
+	xmlnsSitemap = "http://www.sitemaps.org/schemas/sitemap/0.9"
+	xmlnsImage   = "http://www.google.com/schemas/sitemap-image/1.1"
+	xmlnsNews    = "http://www.google.com/schemas/sitemap-news/0.9"
+)
+
+type xmlURLSet struct {
+	XMLName    xml.Name `xml:"urlset"`
+	Xmlns      string   `xml:"xmlns,attr"`
+	XmlnsImage string   `xml:"xmlns:image,attr,omitempty"`
+	XmlnsNews  string   `xml:"xmlns:news,attr,omitempty"`
+	URLs       []xmlURL `xml:"url"`
+}
+
+type xmlURL struct {
+	Loc        string     `xml:"loc"`
+	LastMod    string     `xml:"lastmod,omitempty"`
+	ChangeFreq ChangeFreq `xml:"changefreq,omitempty"`
+	Priority   string     `xml:"priority,omitempty"`
+	Images     []xmlImage `xml:"image:image"`
+	News       *xmlNews   `xml:"news:news"`
+}
+
+type xmlImage struct {
+	Loc string `xml:"image:loc"`
+}
+
+type xmlNews struct {
+	Name     string `xml:"news:publication>news:name"`
+	Language string `xml:"news:publication>news:language"`
+	Date     string `xml:"news:publication_date"`
+	Title    string `xml:"news:title"`
+}
+
+type xmlIndex struct {
+	XMLName  xml.Name         `xml:"sitemapindex"`
+	Xmlns    string           `xml:"xmlns,attr"`
+	Sitemaps []xmlIndexRecord `xml:"sitemap"`
+}
+
+type xmlIndexRecord struct {
+	Loc string `xml:"loc"`
+}
+
+// WriteURLSet writes the URLs as a sitemap file.
+func WriteURLSet(w io.Writer, urls []*URL) error {
+	var set = xmlURLSet{Xmlns: xmlnsSitemap, URLs: make([]xmlURL, 0, len(urls))}
+	for _, u := range urls {
+		var x = xmlURL{Loc: u.Loc, ChangeFreq: u.ChangeFreq}
+		if !u.LastMod.IsZero() {
+			x.LastMod = u.LastMod.Format(time.RFC3339)
+		}
+		if u.Priority != 0 {
+			x.Priority = strconv.FormatFloat(u.Priority, 'f', 1, 64)
+		}
+		for _, img := range u.Images {
+			x.Images = append(x.Images, xmlImage{Loc: img.Loc})
+			set.XmlnsImage = xmlnsImage
+		}
+		if u.News != nil {
+			x.News = &xmlNews{
+				Name:     u.News.PublicationName,
+				Language: u.News.PublicationLanguage,
+				Date:     u.News.PublicationDate.Format(time.RFC3339),
+				Title:    u.News.Title,
+			}
+			set.XmlnsNews = xmlnsNews
+		}
+		set.URLs = append(set.URLs, x)
+	}
+	return writeXML(w, set)
+}
+
+// WriteIndex writes a sitemap index, listing the sitemap files at the locations.
+func WriteIndex(w io.Writer, locations []string) error {
+	var index = xmlIndex{Xmlns: xmlnsSitemap, Sitemaps: make([]xmlIndexRecord, 0, len(locations))}
+	for _, loc := range locations {
+		index.Sitemaps = append(index.Sitemaps, xmlIndexRecord{Loc: loc})
+	}
+	return writeXML(w, index)
+}
+
+func writeXML(w io.Writer, v any) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	var enc = xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	return enc.Encode(v)
+}
+
+// Files returns the contents of every sitemap file, for serving them as static files.
+//
+// If the URLs fit in a single file, only "sitemap.xml" is returned.
+// Otherwise "sitemap.xml" is an index of "sitemap-1.xml", "sitemap-2.xml" and so on,
+// which are listed relative to the sitemap's URL.
+//
+// If gz is true, the files are gzipped and get a ".gz" suffix.
+func (s *Sitemap) Files(gz bool) (map[string][]byte, error) {
+	var urls, err = s.URLs()
+	if err != nil {
+		return nil, err
+	}
+	var ext = ".xml"
+	if gz {
+		ext += ".gz"
+	}
+	var files = make(map[string][]byte)
+	var write = func(name string, f func(w io.Writer) error) error {
+		var buf bytes.Buffer
+		var w io.Writer = &buf
+		var zw *gzip.Writer
+		if gz {
+			zw = gzip.NewWriter(&buf)
+			w = zw
+		}
+		if err := f(w); err != nil {
+			return err
+		}
+		if zw != nil {
+			if err := zw.Close(); err != nil {
+				return err
+			}
+		}
+		files[name] = buf.Bytes()
+		return nil
+	}
+
+	var pages = s.Pages(urls)
+	if pages <= 1 {
+		return files, write("sitemap"+ext, func(w io.Writer) error {
+			return WriteURLSet(w, urls)
+		})
+	}
+
+	var base = s.URL()
+	base = base[:len(base)-len(path.Base(base))]
+	var locations = make([]string, 0, pages)
+	for page := 1; page <= pages; page++ {
+		var name = "sitemap-" + strconv.Itoa(page) + ext
+		var chunk, _ = s.Page(urls, page)
+		if err := write(name, func(w io.Writer) error {
+			return WriteURLSet(w, chunk)
+		}); err != nil {
+			return nil, err
+		}
+		locations = append(locations, base+name)
+	}
+	return files, write("sitemap"+ext, func(w io.Writer) error {
+		return WriteIndex(w, locations)
+	})
+}
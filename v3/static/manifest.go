@@ -0,0 +1,127 @@
+package static
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"io/fs"
+	"path"
+	"strings"
+)
+
+// Length of the content hash in fingerprinted file names.
+var HASH_LENGTH = 12
+
+// Manifest maps file paths to fingerprinted paths,
+// which contain a hash of the file's content.
+//
+//	css/app.css -> css/app.3f2a1b9c04de.css
+type Manifest struct {
+	// Path -> fingerprinted path
+	files map[string]string
+	// Fingerprinted path -> path
+	fingerprinted map[string]string
+	// Path -> content hash
+	hashes map[string]string
+}
+
+// NewManifest hashes every file in the file system.
+//
+// Precompressed siblings (.br and .gz files next to the original) are not included,
+// they are served in place of the original file.
+func NewManifest(fsys fs.FS) (*Manifest, error) {
+	var m = &Manifest{
+		files:         make(map[string]string),
+		fingerprinted: make(map[string]string),
+		hashes:        make(map[string]string),
+	}
+	var err = fs.WalkDir(fsys, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || isCompressedSibling(fsys, p) {
+			return nil
+		}
+		hash, err := hashFile(fsys, p)
+		if err != nil {
+			return err
+		}
+		var fp = fingerprint(p, hash)
+		m.files[p] = fp
+		m.fingerprinted[fp] = p
+		m.hashes[p] = hash
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Path returns the fingerprinted path of a file.
+func (m *Manifest) Path(name string) (string, bool) {
+	var fp, ok = m.files[strings.TrimPrefix(name, "/")]
+	return fp, ok
+}
+
+// Resolve returns the original path of a fingerprinted path.
+func (m *Manifest) Resolve(fingerprinted string) (string, bool) {
+	var p, ok = m.fingerprinted[strings.TrimPrefix(fingerprinted, "/")]
+	return p, ok
+}
+
+// Hash returns the content hash of a file.
+func (m *Manifest) Hash(name string) (string, bool) {
+	var hash, ok = m.hashes[strings.TrimPrefix(name, "/")]
+	return hash, ok
+}
+
+// Files returns a copy of the path to fingerprinted path mapping.
+func (m *Manifest) Files() map[string]string {
+	var files = make(map[string]string, len(m.files))
+	for k, v := range m.files {
+		files[k] = v
+	}
+	return files
+}
+
+// MarshalJSON encodes the manifest as a JSON object of paths to fingerprinted paths.
+func (m *Manifest) MarshalJSON() ([]byte, error) {
+	return json.Marshal(m.files)
+}
+
+func hashFile(fsys fs.FS, p string) (string, error) {
+	var f, err = fsys.Open(p)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	var h = sha256.New()
+	if _, err = io.Copy(h, f); err != nil {
+		return "", err
+	}
+	var hash = hex.EncodeToString(h.Sum(nil))
+	if HASH_LENGTH > 0 && HASH_LENGTH < len(hash) {
+		hash = hash[:HASH_LENGTH]
+	}
+	return hash, nil
+}
+
+// Insert the hash before the extension of the file name.
+func fingerprint(p, hash string) string {
+	var ext = path.Ext(p)
+	return strings.TrimSuffix(p, ext) + "." + hash + ext
+}
+
+// Reports whether the file is a precompressed version of another file.
+func isCompressedSibling(fsys fs.FS, p string) bool {
+	for _, enc := range encodings {
+		if strings.HasSuffix(p, enc.ext) {
+			if _, err := fs.Stat(fsys, strings.TrimSuffix(p, enc.ext)); err == nil {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,216 @@
+// Package static serves static files, with fingerprinted URLs and precompressed variants.
+//
+//	var assets, err = static.New(os.DirFS("assets"), static.Options{URL: "/static/"})
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	r.AddGroup(assets.Route("static"))
+//
+//	// Resolve {{static "css/app.css"}} to /static/css/app.3f2a1b9c04de.css
+//	templates.STATIC_RESOLVER = assets.URL
+package static
+
+import (
+	"bytes"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Nigel2392/router/v3"
+	"github.com/Nigel2392/router/v3/request"
+	"github.com/Nigel2392/router/v3/templates"
+)
+
+// Cache-Control header for fingerprinted files.
+const CACHE_IMMUTABLE = "public, max-age=31536000, immutable"
+
+// Precompressed variants, in order of preference.
+var encodings = []struct {
+	name string
+	ext  string
+}{
+	{"br", ".br"},
+	{"gzip", ".gz"},
+}
+
+type Options struct {
+	// URL prefix the files are served under, defaults to "/static/".
+	URL string
+
+	// Max age of files requested without fingerprint.
+	// Zero means clients must revalidate every request.
+	MaxAge time.Duration
+
+	// File to serve when a file is not found, like "index.html" for single page applications.
+	// Only used for GET and HEAD requests of paths without an extension.
+	Fallback string
+
+	// Do not serve .br and .gz siblings.
+	DisablePrecompressed bool
+}
+
+// Server serves static files from a file system.
+type Server struct {
+	fs       fs.FS
+	opts     Options
+	manifest *Manifest
+}
+
+// New creates a static file server, and builds the manifest of the file system.
+func New(fsys fs.FS, opts Options) (*Server, error) {
+	if opts.URL == "" {
+		opts.URL = "/static/"
+	}
+	var manifest, err = NewManifest(fsys)
+	if err != nil {
+		return nil, err
+	}
+	return &Server{fs: fsys, opts: opts, manifest: manifest}, nil
+}
+
+// Manifest returns the manifest of the file system.
+func (s *Server) Manifest() *Manifest {
+	return s.manifest
+}
+
+// URL returns the fingerprinted URL of a file.
+//
+// Files which are not in the manifest are returned without fingerprint.
+func (s *Server) URL(name string) string {
+	if fp, ok := s.manifest.Path(name); ok {
+		name = fp
+	}
+	return templates.NicePath(false, s.opts.URL, name)
+}
+
+// Route returns a GET route which serves the files under the URL prefix.
+func (s *Server) Route(name string) router.Registrar {
+	return router.NewRoute(router.GET, templates.NicePath(false, "/", s.opts.URL, "/<<any>>"), name, s.ServeHTTP)
+}
+
+// ServeHTTP serves the file of the request's path, relative to the URL prefix.
+//
+// Directories are never listed.
+func (s *Server) ServeHTTP(r *request.Request) {
+	var name = strings.TrimPrefix(r.Request.URL.Path, "/")
+	if prefix := strings.Trim(s.opts.URL, "/"); prefix != "" {
+		name = strings.TrimPrefix(name, prefix+"/")
+	}
+	name = strings.TrimPrefix(path.Clean("/"+name), "/")
+
+	var cacheControl = "public, max-age=" + strconv.Itoa(int(s.opts.MaxAge.Seconds()))
+	if s.opts.MaxAge <= 0 {
+		cacheControl = "no-cache"
+	}
+	if original, ok := s.manifest.Resolve(name); ok {
+		name = original
+		cacheControl = CACHE_IMMUTABLE
+	}
+
+	if !s.isFile(name) {
+		if !s.useFallback(r, name) {
+			r.Error(http.StatusNotFound, http.StatusText(http.StatusNotFound))
+			return
+		}
+		name = s.opts.Fallback
+		cacheControl = "no-cache"
+	}
+
+	var header = r.Response.Header()
+	var served, encoding = name, ""
+	if !s.opts.DisablePrecompressed {
+		header.Add("Vary", "Accept-Encoding")
+		var accepted = acceptedEncodings(r.Request.Header.Get("Accept-Encoding"))
+		for _, enc := range encodings {
+			var ok, listed = accepted[enc.name]
+			if !listed {
+				ok = accepted["*"]
+			}
+			if ok && s.isFile(name+enc.ext) {
+				served, encoding = name+enc.ext, enc.name
+				break
+			}
+		}
+	}
+
+	var f, err = s.fs.Open(served)
+	if err != nil {
+		r.Error(http.StatusNotFound, http.StatusText(http.StatusNotFound))
+		return
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		r.Error(http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+		return
+	}
+	content, ok := f.(io.ReadSeeker)
+	if !ok {
+		var b, err = io.ReadAll(f)
+		if err != nil {
+			r.Error(http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+			return
+		}
+		content = bytes.NewReader(b)
+	}
+
+	// The content type of the original file, not of the compressed file.
+	if contentType := mime.TypeByExtension(path.Ext(name)); contentType != "" {
+		header.Set("Content-Type", contentType)
+	}
+	if encoding != "" {
+		header.Set("Content-Encoding", encoding)
+	}
+	if hash, ok := s.manifest.Hash(name); ok {
+		var etag = hash
+		if encoding != "" {
+			etag += "-" + encoding
+		}
+		header.Set("ETag", `"`+etag+`"`)
+	}
+	header.Set("Cache-Control", cacheControl)
+	http.ServeContent(r.Response, r.Request, name, info.ModTime(), content)
+}
+
+func (s *Server) isFile(name string) bool {
+	if name == "" || name == "." {
+		return false
+	}
+	var info, err = fs.Stat(s.fs, name)
+	return err == nil && !info.IsDir()
+}
+
+func (s *Server) useFallback(r *request.Request, name string) bool {
+	if s.opts.Fallback == "" || path.Ext(name) != "" {
+		return false
+	}
+	if r.Request.Method != http.MethodGet && r.Request.Method != http.MethodHead {
+		return false
+	}
+	return s.isFile(s.opts.Fallback)
+}
+
+// Parse an Accept-Encoding header, encodings with a quality of 0 are not accepted.
+func acceptedEncodings(header string) map[string]bool {
+	var accepted = make(map[string]bool)
+	for _, part := range strings.Split(header, ",") {
+		var name, params, _ = strings.Cut(strings.TrimSpace(part), ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		var q = 1.0
+		if k, v, ok := strings.Cut(strings.TrimSpace(params), "="); ok && strings.TrimSpace(k) == "q" {
+			if f, err := strconv.ParseFloat(strings.TrimSpace(v), 64); err == nil {
+				q = f
+			}
+		}
+		accepted[name] = q > 0
+	}
+	return accepted
+}
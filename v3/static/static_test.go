@@ -0,0 +1,197 @@
+package static
+
+import (
+	"errors"
+	"io/fs"
+	"mime"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/Nigel2392/router/v3"
+)
+
+var testFS = fstest.MapFS{
+	"css/app.css":    {Data: []byte("body { color: red; }"), ModTime: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)},
+	"js/app.js":      {Data: []byte("console.log('plain')")},
+	"js/app.js.br":   {Data: []byte("brotli")},
+	"js/app.js.gz":   {Data: []byte("gzip")},
+	"index.html":     {Data: []byte("<html>app</html>")},
+	"archive.tar.gz": {Data: []byte("archive")},
+}
+
+func newTestServer(t *testing.T, fsys fs.FS, opts Options) (*Server, *router.Router) {
+	t.Helper()
+	var s, err = New(fsys, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var r = router.NewRouter(true)
+	r.AddGroup(s.Route("static"))
+	return s, r
+}
+
+func get(r *router.Router, path string, header ...string) *httptest.ResponseRecorder {
+	var rq = httptest.NewRequest("GET", path, nil)
+	for i := 0; i+1 < len(header); i += 2 {
+		rq.Header.Set(header[i], header[i+1])
+	}
+	var w = httptest.NewRecorder()
+	r.ServeHTTP(w, rq)
+	return w
+}
+
+func TestFingerprintedURLs(t *testing.T) {
+	var s, r = newTestServer(t, testFS, Options{MaxAge: time.Minute})
+
+	var url = s.URL("css/app.css")
+	var hash, _ = s.Manifest().Hash("css/app.css")
+	if url != "/static/css/app."+hash+".css" || len(hash) != HASH_LENGTH {
+		t.Fatalf("got URL %q", url)
+	}
+	if s.URL("missing.css") != "/static/missing.css" {
+		t.Errorf("files which are not in the manifest should not be fingerprinted, got %q", s.URL("missing.css"))
+	}
+	if _, ok := s.Manifest().Files()["archive.tar.gz"]; !ok {
+		t.Error("a .gz file without an original is not a precompressed sibling")
+	}
+	if _, ok := s.Manifest().Files()["js/app.js.gz"]; ok {
+		t.Error("precompressed siblings should not be in the manifest")
+	}
+
+	var w = get(r, url)
+	if w.Code != http.StatusOK || w.Body.String() != "body { color: red; }" {
+		t.Fatalf("got status %d, body %q", w.Code, w.Body)
+	}
+	if cc := w.Header().Get("Cache-Control"); cc != CACHE_IMMUTABLE {
+		t.Errorf("got Cache-Control %q for a fingerprinted URL", cc)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != mime.TypeByExtension(".css") {
+		t.Errorf("got Content-Type %q", ct)
+	}
+	var etag = w.Header().Get("ETag")
+	if etag != `"`+hash+`"` {
+		t.Errorf("got ETag %q", etag)
+	}
+
+	if w := get(r, "/static/css/app.css"); w.Code != http.StatusOK || w.Header().Get("Cache-Control") != "public, max-age=60" {
+		t.Errorf("got status %d, Cache-Control %q without fingerprint", w.Code, w.Header().Get("Cache-Control"))
+	}
+	if w := get(r, url, "If-None-Match", etag); w.Code != http.StatusNotModified {
+		t.Errorf("got status %d with a matching ETag, want 304", w.Code)
+	}
+	for _, path := range []string{"/static/missing.css", "/static/css", "/static/../static/missing.css", "/static/css/app.000000000000.css"} {
+		if w := get(r, path); w.Code != http.StatusNotFound {
+			t.Errorf("GET %s: got status %d, want 404", path, w.Code)
+		}
+	}
+}
+
+func TestPrecompressed(t *testing.T) {
+	var _, r = newTestServer(t, testFS, Options{})
+	var tests = []struct {
+		accept   string
+		encoding string
+		body     string
+	}{
+		{"gzip, deflate, br", "br", "brotli"},
+		{"gzip", "gzip", "gzip"},
+		{"br;q=0, gzip", "gzip", "gzip"},
+		{"*", "br", "brotli"},
+		{"*, br;q=0", "gzip", "gzip"},
+		{"identity", "", "console.log('plain')"},
+		{"", "", "console.log('plain')"},
+	}
+	for _, test := range tests {
+		var w = get(r, "/static/js/app.js", "Accept-Encoding", test.accept)
+		if w.Header().Get("Content-Encoding") != test.encoding || w.Body.String() != test.body {
+			t.Errorf("Accept-Encoding %q: got encoding %q, body %q, want %q, %q", test.accept, w.Header().Get("Content-Encoding"), w.Body, test.encoding, test.body)
+		}
+		if ct := w.Header().Get("Content-Type"); ct != mime.TypeByExtension(".js") {
+			t.Errorf("Accept-Encoding %q: got Content-Type %q, want the type of the original file", test.accept, ct)
+		}
+		if w.Header().Get("Vary") != "Accept-Encoding" {
+			t.Errorf("Accept-Encoding %q: got Vary %q", test.accept, w.Header().Get("Vary"))
+		}
+		if etag := w.Header().Get("ETag"); test.encoding != "" && !strings.HasSuffix(etag, "-"+test.encoding+`"`) {
+			t.Errorf("Accept-Encoding %q: got ETag %q, want one per encoding", test.accept, etag)
+		}
+	}
+
+	var _, disabled = newTestServer(t, testFS, Options{DisablePrecompressed: true})
+	if w := get(disabled, "/static/js/app.js", "Accept-Encoding", "br"); w.Header().Get("Content-Encoding") != "" || w.Header().Get("Vary") != "" {
+		t.Errorf("got encoding %q with DisablePrecompressed", w.Header().Get("Content-Encoding"))
+	}
+}
+
+func TestRange(t *testing.T) {
+	var _, r = newTestServer(t, testFS, Options{})
+	var w = get(r, "/static/css/app.css", "Range", "bytes=0-3")
+	if w.Code != http.StatusPartialContent || w.Body.String() != "body" {
+		t.Errorf("got status %d, body %q, want 206 with the range", w.Code, w.Body)
+	}
+	if cr := w.Header().Get("Content-Range"); cr != "bytes 0-3/20" {
+		t.Errorf("got Content-Range %q", cr)
+	}
+	if w := get(r, "/static/css/app.css", "Range", "bytes=100-"); w.Code != http.StatusRequestedRangeNotSatisfiable {
+		t.Errorf("got status %d for an unsatisfiable range, want 416", w.Code)
+	}
+}
+
+func TestFallback(t *testing.T) {
+	var _, r = newTestServer(t, testFS, Options{Fallback: "index.html", MaxAge: time.Hour})
+	for _, path := range []string{"/static/dashboard", "/static/users/42"} {
+		var w = get(r, path)
+		if w.Code != http.StatusOK || w.Body.String() != "<html>app</html>" || w.Header().Get("Cache-Control") != "no-cache" {
+			t.Errorf("GET %s: got status %d, body %q, Cache-Control %q", path, w.Code, w.Body, w.Header().Get("Cache-Control"))
+		}
+	}
+	if w := get(r, "/static/missing.js"); w.Code != http.StatusNotFound {
+		t.Errorf("got status %d for a missing file with an extension, want 404", w.Code)
+	}
+
+	var _, missing = newTestServer(t, testFS, Options{Fallback: "missing.html"})
+	if w := get(missing, "/static/dashboard"); w.Code != http.StatusNotFound {
+		t.Errorf("got status %d with a missing fallback, want 404", w.Code)
+	}
+}
+
+// A file system whose files can not be stat'ed after opening.
+type brokenFS struct {
+	fstest.MapFS
+}
+
+type brokenFile struct {
+	fs.File
+}
+
+func (f brokenFile) Stat() (fs.FileInfo, error) {
+	return nil, errors.New("broken")
+}
+
+func (b brokenFS) Open(name string) (fs.File, error) {
+	var f, err = b.MapFS.Open(name)
+	if err != nil || name == "." {
+		return f, err
+	}
+	if info, err := f.Stat(); err == nil && info.IsDir() {
+		return f, nil
+	}
+	return brokenFile{f}, nil
+}
+
+func TestServerError(t *testing.T) {
+	var s = &Server{fs: brokenFS{testFS}, opts: Options{URL: "/static/"}, manifest: &Manifest{}}
+	var r = router.NewRouter(true)
+	r.AddGroup(s.Route("static"))
+	var w = get(r, "/static/index.html", "Accept-Encoding", "gzip")
+	if w.Code != http.StatusInternalServerError {
+		t.Fatalf("got status %d, want 500", w.Code)
+	}
+	if w.Header().Get("Vary") != "" || strings.Contains(w.Body.String(), "broken") {
+		t.Errorf("the response should be cleared, got headers %v, body %q", w.Header(), w.Body)
+	}
+}
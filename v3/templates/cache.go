@@ -5,24 +5,64 @@ import (
 	"sync"
 )
 
+type cacheEntry struct {
+	template *template.Template
+	// Name of the template to execute.
+	name string
+	// Files the template was parsed from.
+	files []string
+}
+
 type tCache struct {
-	templates map[string]*template.Template
+	templates map[string]cacheEntry
 	mu        sync.RWMutex
 }
 
 func newCache() *tCache {
-	return &tCache{templates: make(map[string]*template.Template)}
+	return &tCache{templates: make(map[string]cacheEntry)}
 }
 
-func (tc *tCache) Get(key string) (*template.Template, bool) {
+// Get a template, and the name of the template to execute.
+func (tc *tCache) Get(key string) (*template.Template, string, bool) {
 	tc.mu.RLock()
 	defer tc.mu.RUnlock()
-	t, ok := tc.templates[key]
-	return t, ok
+	e, ok := tc.templates[key]
+	return e.template, e.name, ok
+}
+
+// Set a template, with the name of the template to execute and the files it was parsed from.
+func (tc *tCache) Set(key string, value *template.Template, name string, files ...string) {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	tc.templates[key] = cacheEntry{template: value, name: name, files: files}
+}
+
+// Invalidate removes every template which was parsed from one of the files.
+//
+// Returns the keys of the removed templates.
+func (tc *tCache) Invalidate(files ...string) []string {
+	var changed = make(map[string]struct{}, len(files))
+	for _, f := range files {
+		changed[f] = struct{}{}
+	}
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	var removed = make([]string, 0)
+	for key, e := range tc.templates {
+		for _, f := range e.files {
+			if _, ok := changed[f]; ok {
+				delete(tc.templates, key)
+				removed = append(removed, key)
+				break
+			}
+		}
+	}
+	return removed
 }
 
-func (tc *tCache) Set(key string, value *template.Template) {
+// Clear removes every template.
+func (tc *tCache) Clear() {
 	tc.mu.Lock()
 	defer tc.mu.Unlock()
-	tc.templates[key] = value
+	tc.templates = make(map[string]cacheEntry)
 }
@@ -50,8 +50,8 @@ func View(options *Options, ext Extension) func(r *request.Request) {
 			t.AddParseTree(b.Name(), b.Tree)
 		}
 
-		t.Funcs(options.BaseManager.DEFAULT_FUNCS)
-		t.Funcs(options.ExtensionManager.DEFAULT_FUNCS)
+		t.Funcs(options.BaseManager.Funcs())
+		t.Funcs(options.ExtensionManager.Funcs())
 
 		for k, v := range tdata {
 			r.Data.Set(k, v)
@@ -0,0 +1,331 @@
+package templates
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Nigel2392/router/v3/request"
+)
+
+// URL static files are served from, used by the static template function.
+var STATIC_URL = "/static/"
+
+// Resolve the URL of a static file, used by the static template function.
+//
+// If nil, the path is joined with STATIC_URL.
+var STATIC_RESOLVER func(path string) string
+
+// Functions added to every template of a Manager,
+// unless DISABLE_BUILTIN_FUNCS is true.
+//
+// Functions in DEFAULT_FUNCS take precedence.
+//
+// Functions which need the request receive the template data as first argument:
+//
+//	{{url . "user:detail" (dict "id" .User.ID)}}
+//	{{csrf_input .}}
+//	{{range messages .}}{{.Text}}{{end}}
+var BUILTIN_FUNCS = template.FuncMap{
+	"url":        URL,
+	"static":     Static,
+	"csrf_input": CSRFInput,
+	"messages":   GetMessages,
+	"date":       Date,
+	"number":     Number,
+	"filesize":   FileSize,
+	"pluralize":  Pluralize,
+	"truncate":   Truncate,
+	"safeHTML":   SafeHTML,
+	"safeAttr":   SafeAttr,
+	"dict":       Dict,
+	"list":       List,
+	"default":    Default,
+	"json":       JSON,
+}
+
+// URL returns the URL of a named route.
+//
+// Arguments are either positional, or a single dict of named arguments.
+func URL(data *request.TemplateData, name string, args ...any) (string, error) {
+	if data == nil || data.Request == nil {
+		return "", errors.New("url: no template data")
+	}
+	return data.Request.Reverse(name, args...)
+}
+
+// Static returns the URL of a static file.
+//
+// The path is resolved with STATIC_RESOLVER if it is set,
+// which can return fingerprinted URLs.
+func Static(path string) string {
+	if STATIC_RESOLVER != nil {
+		return STATIC_RESOLVER(path)
+	}
+	return NicePath(false, STATIC_URL, path)
+}
+
+// CSRFInput returns a hidden input with the CSRF token,
+// or nothing if no token is set.
+func CSRFInput(data *request.TemplateData) template.HTML {
+	if data == nil || data.CSRFToken == nil {
+		return ""
+	}
+	return data.CSRFToken.Input()
+}
+
+// GetMessages returns the messages of the request.
+func GetMessages(data *request.TemplateData) request.Messages {
+	if data == nil {
+		return nil
+	}
+	return data.Messages
+}
+
+// Date formats a time with the given layout.
+//
+// Accepts time.Time, *time.Time and unix timestamps.
+//
+//	{{.Created | date "2006-01-02"}}
+func Date(layout string, v any) (string, error) {
+	switch t := v.(type) {
+	case time.Time:
+		return t.Format(layout), nil
+	case *time.Time:
+		if t == nil {
+			return "", nil
+		}
+		return t.Format(layout), nil
+	}
+	var i, ok = toInt64(v)
+	if !ok {
+		return "", fmt.Errorf("date: unsupported type %T", v)
+	}
+	return time.Unix(i, 0).Format(layout), nil
+}
+
+// Number formats a number with thousands separators.
+//
+// Floats are rounded to two decimals.
+//
+//	{{1234567 | number}} -> 1,234,567
+func Number(v any) (string, error) {
+	if i, ok := toInt64(v); ok {
+		var s = strconv.FormatInt(i, 10)
+		if i < 0 {
+			return "-" + groupThousands(s[1:]), nil
+		}
+		return groupThousands(s), nil
+	}
+	var f, ok = toFloat64(v)
+	if !ok {
+		return "", fmt.Errorf("number: unsupported type %T", v)
+	}
+	var s = strconv.FormatFloat(math.Abs(f), 'f', 2, 64)
+	var whole, frac, _ = strings.Cut(s, ".")
+	s = groupThousands(whole) + "." + frac
+	if f < 0 {
+		s = "-" + s
+	}
+	return s, nil
+}
+
+// FileSize formats a number of bytes in a human readable format.
+//
+//	{{1536 | filesize}} -> 1.5 KB
+func FileSize(v any) (string, error) {
+	var size, ok = toFloat64(v)
+	if !ok {
+		return "", fmt.Errorf("filesize: unsupported type %T", v)
+	}
+	var units = []string{"bytes", "KB", "MB", "GB", "TB", "PB"}
+	var i int
+	for math.Abs(size) >= 1024 && i < len(units)-1 {
+		size /= 1024
+		i++
+	}
+	if i == 0 {
+		if size == 1 {
+			return "1 byte", nil
+		}
+		return strconv.FormatFloat(size, 'f', -1, 64) + " bytes", nil
+	}
+	var s = strconv.FormatFloat(size, 'f', 1, 64)
+	return strings.TrimSuffix(s, ".0") + " " + units[i], nil
+}
+
+// Pluralize returns a plural suffix if the count is not 1.
+//
+// The suffixes can be given as "singular,plural", or as a single plural suffix.
+// The count is the last argument.
+//
+//	{{.Count | pluralize}}          -> "" or "s"
+//	{{.Count | pluralize "es"}}     -> "" or "es"
+//	{{.Count | pluralize "y,ies"}}  -> "y" or "ies"
+func Pluralize(args ...any) (string, error) {
+	var singular, plural = "", "s"
+	switch len(args) {
+	case 1:
+	case 2:
+		var suffixes, ok = args[0].(string)
+		if !ok {
+			return "", fmt.Errorf("pluralize: suffixes must be a string, not %T", args[0])
+		}
+		if s, p, ok := strings.Cut(suffixes, ","); ok {
+			singular, plural = s, p
+		} else {
+			plural = suffixes
+		}
+	default:
+		return "", errors.New("pluralize: expected a count and optional suffixes")
+	}
+	var count = args[len(args)-1]
+	if rv := reflect.ValueOf(count); rv.Kind() == reflect.Slice || rv.Kind() == reflect.Map || rv.Kind() == reflect.Array {
+		count = rv.Len()
+	}
+	var f, ok = toFloat64(count)
+	if !ok {
+		return "", fmt.Errorf("pluralize: unsupported type %T", count)
+	}
+	if f == 1 {
+		return singular, nil
+	}
+	return plural, nil
+}
+
+// Truncate shortens a string to at most length characters, ending with an ellipsis.
+//
+//	{{.Description | truncate 100}}
+func Truncate(length int, s string) string {
+	if length <= 0 {
+		return ""
+	}
+	if utf8.RuneCountInString(s) <= length {
+		return s
+	}
+	var runes = []rune(s)
+	return strings.TrimSpace(string(runes[:length-1])) + "…"
+}
+
+// SafeHTML marks a string as safe HTML, it will not be escaped.
+//
+// Only use this for trusted content.
+func SafeHTML(s string) template.HTML {
+	return template.HTML(s)
+}
+
+// SafeAttr marks a string as a safe HTML attribute, like `class="active"`.
+//
+// Only use this for trusted content.
+func SafeAttr(s string) template.HTMLAttr {
+	return template.HTMLAttr(s)
+}
+
+// Dict creates a map from key value pairs.
+//
+//	{{template "partials/user.tmpl" dict "User" .User "Admin" true}}
+func Dict(pairs ...any) (map[string]any, error) {
+	if len(pairs)%2 != 0 {
+		return nil, errors.New("dict: expected key value pairs")
+	}
+	var m = make(map[string]any, len(pairs)/2)
+	for i := 0; i < len(pairs); i += 2 {
+		var key, ok = pairs[i].(string)
+		if !ok {
+			return nil, fmt.Errorf("dict: keys must be strings, not %T", pairs[i])
+		}
+		m[key] = pairs[i+1]
+	}
+	return m, nil
+}
+
+// List creates a slice from its arguments.
+func List(values ...any) []any {
+	return values
+}
+
+// Default returns the default value if the value is empty.
+//
+//	{{.Name | default "Anonymous"}}
+func Default(def any, v any) any {
+	if v == nil {
+		return def
+	}
+	var rv = reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Map, reflect.Array, reflect.String:
+		if rv.Len() == 0 {
+			return def
+		}
+	default:
+		if rv.IsZero() {
+			return def
+		}
+	}
+	return v
+}
+
+// JSON encodes a value as JSON, for use in scripts.
+//
+//	<script>var user = {{json .User}};</script>
+func JSON(v any) (template.JS, error) {
+	var b, err = json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return template.JS(b), nil
+}
+
+func toInt64(v any) (int64, bool) {
+	var rv = reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int(), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if rv.Uint() > math.MaxInt64 {
+			return 0, false
+		}
+		return int64(rv.Uint()), true
+	}
+	return 0, false
+}
+
+func toFloat64(v any) (float64, bool) {
+	if i, ok := toInt64(v); ok {
+		return float64(i), true
+	}
+	var rv = reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), true
+	case reflect.Uint64:
+		return float64(rv.Uint()), true
+	}
+	return 0, false
+}
+
+// Insert a comma every three digits, from the right.
+func groupThousands(digits string) string {
+	if len(digits) <= 3 {
+		return digits
+	}
+	var b strings.Builder
+	var first = len(digits) % 3
+	if first > 0 {
+		b.WriteString(digits[:first])
+	}
+	for i := first; i < len(digits); i += 3 {
+		if b.Len() > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(digits[i : i+3])
+	}
+	return b.String()
+}
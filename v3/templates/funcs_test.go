@@ -0,0 +1,170 @@
+package templates
+
+import (
+	"errors"
+	"fmt"
+	"html/template"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Nigel2392/router/v3/request"
+	"github.com/Nigel2392/router/v3/request/writer"
+)
+
+func TestBuiltinFuncs(t *testing.T) {
+	var date = time.Date(2023, 4, 5, 6, 7, 8, 0, time.UTC)
+	var tests = []struct {
+		name    string
+		call    func() (any, error)
+		want    any
+		wantErr bool
+	}{
+		{"static", func() (any, error) { return Static("css/app.css"), nil }, "/static/css/app.css", false},
+		{"date", func() (any, error) { return Date("2006-01-02", date) }, "2023-04-05", false},
+		{"date pointer", func() (any, error) { return Date("2006-01-02", &date) }, "2023-04-05", false},
+		{"date nil pointer", func() (any, error) { return Date("2006-01-02", (*time.Time)(nil)) }, "", false},
+		{"date unix", func() (any, error) { return Date("2006", date.Unix()) }, date.Local().Format("2006"), false},
+		{"date unsupported", func() (any, error) { return Date("2006", "today") }, "", true},
+		{"number int", func() (any, error) { return Number(1234567) }, "1,234,567", false},
+		{"number negative", func() (any, error) { return Number(-1234) }, "-1,234", false},
+		{"number small", func() (any, error) { return Number(uint8(12)) }, "12", false},
+		{"number float", func() (any, error) { return Number(-1234.567) }, "-1,234.57", false},
+		{"number unsupported", func() (any, error) { return Number("1") }, "", true},
+		{"filesize byte", func() (any, error) { return FileSize(1) }, "1 byte", false},
+		{"filesize bytes", func() (any, error) { return FileSize(512) }, "512 bytes", false},
+		{"filesize KB", func() (any, error) { return FileSize(1536) }, "1.5 KB", false},
+		{"filesize MB", func() (any, error) { return FileSize(1 << 20) }, "1 MB", false},
+		{"filesize unsupported", func() (any, error) { return FileSize("1") }, "", true},
+		{"pluralize one", func() (any, error) { return Pluralize(1) }, "", false},
+		{"pluralize many", func() (any, error) { return Pluralize(2) }, "s", false},
+		{"pluralize suffix", func() (any, error) { return Pluralize("es", 0) }, "es", false},
+		{"pluralize pair", func() (any, error) { return Pluralize("y,ies", 1) }, "y", false},
+		{"pluralize slice", func() (any, error) { return Pluralize("y,ies", []int{1, 2}) }, "ies", false},
+		{"pluralize bad suffix", func() (any, error) { return Pluralize(1, 1) }, "", true},
+		{"pluralize no args", func() (any, error) { return Pluralize() }, "", true},
+		{"truncate short", func() (any, error) { return Truncate(10, "hello"), nil }, "hello", false},
+		{"truncate long", func() (any, error) { return Truncate(6, "hello world"), nil }, "hello…", false},
+		{"truncate zero", func() (any, error) { return Truncate(0, "hello"), nil }, "", false},
+		{"safeHTML", func() (any, error) { return SafeHTML("<b>"), nil }, template.HTML("<b>"), false},
+		{"safeAttr", func() (any, error) { return SafeAttr(`class="a"`), nil }, template.HTMLAttr(`class="a"`), false},
+		{"dict", func() (any, error) { return Dict("a", 1, "b", "2") }, map[string]any{"a": 1, "b": "2"}, false},
+		{"dict odd", func() (any, error) { return Dict("a") }, nil, true},
+		{"dict key", func() (any, error) { return Dict(1, 1) }, nil, true},
+		{"list", func() (any, error) { return List(1, "a"), nil }, []any{1, "a"}, false},
+		{"default nil", func() (any, error) { return Default("x", nil), nil }, "x", false},
+		{"default empty", func() (any, error) { return Default("x", ""), nil }, "x", false},
+		{"default zero", func() (any, error) { return Default(1, 0), nil }, 1, false},
+		{"default set", func() (any, error) { return Default("x", "y"), nil }, "y", false},
+		{"json", func() (any, error) { return JSON(map[string]int{"a": 1}) }, template.JS(`{"a":1}`), false},
+		{"json unsupported", func() (any, error) { return JSON(func() {}) }, template.JS(""), true},
+		{"csrf_input nil", func() (any, error) { return CSRFInput(nil), nil }, template.HTML(""), false},
+		{"messages nil", func() (any, error) { return GetMessages(nil), nil }, request.Messages(nil), false},
+	}
+	for _, test := range tests {
+		var got, err = test.call()
+		if (err != nil) != test.wantErr {
+			t.Errorf("%s: error = %v, want error %v", test.name, err, test.wantErr)
+			continue
+		}
+		if test.wantErr {
+			continue
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: got %#v, want %#v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestBuiltinFuncsCovered(t *testing.T) {
+	var tested = []string{
+		"url", "static", "csrf_input", "messages", "date", "number", "filesize",
+		"pluralize", "truncate", "safeHTML", "safeAttr", "dict", "list", "default", "json",
+	}
+	if len(tested) != len(BUILTIN_FUNCS) {
+		t.Fatalf("BUILTIN_FUNCS has %d functions, %d are tested", len(BUILTIN_FUNCS), len(tested))
+	}
+	for _, name := range tested {
+		if _, ok := BUILTIN_FUNCS[name]; !ok {
+			t.Errorf("BUILTIN_FUNCS has no function %q", name)
+		}
+	}
+}
+
+func TestStaticResolver(t *testing.T) {
+	STATIC_RESOLVER = func(path string) string {
+		return "/assets/" + path + "?v=1"
+	}
+	defer func() { STATIC_RESOLVER = nil }()
+	if got := Static("app.css"); got != "/assets/app.css?v=1" {
+		t.Errorf("got %q, want %q", got, "/assets/app.css?v=1")
+	}
+}
+
+func TestRequestFuncs(t *testing.T) {
+	var rq = request.NewRequest(writer.NewClearable(httptest.NewRecorder()), httptest.NewRequest("GET", "/", nil), nil)
+	// The router sets Reverse, which checks the arguments.
+	rq.Reverse = func(name string, args ...any) (string, error) {
+		if name != "user:detail" || len(args) != 1 {
+			return "", errors.New("no such route")
+		}
+		var id = args[0]
+		if named, ok := id.(map[string]any); ok {
+			id = named["id"]
+		}
+		if _, ok := id.(int); !ok {
+			return "", errors.New("invalid argument")
+		}
+		return fmt.Sprintf("/users/%d", id), nil
+	}
+
+	rq.Data.CSRFToken = request.NewCSRFToken("token")
+	rq.Data.AddMessage("info", "hello")
+
+	var tests = []struct {
+		name    string
+		args    []any
+		want    string
+		wantErr bool
+	}{
+		{"user:detail", []any{5}, "/users/5", false},
+		{"user:detail", []any{map[string]any{"id": 5}}, "/users/5", false},
+		{"user:detail", []any{map[string]any{"pk": 5}}, "", true},
+		{"user:detail", []any{"abc"}, "", true},
+		{"user:detail", nil, "", true},
+		{"missing", nil, "", true},
+	}
+	for _, test := range tests {
+		var got, err = URL(rq.Data, test.name, test.args...)
+		if (err != nil) != test.wantErr {
+			t.Errorf("url %q %v: error = %v, want error %v", test.name, test.args, err, test.wantErr)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("url %q %v: got %q, want %q", test.name, test.args, got, test.want)
+		}
+	}
+	if _, err := URL(nil, "user:detail", 5); err == nil {
+		t.Error("url without template data should fail")
+	}
+
+	if got := CSRFInput(rq.Data); !strings.Contains(string(got), `value="token"`) {
+		t.Errorf("csrf_input: got %q", got)
+	}
+	if got := GetMessages(rq.Data); len(got) != 1 || got[0].Text != "hello" {
+		t.Errorf("messages: got %v", got)
+	}
+
+	var tmpl = template.Must(template.New("t").Funcs(BUILTIN_FUNCS).Parse(
+		`{{url . "user:detail" (dict "id" 7)}} {{.Request.Reverse "user:detail" 8}}`,
+	))
+	var b strings.Builder
+	if err := tmpl.Execute(&b, rq.Data); err != nil {
+		t.Fatal(err)
+	}
+	if b.String() != "/users/7 /users/8" {
+		t.Errorf("template: got %q", b.String())
+	}
+}
@@ -0,0 +1,159 @@
+package templates
+
+import (
+	"errors"
+	"html/template"
+	"io/fs"
+	"regexp"
+	"strings"
+)
+
+// Matches the extends and include directives, which are written as template comments:
+//
+//	{{/* extends "layouts/admin.tmpl" */}}
+//	{{/* include "partials/nav.tmpl" */}}
+var directiveRegex = regexp.MustCompile(`\{\{-?\s*/\*\s*(extends|include)\s+"([^"]+)"\s*\*/\s*-?\}\}`)
+
+// NotFoundError is returned when a template does not exist in any of the TEMPLATE_DIRS.
+type NotFoundError struct {
+	// Name of the template.
+	Name string
+
+	// Every path which was searched, in order.
+	Searched []string
+}
+
+func (e *NotFoundError) Error() string {
+	return "template not found: " + e.Name + " (searched: " + strings.Join(e.Searched, ", ") + ")"
+}
+
+// A template file, with its directives.
+type templateFile struct {
+	// Name of the template, relative to the template directories.
+	name string
+	// Path of the file in TEMPLATEFS.
+	path    string
+	content string
+	// Name of the template this template extends, if any.
+	extends  string
+	includes []string
+}
+
+// Find a template in TEMPLATE_DIRS.
+//
+// Directories are searched in order, so templates in earlier directories
+// override templates with the same name in later directories.
+// The root of TEMPLATEFS is searched last.
+func (tm *Manager) resolve(templateName string) (string, error) {
+	var searched = make([]string, 0, len(tm.TEMPLATE_DIRS)+1)
+	for _, directory := range tm.TEMPLATE_DIRS {
+		searched = append(searched, NicePath(false, directory, templateName))
+	}
+	searched = append(searched, NicePath(false, templateName))
+	for _, p := range searched {
+		if info, err := fs.Stat(tm.TEMPLATEFS, p); err == nil && !info.IsDir() {
+			return p, nil
+		}
+	}
+	return "", &NotFoundError{Name: templateName, Searched: searched}
+}
+
+// Find and read a template, and parse its directives.
+func (tm *Manager) load(templateName string) (*templateFile, error) {
+	var p, err = tm.resolve(templateName)
+	if err != nil {
+		return nil, err
+	}
+	content, err := fs.ReadFile(tm.TEMPLATEFS, p)
+	if err != nil {
+		return nil, errors.New("Error reading template: " + p + " (" + err.Error() + ")")
+	}
+	var file = &templateFile{name: templateName, path: p, content: string(content)}
+	for _, match := range directiveRegex.FindAllStringSubmatch(file.content, -1) {
+		switch match[1] {
+		case "extends":
+			if file.extends != "" {
+				return nil, errors.New("Error parsing template: " + p + " (multiple extends directives)")
+			}
+			file.extends = match[2]
+		case "include":
+			file.includes = append(file.includes, match[2])
+		}
+	}
+	return file, nil
+}
+
+// Parse a template which uses extends or include directives.
+//
+// The inheritance chain is parsed from the root template down to the given template,
+// so blocks defined in a child override the blocks of its parents.
+// The root template is executed.
+//
+// Included templates are parsed under the name used in the directive,
+// so they can be rendered with {{template "partials/nav.tmpl" .}}.
+func (tm *Manager) parseInherited(file *templateFile, base_templates []string) (*template.Template, string, []string, error) {
+	var chain = []*templateFile{file}
+	var seen = map[string]bool{file.name: true}
+	for current := file; current.extends != ""; {
+		if seen[current.extends] {
+			return nil, "", nil, errors.New("Error parsing template: " + file.path + " (inheritance cycle at " + current.extends + ")")
+		}
+		seen[current.extends] = true
+		var parent, err = tm.load(current.extends)
+		if err != nil {
+			return nil, "", nil, err
+		}
+		chain = append(chain, parent)
+		current = parent
+	}
+
+	// Collect includes of the whole chain, and of the included templates themselves.
+	var includes = make([]*templateFile, 0)
+	var included = make(map[string]bool)
+	var queue = make([]string, 0)
+	for _, f := range chain {
+		queue = append(queue, f.includes...)
+	}
+	for len(queue) > 0 {
+		var name = queue[0]
+		queue = queue[1:]
+		if included[name] || seen[name] {
+			continue
+		}
+		included[name] = true
+		var inc, err = tm.load(name)
+		if err != nil {
+			return nil, "", nil, err
+		}
+		includes = append(includes, inc)
+		queue = append(queue, inc.includes...)
+	}
+
+	var root = chain[len(chain)-1]
+	var t = template.New(root.name)
+	t.Funcs(tm.Funcs())
+	var files = append(make([]string, 0, len(base_templates)+len(chain)+len(includes)), base_templates...)
+	if len(base_templates) > 0 {
+		var err error
+		if t, err = t.ParseFS(tm.TEMPLATEFS, base_templates...); err != nil {
+			return nil, "", nil, errors.New("Error parsing template: " + file.path + " (" + err.Error() + ")")
+		}
+	}
+
+	// Includes first, so the chain can override their blocks.
+	var ordered = append(make([]*templateFile, 0, len(chain)+len(includes)), includes...)
+	for i := len(chain) - 1; i >= 0; i-- {
+		ordered = append(ordered, chain[i])
+	}
+	for _, f := range ordered {
+		var tmpl = t
+		if f != root {
+			tmpl = t.New(f.name)
+		}
+		if _, err := tmpl.Parse(f.content); err != nil {
+			return nil, "", nil, errors.New("Error parsing template: " + f.path + " (" + err.Error() + ")")
+		}
+		files = append(files, f.path)
+	}
+	return t, root.name, files, nil
+}
@@ -4,7 +4,9 @@ import (
 	"errors"
 	"html/template"
 	"io/fs"
+	"path"
 	"strings"
+	"time"
 )
 
 // Template manager
@@ -21,8 +23,28 @@ type Manager struct {
 	TEMPLATE_DIRS      []string
 	// Functions to add to templates
 	DEFAULT_FUNCS template.FuncMap
+	// Do not add BUILTIN_FUNCS to templates
+	DISABLE_BUILTIN_FUNCS bool
 	// Template file system
 	TEMPLATEFS fs.FS
+
+	// Development mode.
+	//
+	// Watch TEMPLATEFS for changes, and only reparse the changed templates
+	// and the templates which depend on them.
+	// Templates are cached, even if USE_TEMPLATE_CACHE is false.
+	HOT_RELOAD bool
+	// How often TEMPLATEFS is checked for changes, defaults to one second.
+	HOT_RELOAD_INTERVAL time.Duration
+
+	// Production mode.
+	//
+	// Parse every template with one of the BASE_TEMPLATE_SUFFIXES in TEMPLATE_DIRS
+	// when calling Init, instead of on the first request.
+	// Templates are cached, even if USE_TEMPLATE_CACHE is false.
+	PRECOMPILE bool
+
+	watcher *watcher
 }
 
 func (tm *Manager) cache() *tCache {
@@ -32,81 +54,221 @@ func (tm *Manager) cache() *tCache {
 	return tm.tCache
 }
 
+// Funcs returns the functions added to templates;
+// BUILTIN_FUNCS, overridden by DEFAULT_FUNCS.
+func (tm *Manager) Funcs() template.FuncMap {
+	if tm.DISABLE_BUILTIN_FUNCS {
+		return tm.DEFAULT_FUNCS
+	}
+	var funcs = make(template.FuncMap, len(BUILTIN_FUNCS)+len(tm.DEFAULT_FUNCS))
+	for k, v := range BUILTIN_FUNCS {
+		funcs[k] = v
+	}
+	for k, v := range tm.DEFAULT_FUNCS {
+		funcs[k] = v
+	}
+	return funcs
+}
+
+// Reports whether parsed templates should be kept.
+func (tm *Manager) useCache() bool {
+	return tm.USE_TEMPLATE_CACHE || tm.HOT_RELOAD || tm.PRECOMPILE
+}
+
 // Initialize the template manager
+//
+// It panics if InitE returns an error, use InitE with PRECOMPILE or HOT_RELOAD.
 func (tm *Manager) Init() {
+	if err := tm.InitE(); err != nil {
+		panic(err)
+	}
+}
+
+// InitE initializes the template manager, and returns any error.
+//
+// If PRECOMPILE is true, every template is parsed,
+// and all parse errors are returned at once.
+//
+// If HOT_RELOAD is true, TEMPLATEFS is watched for changes until Close is called.
+func (tm *Manager) InitE() error {
+	tm.Close()
 	tm.tCache = newCache()
+	if tm.PRECOMPILE {
+		if err := tm.Precompile(); err != nil {
+			return err
+		}
+	}
+	if tm.HOT_RELOAD {
+		var w, err = newWatcher(tm)
+		if err != nil {
+			return err
+		}
+		tm.watcher = w
+		go w.run()
+	}
+	return nil
+}
+
+// Close stops watching TEMPLATEFS for changes.
+func (tm *Manager) Close() {
+	if tm.watcher != nil {
+		tm.watcher.stop()
+		tm.watcher = nil
+	}
 }
 
 // Get a template
 func (tm *Manager) Get(templateName string) (*template.Template, string, error) {
 	// Check if template is cached
-	var t *template.Template
-	var ok bool
-	if t, ok = tm.cache().Get(templateName); !ok || !tm.USE_TEMPLATE_CACHE {
-		// If not, cache it
-		var base_template_dirs = tm.BASE_TEMPLATE_DIRS
-		var directories = tm.TEMPLATE_DIRS
-		var extensions = tm.BASE_TEMPLATE_SUFFIXES
-
-		// Search fs for all base templates, in every base directory
-		var base_templates = make([]string, 0)
-		for _, base_template_dir := range base_template_dirs {
-			// Read all files in base template directory
-			files, err := fs.ReadDir(tm.TEMPLATEFS, base_template_dir)
+	if tm.useCache() {
+		if t, name, ok := tm.cache().Get(templateName); ok {
+			return t, name, nil
+		}
+	}
+
+	var t, name, files, err = tm.parse(templateName)
+	if err != nil {
+		return nil, "", err
+	}
+	if tm.useCache() {
+		tm.cache().Set(templateName, t, name, files...)
+	}
+
+	// Render template
+	return t, name, nil
+}
+
+// Precompile parses every template with one of the BASE_TEMPLATE_SUFFIXES in TEMPLATE_DIRS,
+// or in the root of TEMPLATEFS if no directories are set, and caches it.
+//
+// Base templates are not parsed on their own.
+// All errors are returned at once.
+func (tm *Manager) Precompile() error {
+	var directories = make([]string, 0, len(tm.TEMPLATE_DIRS))
+	for _, directory := range tm.TEMPLATE_DIRS {
+		directories = append(directories, cleanDir(directory))
+	}
+	if len(directories) == 0 {
+		directories = []string{"."}
+	}
+	var errs = make([]error, 0)
+	for _, directory := range directories {
+		var err = fs.WalkDir(tm.TEMPLATEFS, directory, func(p string, d fs.DirEntry, err error) error {
 			if err != nil {
-				return nil, "", errors.New("Error reading base template directory: " + base_template_dir + " (" + err.Error() + ")")
+				errs = append(errs, err)
+				return nil
 			}
-			// Add all files to base templates
-			for _, file := range files {
-				var name = file.Name()
-				// Check if file is a template
-				for _, extension := range extensions {
-					if name[len(name)-len(extension):] == extension {
-						base_templates = append(base_templates, base_template_dir+"/"+file.Name())
-					}
+			if d.IsDir() {
+				if tm.isBaseDir(p) {
+					return fs.SkipDir
 				}
+				return nil
 			}
-		}
-		var template_name string
-		// Search fs for all templates, in every directory
-		if len(directories) > 0 {
-			for _, directory := range directories {
-				// Check if file exists
-				var dirName = NicePath(false, directory, templateName)
-				var _, err = fs.Stat(tm.TEMPLATEFS, dirName)
-				if err == nil {
-					template_name = dirName
-					break
-				}
+			if !tm.hasTemplateSuffix(p) {
+				return nil
 			}
+			var templateName = strings.TrimPrefix(strings.TrimPrefix(p, directory), "/")
+			if directory == "." {
+				templateName = p
+			}
+			// Templates found in an earlier directory take precedence, like in Get.
+			if _, _, ok := tm.cache().Get(templateName); ok {
+				return nil
+			}
+			t, name, files, err := tm.parse(templateName)
+			if err != nil {
+				errs = append(errs, err)
+				return nil
+			}
+			tm.cache().Set(templateName, t, name, files...)
+			return nil
+		})
+		if err != nil {
+			errs = append(errs, err)
 		}
-		if template_name == "" {
-			template_name = NicePath(false, templateName)
-		}
-		var err error
-		var t = template.New(template_name)
-		t.Funcs(tm.DEFAULT_FUNCS)
-		t, err = t.ParseFS(tm.TEMPLATEFS, append(base_templates, template_name)...)
+	}
+	return errors.Join(errs...)
+}
+
+// Parse a template, together with all base templates.
+//
+// Returns the template, the name of the template to execute and the paths of all files parsed.
+func (tm *Manager) parse(templateName string) (*template.Template, string, []string, error) {
+	var base_templates, err = tm.baseTemplates()
+	if err != nil {
+		return nil, "", nil, err
+	}
+	file, err := tm.load(templateName)
+	if err != nil {
+		return nil, "", nil, err
+	}
+	if file.extends != "" || len(file.includes) > 0 {
+		return tm.parseInherited(file, base_templates)
+	}
+	var template_name = file.path
+	var files = append(base_templates, template_name)
+	var t = template.New(template_name)
+	t.Funcs(tm.Funcs())
+	t, err = t.ParseFS(tm.TEMPLATEFS, files...)
+	if err != nil {
+		return nil, "", nil, errors.New("Error parsing template: " + template_name + " (" + err.Error() + ")")
+	}
+	return t, FilenameFromPath(template_name), files, nil
+}
+
+// Returns the paths of all base templates, in every base directory.
+func (tm *Manager) baseTemplates() ([]string, error) {
+	var base_templates = make([]string, 0)
+	for _, base_template_dir := range tm.BASE_TEMPLATE_DIRS {
+		base_template_dir = cleanDir(base_template_dir)
+		// Read all files in base template directory
+		files, err := fs.ReadDir(tm.TEMPLATEFS, base_template_dir)
 		if err != nil {
-			return nil, "", errors.New("Error parsing template: " + template_name + " (" + err.Error() + ")")
+			return nil, errors.New("Error reading base template directory: " + base_template_dir + " (" + err.Error() + ")")
+		}
+		// Add all files to base templates
+		for _, file := range files {
+			if !file.IsDir() && tm.hasTemplateSuffix(file.Name()) {
+				base_templates = append(base_templates, path.Join(base_template_dir, file.Name()))
+			}
+		}
+	}
+	return base_templates, nil
+}
+
+func (tm *Manager) hasTemplateSuffix(name string) bool {
+	for _, extension := range tm.BASE_TEMPLATE_SUFFIXES {
+		if strings.HasSuffix(name, extension) {
+			return true
 		}
-		tm.cache().Set(templateName, t)
+	}
+	return false
+}
 
-		// Render template
-		return t, FilenameFromPath(template_name), nil
+// Returns the directory as a path in TEMPLATEFS, like "pages" for "/pages/" or "pages\\".
+func cleanDir(dir string) string {
+	dir = strings.Trim(path.Clean(strings.Replace(dir, "\\", "/", -1)), "/")
+	if dir == "" {
+		return "."
 	}
-	var name = FilenameFromPath(templateName)
-	if t == nil {
-		var err = errors.New("template not found")
-		return nil, "", err
+	return dir
+}
+
+// Reports whether the path is, or is inside of, a base template directory.
+func (tm *Manager) isBaseDir(p string) bool {
+	for _, base_template_dir := range tm.BASE_TEMPLATE_DIRS {
+		var dir = cleanDir(base_template_dir)
+		if p == dir || strings.HasPrefix(p, dir+"/") {
+			return true
+		}
 	}
-	return t, name, nil
+	return false
 }
 
 // Render a template from a string
 func (tm *Manager) GetFromString(templateString string, templateName string) (*template.Template, error) {
 	var t = template.New(templateName)
-	t.Funcs(tm.DEFAULT_FUNCS)
+	t.Funcs(tm.Funcs())
 	var err error
 	t, err = t.Parse(templateString)
 	if err != nil {
@@ -117,31 +279,13 @@ func (tm *Manager) GetFromString(templateString string, templateName string) (*t
 
 // Get base templates
 func (tm *Manager) GetBases(funcMap template.FuncMap) (*template.Template, error) {
-	var base_template_dirs = tm.BASE_TEMPLATE_DIRS
-	var extensions = tm.BASE_TEMPLATE_SUFFIXES
-
-	// Search fs for all base templates, in every base directory
-	var base_templates = make([]string, 0)
-	for _, base_template_dir := range base_template_dirs {
-		// Read all files in base template directory
-		files, err := fs.ReadDir(tm.TEMPLATEFS, base_template_dir)
-		if err != nil {
-			return nil, errors.New("Error reading base template directory: " + base_template_dir + " (" + err.Error() + ")")
-		}
-		// Add all files to base templates
-		for _, file := range files {
-			var name = file.Name()
-			// Check if file is a template
-			for _, extension := range extensions {
-				if name[len(name)-len(extension):] == extension {
-					base_templates = append(base_templates, base_template_dir+"/"+file.Name())
-				}
-			}
-		}
+	var base_templates, err = tm.baseTemplates()
+	if err != nil {
+		return nil, err
 	}
 	var t = template.New("base")
 	var newFuncMap = make(template.FuncMap)
-	for k, v := range tm.DEFAULT_FUNCS {
+	for k, v := range tm.Funcs() {
 		newFuncMap[k] = v
 	}
 	for k, v := range funcMap {
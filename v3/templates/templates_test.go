@@ -0,0 +1,92 @@
+package templates
+
+import (
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestPrecompileCleansDirs(t *testing.T) {
+	var tm = &Manager{
+		TEMPLATEFS: fstest.MapFS{
+			"base/base.tmpl":   {Data: []byte(`{{define "base"}}base{{end}}`)},
+			"pages/index.tmpl": {Data: []byte(`{{template "base"}} index`)},
+		},
+		BASE_TEMPLATE_SUFFIXES: []string{".tmpl"},
+		BASE_TEMPLATE_DIRS:     []string{"base/"},
+		TEMPLATE_DIRS:          []string{"pages/"},
+		PRECOMPILE:             true,
+	}
+	if err := tm.InitE(); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, ok := tm.cache().Get("index.tmpl"); !ok {
+		t.Fatal("index.tmpl was not precompiled")
+	}
+	if _, _, ok := tm.cache().Get("/index.tmpl"); ok {
+		t.Fatal("template was cached with a leading slash")
+	}
+}
+
+func TestWatcherClearsCacheOnNewFile(t *testing.T) {
+	var fsys = fstest.MapFS{
+		"a/page.tmpl": {Data: []byte(`a`)},
+	}
+	var tm = &Manager{
+		TEMPLATEFS:             fsys,
+		BASE_TEMPLATE_SUFFIXES: []string{".tmpl"},
+		TEMPLATE_DIRS:          []string{"b", "a"},
+		USE_TEMPLATE_CACHE:     true,
+	}
+	var w, err = newWatcher(tm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tm.tCache = newCache()
+	if _, _, err := tm.Get("page.tmpl"); err != nil {
+		t.Fatal(err)
+	}
+
+	// A template in an earlier directory now takes precedence.
+	fsys["b/page.tmpl"] = &fstest.MapFile{Data: []byte(`b`)}
+	w.check()
+	if _, _, ok := tm.cache().Get("page.tmpl"); ok {
+		t.Fatal("cache was not cleared after a template was added")
+	}
+}
+
+func TestGetWithoutCache(t *testing.T) {
+	var tm = &Manager{
+		TEMPLATEFS:             fstest.MapFS{"page.tmpl": {Data: []byte(`page`)}},
+		BASE_TEMPLATE_SUFFIXES: []string{".tmpl"},
+	}
+	if _, _, err := tm.Get("page.tmpl"); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, ok := tm.cache().Get("page.tmpl"); ok {
+		t.Fatal("template was cached while caching is disabled")
+	}
+}
+
+func TestCloseWaitsForWatcher(t *testing.T) {
+	var tm = &Manager{
+		TEMPLATEFS:             fstest.MapFS{"page.tmpl": {Data: []byte(`page`)}},
+		BASE_TEMPLATE_SUFFIXES: []string{".tmpl"},
+		HOT_RELOAD:             true,
+		HOT_RELOAD_INTERVAL:    time.Microsecond,
+	}
+	// Run with -race; InitE replaces the cache the previous watcher used.
+	for i := 0; i < 20; i++ {
+		if err := tm.InitE(); err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(time.Millisecond)
+	}
+	var w = tm.watcher
+	tm.Close()
+	select {
+	case <-w.stopped:
+	default:
+		t.Fatal("Close returned before the watcher stopped")
+	}
+}
@@ -0,0 +1,123 @@
+package templates
+
+import (
+	"io/fs"
+	"path"
+	"time"
+)
+
+// State of a file, used to detect changes.
+type fileState struct {
+	modTime time.Time
+	size    int64
+}
+
+// Watches TEMPLATEFS for changes, by polling it.
+//
+// Polling works for every fs.FS which reports modification times, like os.DirFS.
+// File systems which do not, like embed.FS, never change.
+type watcher struct {
+	tm       *Manager
+	files    map[string]fileState
+	interval time.Duration
+	done     chan struct{}
+	stopped  chan struct{}
+}
+
+func newWatcher(tm *Manager) (*watcher, error) {
+	var w = &watcher{
+		tm:       tm,
+		interval: tm.HOT_RELOAD_INTERVAL,
+		done:     make(chan struct{}),
+		stopped:  make(chan struct{}),
+	}
+	if w.interval <= 0 {
+		w.interval = time.Second
+	}
+	var files, err = w.scan()
+	if err != nil {
+		return nil, err
+	}
+	w.files = files
+	return w, nil
+}
+
+func (w *watcher) run() {
+	defer close(w.stopped)
+	var ticker = time.NewTicker(w.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-w.done:
+			return
+		case <-ticker.C:
+			w.check()
+		}
+	}
+}
+
+// Stop the watcher, and wait until it no longer touches the cache.
+func (w *watcher) stop() {
+	close(w.done)
+	<-w.stopped
+}
+
+// Check for changes, and invalidate the templates which depend on the changed files.
+func (w *watcher) check() {
+	var files, err = w.scan()
+	if err != nil {
+		return
+	}
+	var changed = make([]string, 0)
+	var added, removed bool
+	for p, state := range files {
+		if old, ok := w.files[p]; !ok || old != state {
+			changed = append(changed, p)
+			added = added || !ok
+		}
+	}
+	for p := range w.files {
+		if _, ok := files[p]; !ok {
+			changed = append(changed, p)
+			removed = true
+		}
+	}
+	w.files = files
+	if len(changed) == 0 {
+		return
+	}
+	// Templates are looked up in every directory of the search path,
+	// a new or removed file can change which file any cached template, or template it includes, resolves to.
+	if added || removed {
+		w.tm.cache().Clear()
+		return
+	}
+	for _, p := range changed {
+		// Every template depends on the base templates,
+		// and added or removed base templates change the set of files parsed.
+		if w.tm.isBaseDir(path.Dir(p)) {
+			w.tm.cache().Clear()
+			return
+		}
+	}
+	w.tm.cache().Invalidate(changed...)
+}
+
+func (w *watcher) scan() (map[string]fileState, error) {
+	var files = make(map[string]fileState)
+	var err = fs.WalkDir(w.tm.TEMPLATEFS, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		files[p] = fileState{modTime: info.ModTime(), size: info.Size()}
+		return nil
+	})
+	return files, err
+}
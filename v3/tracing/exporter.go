@@ -0,0 +1,51 @@
+package tracing
+
+import (
+	"context"
+	"sync"
+)
+
+// Exporter sends ended spans to a tracing backend.
+type Exporter interface {
+	// Export exports the spans, it may buffer them.
+	Export(ctx context.Context, spans []*Span) error
+
+	// Shutdown exports buffered spans, and stops the exporter.
+	Shutdown(ctx context.Context) error
+}
+
+// InMemoryExporter keeps exported spans in memory, for tests.
+type InMemoryExporter struct {
+	spans []*Span
+	mu    sync.Mutex
+}
+
+// NewInMemoryExporter returns an empty in-memory exporter.
+func NewInMemoryExporter() *InMemoryExporter {
+	return &InMemoryExporter{}
+}
+
+func (e *InMemoryExporter) Export(ctx context.Context, spans []*Span) error {
+	e.mu.Lock()
+	e.spans = append(e.spans, spans...)
+	e.mu.Unlock()
+	return nil
+}
+
+func (e *InMemoryExporter) Shutdown(ctx context.Context) error {
+	return nil
+}
+
+// Spans returns the exported spans, in the order they ended.
+func (e *InMemoryExporter) Spans() []*Span {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return append([]*Span(nil), e.spans...)
+}
+
+// Reset removes the exported spans.
+func (e *InMemoryExporter) Reset() {
+	e.mu.Lock()
+	e.spans = nil
+	e.mu.Unlock()
+}
@@ -0,0 +1,340 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Defaults of the OTLP exporter.
+var (
+	OTLP_ENDPOINT       = "http://localhost:4318/v1/traces"
+	OTLP_BATCH_SIZE     = 512
+	OTLP_QUEUE_SIZE     = 2048
+	OTLP_FLUSH_INTERVAL = 5 * time.Second
+	OTLP_TIMEOUT        = 10 * time.Second
+)
+
+// ErrQueueFull is returned by Export when spans were dropped, because the queue was full.
+var ErrQueueFull = errors.New("tracing: export queue is full, spans were dropped")
+
+// The instrumentation scope written to OTLP payloads.
+const scopeName = "github.com/Nigel2392/router/v3/tracing"
+
+// OTLPExporter sends spans to an OpenTelemetry collector, with OTLP/HTTP and JSON encoding.
+//
+// Spans are queued, and sent in the background when the batch is full and every flush interval.
+// The queued spans are sent on Shutdown.
+type OTLPExporter struct {
+	// URL of the traces endpoint, OTLP_ENDPOINT if empty.
+	Endpoint string
+
+	// Extra headers sent with every request, like an API key.
+	Headers map[string]string
+
+	// Value of the service.name resource attribute.
+	ServiceName string
+
+	// Extra resource attributes, like "service.version".
+	Resource map[string]any
+
+	// Client used to send the spans.
+	// If nil, a client with a timeout of OTLP_TIMEOUT is used.
+	Client *http.Client
+
+	// Spans per request, maximum number of queued spans, and time between flushes.
+	// The defaults are used when zero.
+	BatchSize     int
+	QueueSize     int
+	FlushInterval time.Duration
+
+	// Called with errors of background flushes.
+	OnError func(err error)
+
+	queue []*Span
+	start sync.Once
+	flush chan struct{}
+	stop  chan struct{}
+	done  chan struct{}
+	mu    sync.Mutex
+}
+
+// NewOTLPExporter returns an exporter for the service, sending to the endpoint, or OTLP_ENDPOINT if empty.
+func NewOTLPExporter(serviceName, endpoint string) *OTLPExporter {
+	return &OTLPExporter{ServiceName: serviceName, Endpoint: endpoint}
+}
+
+// Export adds the spans to the queue, it never sends them itself.
+//
+// If the batch is full, the background goroutine is woken up to send it.
+// If the queue is full, the spans which do not fit are dropped and ErrQueueFull is returned.
+func (e *OTLPExporter) Export(ctx context.Context, spans []*Span) error {
+	e.start.Do(e.run)
+	e.mu.Lock()
+	var free = e.queueSize() - len(e.queue)
+	var dropped = len(spans) > free
+	if dropped {
+		if free < 0 {
+			free = 0
+		}
+		spans = spans[:free]
+	}
+	e.queue = append(e.queue, spans...)
+	var full = len(e.queue) >= e.batchSize()
+	e.mu.Unlock()
+	if full {
+		select {
+		case e.flush <- struct{}{}:
+		default:
+		}
+	}
+	if dropped {
+		return ErrQueueFull
+	}
+	return nil
+}
+
+// Flush sends the queued spans.
+func (e *OTLPExporter) Flush(ctx context.Context) error {
+	e.mu.Lock()
+	var spans = e.queue
+	e.queue = nil
+	e.mu.Unlock()
+
+	var errs []error
+	for len(spans) > 0 {
+		var n = len(spans)
+		if n > e.batchSize() {
+			n = e.batchSize()
+		}
+		if err := e.send(ctx, spans[:n]); err != nil {
+			errs = append(errs, err)
+		}
+		spans = spans[n:]
+	}
+	return errors.Join(errs...)
+}
+
+// Shutdown stops the background flushes, and sends the queued spans.
+func (e *OTLPExporter) Shutdown(ctx context.Context) error {
+	e.start.Do(func() {})
+	e.mu.Lock()
+	if e.stop != nil {
+		close(e.stop)
+		e.stop = nil
+		e.mu.Unlock()
+		select {
+		case <-e.done:
+		case <-ctx.Done():
+		}
+	} else {
+		e.mu.Unlock()
+	}
+	return e.Flush(ctx)
+}
+
+// Flushes the queue every interval, and when a batch is full, until the exporter is shut down.
+func (e *OTLPExporter) run() {
+	var interval = e.FlushInterval
+	if interval == 0 {
+		interval = OTLP_FLUSH_INTERVAL
+	}
+	e.flush = make(chan struct{}, 1)
+	e.stop = make(chan struct{})
+	e.done = make(chan struct{})
+	go func(stop <-chan struct{}) {
+		defer close(e.done)
+		var ticker = time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+			case <-e.flush:
+			case <-stop:
+				return
+			}
+			var ctx, cancel = context.WithTimeout(context.Background(), e.timeout())
+			if err := e.Flush(ctx); err != nil && e.OnError != nil {
+				e.OnError(err)
+			}
+			cancel()
+		}
+	}(e.stop)
+}
+
+func (e *OTLPExporter) timeout() time.Duration {
+	if e.Client != nil && e.Client.Timeout > 0 {
+		return e.Client.Timeout
+	}
+	return OTLP_TIMEOUT
+}
+
+func (e *OTLPExporter) queueSize() int {
+	if e.QueueSize <= 0 {
+		return OTLP_QUEUE_SIZE
+	}
+	return e.QueueSize
+}
+
+func (e *OTLPExporter) batchSize() int {
+	if e.BatchSize <= 0 {
+		return OTLP_BATCH_SIZE
+	}
+	return e.BatchSize
+}
+
+func (e *OTLPExporter) send(ctx context.Context, spans []*Span) error {
+	var body, err = json.Marshal(e.payload(spans))
+	if err != nil {
+		return err
+	}
+	var endpoint = e.Endpoint
+	if endpoint == "" {
+		endpoint = OTLP_ENDPOINT
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range e.Headers {
+		req.Header.Set(k, v)
+	}
+	var client = e.Client
+	if client == nil {
+		client = &http.Client{Timeout: OTLP_TIMEOUT}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("tracing: export: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("tracing: export: %s", resp.Status)
+	}
+	return nil
+}
+
+// Types of the OTLP JSON encoding.
+type (
+	otlpPayload struct {
+		ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+	}
+	otlpResourceSpans struct {
+		Resource   otlpResource     `json:"resource"`
+		ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+	}
+	otlpResource struct {
+		Attributes []otlpKeyValue `json:"attributes"`
+	}
+	otlpScopeSpans struct {
+		Scope otlpScope  `json:"scope"`
+		Spans []otlpSpan `json:"spans"`
+	}
+	otlpScope struct {
+		Name string `json:"name"`
+	}
+	otlpSpan struct {
+		TraceID           string         `json:"traceId"`
+		SpanID            string         `json:"spanId"`
+		ParentSpanID      string         `json:"parentSpanId,omitempty"`
+		TraceState        string         `json:"traceState,omitempty"`
+		Name              string         `json:"name"`
+		Kind              SpanKind       `json:"kind"`
+		StartTimeUnixNano string         `json:"startTimeUnixNano"`
+		EndTimeUnixNano   string         `json:"endTimeUnixNano"`
+		Attributes        []otlpKeyValue `json:"attributes,omitempty"`
+		Events            []otlpEvent    `json:"events,omitempty"`
+		Status            otlpStatus     `json:"status"`
+	}
+	otlpEvent struct {
+		TimeUnixNano string         `json:"timeUnixNano"`
+		Name         string         `json:"name"`
+		Attributes   []otlpKeyValue `json:"attributes,omitempty"`
+	}
+	otlpStatus struct {
+		Code    StatusCode `json:"code,omitempty"`
+		Message string     `json:"message,omitempty"`
+	}
+	otlpKeyValue struct {
+		Key   string         `json:"key"`
+		Value map[string]any `json:"value"`
+	}
+)
+
+func (e *OTLPExporter) payload(spans []*Span) otlpPayload {
+	var resource = map[string]any{"service.name": e.ServiceName}
+	for k, v := range e.Resource {
+		resource[k] = v
+	}
+	var encoded = make([]otlpSpan, len(spans))
+	for i, span := range spans {
+		span.mu.Lock()
+		encoded[i] = otlpSpan{
+			TraceID:           span.SpanContext.TraceID.String(),
+			SpanID:            span.SpanContext.SpanID.String(),
+			TraceState:        span.SpanContext.TraceState,
+			Name:              span.Name,
+			Kind:              span.Kind,
+			StartTimeUnixNano: unixNano(span.Start),
+			EndTimeUnixNano:   unixNano(span.End),
+			Attributes:        otlpAttributes(span.Attributes),
+			Status:            otlpStatus{Code: span.Status, Message: span.StatusMessage},
+		}
+		if span.Parent.IsValid() {
+			encoded[i].ParentSpanID = span.Parent.String()
+		}
+		for _, event := range span.Events {
+			encoded[i].Events = append(encoded[i].Events, otlpEvent{
+				TimeUnixNano: unixNano(event.Time),
+				Name:         event.Name,
+				Attributes:   otlpAttributes(event.Attributes),
+			})
+		}
+		span.mu.Unlock()
+	}
+	return otlpPayload{ResourceSpans: []otlpResourceSpans{{
+		Resource:   otlpResource{Attributes: otlpAttributes(resource)},
+		ScopeSpans: []otlpScopeSpans{{Scope: otlpScope{Name: scopeName}, Spans: encoded}},
+	}}}
+}
+
+func unixNano(t time.Time) string {
+	return strconv.FormatInt(t.UnixNano(), 10)
+}
+
+// Encodes attributes as OTLP key values, 64 bit integers are encoded as strings.
+func otlpAttributes(attributes map[string]any) []otlpKeyValue {
+	var kvs = make([]otlpKeyValue, 0, len(attributes))
+	for k, v := range attributes {
+		var value = make(map[string]any, 1)
+		switch v := v.(type) {
+		case string:
+			value["stringValue"] = v
+		case bool:
+			value["boolValue"] = v
+		case int:
+			value["intValue"] = strconv.FormatInt(int64(v), 10)
+		case int64:
+			value["intValue"] = strconv.FormatInt(v, 10)
+		case float64:
+			value["doubleValue"] = v
+		default:
+			value["stringValue"] = fmt.Sprint(v)
+		}
+		kvs = append(kvs, otlpKeyValue{Key: k, Value: value})
+	}
+	sort.Slice(kvs, func(i, j int) bool {
+		return kvs[i].Key < kvs[j].Key
+	})
+	return kvs
+}
@@ -0,0 +1,112 @@
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Nigel2392/router/v3/client"
+)
+
+// A collector which blocks every request until it is released.
+type blockingCollector struct {
+	*httptest.Server
+	release chan struct{}
+	mu      sync.Mutex
+	spans   int
+}
+
+func newBlockingCollector(t *testing.T) *blockingCollector {
+	var c = &blockingCollector{release: make(chan struct{})}
+	c.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-c.release
+		var payload otlpPayload
+		json.NewDecoder(r.Body).Decode(&payload)
+		c.mu.Lock()
+		c.spans += len(payload.ResourceSpans[0].ScopeSpans[0].Spans)
+		c.mu.Unlock()
+	}))
+	t.Cleanup(c.Close)
+	return c
+}
+
+func (c *blockingCollector) received() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.spans
+}
+
+func testSpans(n int) []*Span {
+	var spans = make([]*Span, n)
+	for i := range spans {
+		spans[i] = &Span{Name: "span", Start: time.Now(), End: time.Now()}
+	}
+	return spans
+}
+
+func TestOTLPExportDoesNotSend(t *testing.T) {
+	var collector = newBlockingCollector(t)
+	var exporter = NewOTLPExporter("test", collector.URL)
+	exporter.BatchSize = 2
+	exporter.QueueSize = 4
+	exporter.FlushInterval = time.Hour
+
+	// The collector blocks, Export must return without waiting for it.
+	var done = make(chan error, 1)
+	go func() {
+		done <- exporter.Export(context.Background(), testSpans(2))
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Export blocked on the collector")
+	}
+
+	// Wait until the first batch is taken off the queue, then fill it up.
+	for deadline := time.Now().Add(time.Second); ; {
+		exporter.mu.Lock()
+		var queued = len(exporter.queue)
+		exporter.mu.Unlock()
+		if queued == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("the full batch was not sent in the background")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if err := exporter.Export(context.Background(), testSpans(3)); err != nil {
+		t.Fatal(err)
+	}
+	if err := exporter.Export(context.Background(), testSpans(2)); !errors.Is(err, ErrQueueFull) {
+		t.Fatalf("got error %v, want %v", err, ErrQueueFull)
+	}
+
+	close(collector.release)
+	if err := exporter.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if got := collector.received(); got != 6 {
+		t.Errorf("collector received %d spans, want 6", got)
+	}
+}
+
+func TestInjectHookIsOptIn(t *testing.T) {
+	if len(client.REQUEST_HOOKS) != 0 {
+		t.Fatal("importing tracing should not register client hooks")
+	}
+	var ctx, span = New(nil).Start(context.Background(), "span", SpanKindClient)
+	var rq = httptest.NewRequest("GET", "/", nil).WithContext(ctx)
+	InjectHook(rq)
+	if rq.Header.Get(HEADER_TRACEPARENT) != span.SpanContext.Traceparent() {
+		t.Errorf("got traceparent %q, want %q", rq.Header.Get(HEADER_TRACEPARENT), span.SpanContext.Traceparent())
+	}
+}
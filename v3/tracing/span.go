@@ -0,0 +1,259 @@
+package tracing
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// The version of traceparent headers which are written.
+const traceparentVersion = "00"
+
+// Flag of a span context, which is set if the trace is sampled.
+const FlagSampled byte = 0x01
+
+// TraceID identifies a trace.
+type TraceID [16]byte
+
+// IsValid reports whether the ID is not all zeroes.
+func (t TraceID) IsValid() bool {
+	return t != TraceID{}
+}
+
+func (t TraceID) String() string {
+	return hex.EncodeToString(t[:])
+}
+
+// SpanID identifies a span within a trace.
+type SpanID [8]byte
+
+// IsValid reports whether the ID is not all zeroes.
+func (s SpanID) IsValid() bool {
+	return s != SpanID{}
+}
+
+func (s SpanID) String() string {
+	return hex.EncodeToString(s[:])
+}
+
+func newTraceID() (id TraceID) {
+	for !id.IsValid() {
+		rand.Read(id[:])
+	}
+	return id
+}
+
+func newSpanID() (id SpanID) {
+	for !id.IsValid() {
+		rand.Read(id[:])
+	}
+	return id
+}
+
+// SpanContext is the part of a span which is propagated to other services.
+type SpanContext struct {
+	TraceID    TraceID
+	SpanID     SpanID
+	Flags      byte
+	TraceState string
+
+	// Remote is true if the span context was parsed from an incoming request.
+	Remote bool
+}
+
+// IsValid reports whether the trace and span IDs are valid.
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID.IsValid() && sc.SpanID.IsValid()
+}
+
+// IsSampled reports whether the sampled flag is set.
+func (sc SpanContext) IsSampled() bool {
+	return sc.Flags&FlagSampled != 0
+}
+
+// Traceparent returns the span context formatted as a W3C traceparent header.
+func (sc SpanContext) Traceparent() string {
+	return fmt.Sprintf("%s-%s-%s-%02x", traceparentVersion, sc.TraceID, sc.SpanID, sc.Flags)
+}
+
+// ParseTraceparent parses a W3C traceparent header, like
+// "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01".
+//
+// Headers of future versions are parsed as far as they are compatible with version 00.
+func ParseTraceparent(header string) (SpanContext, error) {
+	var sc SpanContext
+	header = strings.TrimSpace(header)
+	if len(header) < 55 || len(header) > 55 && (header[:2] == "00" || header[55] != '-') {
+		return sc, errors.New("tracing: invalid traceparent length")
+	}
+	var parts = strings.SplitN(header[:55], "-", 4)
+	if len(parts) != 4 || len(parts[0]) != 2 || len(parts[1]) != 32 || len(parts[2]) != 16 || len(parts[3]) != 2 {
+		return sc, errors.New("tracing: invalid traceparent format")
+	}
+	for _, part := range parts {
+		if strings.ToLower(part) != part {
+			return sc, errors.New("tracing: traceparent must be lowercase")
+		}
+	}
+	if parts[0] == "ff" {
+		return sc, errors.New("tracing: invalid traceparent version")
+	}
+	var version, flags [1]byte
+	if _, err := hex.Decode(version[:], []byte(parts[0])); err != nil {
+		return sc, fmt.Errorf("tracing: invalid traceparent version: %w", err)
+	}
+	if _, err := hex.Decode(sc.TraceID[:], []byte(parts[1])); err != nil {
+		return sc, fmt.Errorf("tracing: invalid trace ID: %w", err)
+	}
+	if _, err := hex.Decode(sc.SpanID[:], []byte(parts[2])); err != nil {
+		return sc, fmt.Errorf("tracing: invalid span ID: %w", err)
+	}
+	if _, err := hex.Decode(flags[:], []byte(parts[3])); err != nil {
+		return sc, fmt.Errorf("tracing: invalid trace flags: %w", err)
+	}
+	if !sc.IsValid() {
+		return SpanContext{}, errors.New("tracing: trace and span IDs must not be zero")
+	}
+	sc.Flags = flags[0]
+	sc.Remote = true
+	return sc, nil
+}
+
+// Maximum number of list members of a tracestate header.
+const maxTraceStateMembers = 32
+
+// Returns the tracestate header without empty members, or an empty string if it is invalid.
+func parseTraceState(header string) string {
+	var members = make([]string, 0)
+	for _, member := range strings.Split(header, ",") {
+		member = strings.TrimSpace(member)
+		if member == "" {
+			continue
+		}
+		var key, value, ok = strings.Cut(member, "=")
+		if !ok || key == "" || value == "" {
+			return ""
+		}
+		members = append(members, member)
+	}
+	if len(members) > maxTraceStateMembers {
+		return ""
+	}
+	return strings.Join(members, ",")
+}
+
+// SpanKind describes the relationship of a span to its parent and children.
+type SpanKind int
+
+const (
+	SpanKindInternal SpanKind = 1
+	SpanKindServer   SpanKind = 2
+	SpanKindClient   SpanKind = 3
+)
+
+// StatusCode of a span.
+type StatusCode int
+
+const (
+	StatusUnset StatusCode = 0
+	StatusOK    StatusCode = 1
+	StatusError StatusCode = 2
+)
+
+// Event is something that happened during a span, like an exception.
+type Event struct {
+	Name       string
+	Time       time.Time
+	Attributes map[string]any
+}
+
+// Span is a single operation within a trace, like serving a request.
+type Span struct {
+	Name          string
+	Kind          SpanKind
+	SpanContext   SpanContext
+	Parent        SpanID
+	Start         time.Time
+	End           time.Time
+	Attributes    map[string]any
+	Events        []Event
+	Status        StatusCode
+	StatusMessage string
+
+	tracer *Tracer
+	ended  bool
+	mu     sync.Mutex
+}
+
+// SetAttribute sets an attribute of the span, like "http.route".
+//
+// Once the span ended it is not changed anymore, it may be in the hands of the exporter.
+func (s *Span) SetAttribute(key string, value any) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.ended {
+		return
+	}
+	s.Attributes[key] = value
+}
+
+// SetStatus sets the status of the span.
+//
+// An error status is never overwritten by an OK status.
+func (s *Span) SetStatus(code StatusCode, message string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.setStatus(code, message)
+}
+
+func (s *Span) setStatus(code StatusCode, message string) {
+	if s.ended || s.Status == StatusError && code != StatusError {
+		return
+	}
+	s.Status = code
+	s.StatusMessage = message
+}
+
+// RecordError adds an exception event for the error, and sets the status of the span to error.
+//
+// Errors are not recorded after the span ended.
+func (s *Span) RecordError(err error) {
+	if err == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.ended {
+		return
+	}
+	s.Events = append(s.Events, Event{
+		Name: "exception",
+		Time: time.Now(),
+		Attributes: map[string]any{
+			"exception.type":    fmt.Sprintf("%T", err),
+			"exception.message": err.Error(),
+		},
+	})
+	s.setStatus(StatusError, err.Error())
+}
+
+// Finish ends the span, and exports it if it is sampled.
+//
+// Calling Finish more than once has no effect.
+func (s *Span) Finish() {
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	s.End = time.Now()
+	s.mu.Unlock()
+	if s.tracer != nil && s.SpanContext.IsSampled() {
+		s.tracer.export(s)
+	}
+}
@@ -0,0 +1,217 @@
+// Package tracing traces requests with spans, propagated with W3C Trace Context headers.
+//
+// Spans are exported with an Exporter, like the OTLP/HTTP JSON exporter,
+// or the in-memory exporter for tests.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/Nigel2392/router/v3"
+	"github.com/Nigel2392/router/v3/request"
+	"github.com/Nigel2392/router/v3/request/writer"
+)
+
+// Headers of the W3C Trace Context standard.
+const (
+	HEADER_TRACEPARENT = "traceparent"
+	HEADER_TRACESTATE  = "tracestate"
+)
+
+// InjectHook sets the trace context of the request's context on its headers.
+//
+// Register it to continue traces in outbound requests made with client.Client:
+//
+//	client.REQUEST_HOOKS = append(client.REQUEST_HOOKS, tracing.InjectHook)
+func InjectHook(r *http.Request) {
+	Inject(r.Context(), r.Header)
+}
+
+// Tracer starts spans, and exports them when they end.
+type Tracer struct {
+	// Exporter of ended spans.
+	Exporter Exporter
+
+	// Sample decides whether a new trace is sampled, every trace is sampled if nil.
+	// Traces continued from a request keep the sampling decision of the caller.
+	Sample func(id TraceID) bool
+
+	// Called when exporting fails, errors are ignored if nil.
+	OnError func(err error)
+}
+
+// New returns a tracer which exports spans to the exporter.
+func New(exporter Exporter) *Tracer {
+	return &Tracer{Exporter: exporter}
+}
+
+// Start starts a span, as child of the span in the context.
+//
+// The returned context contains the new span.
+func (t *Tracer) Start(ctx context.Context, name string, kind SpanKind) (context.Context, *Span) {
+	var parent = SpanContextFromContext(ctx)
+	var span = &Span{
+		Name:       name,
+		Kind:       kind,
+		Start:      time.Now(),
+		Attributes: make(map[string]any),
+		tracer:     t,
+	}
+	if parent.IsValid() {
+		span.SpanContext = SpanContext{TraceID: parent.TraceID, Flags: parent.Flags, TraceState: parent.TraceState}
+		span.Parent = parent.SpanID
+	} else {
+		span.SpanContext.TraceID = newTraceID()
+		if t.Sample == nil || t.Sample(span.SpanContext.TraceID) {
+			span.SpanContext.Flags = FlagSampled
+		}
+	}
+	span.SpanContext.SpanID = newSpanID()
+	return ContextWithSpan(ctx, span), span
+}
+
+func (t *Tracer) export(span *Span) {
+	if t.Exporter == nil {
+		return
+	}
+	if err := t.Exporter.Export(context.Background(), []*Span{span}); err != nil && t.OnError != nil {
+		t.OnError(err)
+	}
+}
+
+// Shutdown flushes and shuts down the exporter.
+func (t *Tracer) Shutdown(ctx context.Context) error {
+	if t.Exporter == nil {
+		return nil
+	}
+	return t.Exporter.Shutdown(ctx)
+}
+
+type contextKey struct{}
+
+// Remote span contexts are stored in the context as well, with their own key.
+type remoteKey struct{}
+
+// ContextWithSpan returns a context containing the span.
+func ContextWithSpan(ctx context.Context, span *Span) context.Context {
+	return context.WithValue(ctx, contextKey{}, span)
+}
+
+// SpanFromContext returns the span in the context, or nil.
+func SpanFromContext(ctx context.Context) *Span {
+	var span, _ = ctx.Value(contextKey{}).(*Span)
+	return span
+}
+
+// ContextWithRemoteSpanContext returns a context containing a span context received from another service.
+func ContextWithRemoteSpanContext(ctx context.Context, sc SpanContext) context.Context {
+	return context.WithValue(ctx, remoteKey{}, sc)
+}
+
+// SpanContextFromContext returns the span context of the span in the context,
+// or the remote span context if there is no span.
+func SpanContextFromContext(ctx context.Context) SpanContext {
+	if span := SpanFromContext(ctx); span != nil {
+		return span.SpanContext
+	}
+	var sc, _ = ctx.Value(remoteKey{}).(SpanContext)
+	return sc
+}
+
+// RecordError records the error on the span in the context, if there is one.
+func RecordError(ctx context.Context, err error) {
+	if span := SpanFromContext(ctx); span != nil {
+		span.RecordError(err)
+	}
+}
+
+// PanicHook records panics recovered by middleware.Recoverer on the request's span.
+//
+//	middleware.PANIC_HOOKS = append(middleware.PANIC_HOOKS, tracing.PanicHook)
+func PanicHook(r *request.Request, err error) {
+	RecordError(r.Context(), err)
+}
+
+// Extract returns a context containing the span context of the traceparent and tracestate headers.
+//
+// Invalid headers are ignored, and the context is returned as is.
+func Extract(ctx context.Context, header http.Header) context.Context {
+	var sc, err = ParseTraceparent(header.Get(HEADER_TRACEPARENT))
+	if err != nil {
+		return ctx
+	}
+	sc.TraceState = parseTraceState(header.Get(HEADER_TRACESTATE))
+	return ContextWithRemoteSpanContext(ctx, sc)
+}
+
+// Inject sets the traceparent and tracestate headers from the span context in the context.
+func Inject(ctx context.Context, header http.Header) {
+	var sc = SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return
+	}
+	header.Set(HEADER_TRACEPARENT, sc.Traceparent())
+	if sc.TraceState != "" {
+		header.Set(HEADER_TRACESTATE, sc.TraceState)
+	}
+}
+
+// Middleware starts a server span for every request, named after the method and the pattern of the matched route.
+//
+// The trace is continued from the traceparent and tracestate headers of the request,
+// and the span is set on the request's context, so outbound requests made with client.Client continue it
+// when InjectHook is registered.
+// The status code, errors passed to the request's error handler, and panics are recorded on the span.
+func (t *Tracer) Middleware() router.Middleware {
+	return func(next router.Handler) router.Handler {
+		return router.HandleFunc(func(r *request.Request) {
+			var name = r.Method() + " " + r.Route.Pattern
+			var ctx, span = t.Start(Extract(r.Context(), r.Request.Header), name, SpanKindServer)
+			r.Request = r.Request.WithContext(ctx)
+
+			span.SetAttribute("http.request.method", r.Method())
+			span.SetAttribute("http.route", r.Route.Pattern)
+			span.SetAttribute("url.path", r.Request.URL.Path)
+			span.SetAttribute("server.address", request.GetHost(r))
+			if ua := r.Request.UserAgent(); ua != "" {
+				span.SetAttribute("user_agent.original", ua)
+			}
+
+			var handleError = r.ErrorHandler
+			r.ErrorHandler = func(r *request.Request, err error) {
+				span.RecordError(err)
+				if handleError != nil {
+					handleError(r, err)
+				}
+			}
+
+			var rec = writer.NewStatusRecorder(r.Response)
+			r.Response = rec
+			defer func() {
+				if p := recover(); p != nil {
+					span.RecordError(panicError(p))
+					span.SetAttribute("http.response.status_code", http.StatusInternalServerError)
+					span.Finish()
+					panic(p)
+				}
+				var status = rec.Status()
+				span.SetAttribute("http.response.status_code", status)
+				if status >= 500 {
+					span.SetStatus(StatusError, http.StatusText(status))
+				}
+				span.Finish()
+			}()
+			next.ServeHTTP(r)
+		})
+	}
+}
+
+func panicError(p any) error {
+	if err, ok := p.(error); ok {
+		return fmt.Errorf("panic: %w", err)
+	}
+	return fmt.Errorf("panic: %v", p)
+}
@@ -0,0 +1,56 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Nigel2392/router/v3"
+	"github.com/Nigel2392/router/v3/middleware"
+	"github.com/Nigel2392/router/v3/request"
+)
+
+func TestSpanIsFrozenAfterFinish(t *testing.T) {
+	var _, span = New(nil).Start(context.Background(), "span", SpanKindServer)
+	span.Finish()
+	span.RecordError(errors.New("late"))
+	span.SetAttribute("late", true)
+	span.SetStatus(StatusError, "late")
+	if len(span.Events) != 0 || len(span.Attributes) != 0 || span.Status == StatusError {
+		t.Errorf("span was changed after it ended: %+v", span)
+	}
+}
+
+func TestPanicRecordedOnce(t *testing.T) {
+	var hooks = middleware.PANIC_HOOKS
+	middleware.PANIC_HOOKS = append(middleware.PANIC_HOOKS, PanicHook)
+	defer func() { middleware.PANIC_HOOKS = hooks }()
+
+	for _, recovererOutside := range []bool{false, true} {
+		var exporter = NewInMemoryExporter()
+		var tracer = New(exporter)
+		var r = router.NewRouter(true)
+		if recovererOutside {
+			r.Use(middleware.ProblemRecoverer(), tracer.Middleware())
+		} else {
+			r.Use(tracer.Middleware(), middleware.ProblemRecoverer())
+		}
+		r.Get("/panic", router.HandleFunc(func(r *request.Request) {
+			panic("boom")
+		}))
+		var w = httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("GET", "/panic", nil))
+		if w.Code != 500 {
+			t.Errorf("recoverer outside %v: got status %d, want 500", recovererOutside, w.Code)
+		}
+
+		var spans = exporter.Spans()
+		if len(spans) != 1 {
+			t.Fatalf("recoverer outside %v: got %d spans, want 1", recovererOutside, len(spans))
+		}
+		if len(spans[0].Events) != 1 || spans[0].Status != StatusError {
+			t.Errorf("recoverer outside %v: got %d events and status %v, want the panic recorded once", recovererOutside, len(spans[0].Events), spans[0].Status)
+		}
+	}
+}
@@ -0,0 +1,177 @@
+package router
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Nigel2392/router/v3/request/params"
+	"github.com/Nigel2392/routevars"
+)
+
+// VariableType matches the values of path variables with a custom type, like <<day:date>>.
+//
+// Requests with values which do not match the type do not match the route,
+// and thus result in a 404 Not Found instead of reaching the handler.
+type VariableType struct {
+	// Regex a value must match, it always matches a single path segment.
+	// If empty, any value matches.
+	Regex string
+
+	// Valid optionally reports whether a value is valid, after the regex matched.
+	Valid func(value string) bool
+
+	// A valid value, used by Validate to detect shadowed routes.
+	// Routes with variables without a sample are not checked.
+	Sample string
+}
+
+var variableTypes = struct {
+	types map[string]*variableType
+	mu    sync.RWMutex
+}{
+	types: map[string]*variableType{
+		"date": newVariableType(DateType("2006-01-02")),
+	},
+}
+
+// A registered variable type, with its compiled regex.
+type variableType struct {
+	VariableType
+	regex *regexp.Regexp
+}
+
+func newVariableType(typ VariableType) *variableType {
+	var pattern = typ.Regex
+	if pattern == "" {
+		pattern = "[^/]+"
+	}
+	return &variableType{VariableType: typ, regex: regexp.MustCompile("^(?:" + pattern + ")$")}
+}
+
+func (t *variableType) match(value string) bool {
+	if strings.Contains(value, "/") || !t.regex.MatchString(value) {
+		return false
+	}
+	return t.Valid == nil || t.Valid(value)
+}
+
+// RegisterType registers a custom type for path variables, like <<status:status>>.
+//
+// It panics if the name is a type of routevars, or if the regex does not compile.
+// Types should be registered before routes are matched.
+//
+//	router.RegisterType("status", router.EnumType("draft", "published"))
+//	r.Get("/posts/<<status:status>>", postsFunc)
+func RegisterType(name string, typ VariableType) {
+	if builtinTypes[name] || strings.HasPrefix(strings.ToLower(name), "raw(") {
+		panic(fmt.Sprintf("router: can not register the builtin type %q", name))
+	}
+	var t = newVariableType(typ)
+	variableTypes.mu.Lock()
+	variableTypes.types[name] = t
+	variableTypes.mu.Unlock()
+	compiledPaths.Range(func(key, value any) bool {
+		compiledPaths.Delete(key)
+		return true
+	})
+}
+
+// Returns the registered type with the name.
+func lookupType(name string) (*variableType, bool) {
+	variableTypes.mu.RLock()
+	defer variableTypes.mu.RUnlock()
+	var t, ok = variableTypes.types[name]
+	return t, ok
+}
+
+// DateType matches dates formatted with the layout, like "2006-01-02".
+//
+// The type "date" is registered by default, with the layout "2006-01-02".
+func DateType(layout string) VariableType {
+	return VariableType{
+		Valid: func(value string) bool {
+			var _, err = time.Parse(layout, value)
+			return err == nil
+		},
+		Sample: time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC).Format(layout),
+	}
+}
+
+// EnumType matches one of the values.
+func EnumType(values ...string) VariableType {
+	var quoted = make([]string, len(values))
+	for i, v := range values {
+		quoted[i] = regexp.QuoteMeta(v)
+	}
+	var typ = VariableType{Regex: strings.Join(quoted, "|")}
+	if len(values) > 0 {
+		typ.Sample = values[0]
+	}
+	return typ
+}
+
+// The types of routevars, which can not be registered.
+var builtinTypes = map[string]bool{
+	routevars.NameInt:      true,
+	routevars.NameString:   true,
+	routevars.NameSlug:     true,
+	routevars.NameUUID:     true,
+	routevars.NameAny:      true,
+	routevars.NameHex:      true,
+	routevars.NameAlphaNum: true,
+}
+
+// A path with variables of custom types.
+type compiledPath struct {
+	// The path, with the variables of custom types replaced by <<name:any>>.
+	path  routevars.URLFormatter
+	types map[string]*variableType
+}
+
+var compiledPaths sync.Map
+
+func compilePath(path routevars.URLFormatter) *compiledPath {
+	if c, ok := compiledPaths.Load(path); ok {
+		return c.(*compiledPath)
+	}
+	var c = &compiledPath{path: path}
+	var parts = strings.Split(string(path), "/")
+	for i, part := range parts {
+		var name, typ, ok = pathVariable(part)
+		if !ok {
+			continue
+		}
+		var t, custom = lookupType(typ)
+		if !custom {
+			continue
+		}
+		if c.types == nil {
+			c.types = make(map[string]*variableType)
+		}
+		c.types[name] = t
+		parts[i] = routevars.RT_PATH_VAR_PREFIX + name + routevars.RT_PATH_VAR_DELIM + routevars.NameAny + routevars.RT_PATH_VAR_SUFFIX
+	}
+	if c.types != nil {
+		c.path = routevars.URLFormatter(strings.Join(parts, "/"))
+	}
+	compiledPaths.Store(path, c)
+	return c
+}
+
+// Matches the path of the route, checking the values of variables with custom types.
+func (r *Route) matchPath(path string) (bool, params.URLParams) {
+	var c = compilePath(r.Path)
+	var ok, vars = c.path.Match(path)
+	if !ok {
+		return false, nil
+	}
+	for name, t := range c.types {
+		if !t.match(vars[name]) {
+			return false, nil
+		}
+	}
+	return true, vars
+}
@@ -0,0 +1,75 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/Nigel2392/router/v3/request"
+)
+
+func TestRegisteredTypes(t *testing.T) {
+	RegisterType("test_status", EnumType("draft", "published"))
+	RegisterType("test_even", VariableType{
+		Regex: "[0-9]+",
+		Valid: func(value string) bool {
+			var i, err = strconv.Atoi(value)
+			return err == nil && i%2 == 0
+		},
+		Sample: "2",
+	})
+
+	var r = NewRouter(true)
+	var handler = HandleFunc(func(r *request.Request) {
+		for _, name := range []string{"status", "day", "n"} {
+			if r.URLParams.Has(name) {
+				r.WriteString(r.URLParams.Get(name))
+			}
+		}
+	})
+	r.Get("/posts/<<status:test_status>>", handler)
+	r.Get("/archive/<<day:date>>", handler)
+	r.Get("/even/<<n:test_even>>", handler)
+
+	var tests = []struct {
+		path   string
+		status int
+		param  string
+	}{
+		{"/posts/draft", http.StatusOK, "draft"},
+		{"/posts/published", http.StatusOK, "published"},
+		{"/posts/deleted", http.StatusNotFound, ""},
+		{"/posts/draftx", http.StatusNotFound, ""},
+		{"/archive/2023-01-31", http.StatusOK, "2023-01-31"},
+		{"/archive/2023-02-31", http.StatusNotFound, ""},
+		{"/archive/yesterday", http.StatusNotFound, ""},
+		{"/even/4", http.StatusOK, "4"},
+		{"/even/3", http.StatusNotFound, ""},
+		{"/even/four", http.StatusNotFound, ""},
+	}
+	for _, test := range tests {
+		var w = httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("GET", test.path, nil))
+		if w.Code != test.status {
+			t.Errorf("GET %s: got status %d, want %d", test.path, w.Code, test.status)
+			continue
+		}
+		if w.Code == http.StatusOK && w.Body.String() != test.param {
+			t.Errorf("GET %s: got parameter %q", test.path, w.Body.String())
+		}
+	}
+}
+
+func TestRegisterBuiltinTypePanics(t *testing.T) {
+	for _, name := range []string{"int", "string", "raw(.*)"} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("registering %q should panic", name)
+				}
+			}()
+			RegisterType(name, VariableType{})
+		}()
+	}
+}
@@ -0,0 +1,325 @@
+package router
+
+import (
+	"fmt"
+	"log"
+	"regexp"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// The result of validating the routes, cached for a version of the router.
+type validation struct {
+	result atomic.Pointer[validationResult]
+	mu     sync.Mutex
+}
+
+type validationResult struct {
+	err     error
+	version uint64
+}
+
+// Returns the result of Validate for the router's current routes.
+//
+// The routes are only validated again after they changed,
+// errors are logged once for every version of the routes.
+func (r *Router) validated() error {
+	var version = r.version.Load()
+	if v := r.validation.result.Load(); v != nil && v.version == version {
+		return v.err
+	}
+	r.validation.mu.Lock()
+	defer r.validation.mu.Unlock()
+	if v := r.validation.result.Load(); v != nil && v.version == version {
+		return v.err
+	}
+	var err = r.Validate()
+	if err != nil {
+		log.Printf("router: %v", err)
+	}
+	r.validation.result.Store(&validationResult{err: err, version: version})
+	return err
+}
+
+// Kinds of issues reported by Validate.
+const (
+	// A route can never match, because an earlier route matches the same requests.
+	IssueShadowed = "shadowed"
+
+	// A child route can never match, because one of its parents matches the same requests.
+	IssueUnreachable = "unreachable"
+
+	// Two routes share the same full name, but not the same path.
+	IssueDuplicateName = "duplicate name"
+
+	// A route's path is not a valid routevars pattern.
+	IssueMalformedPattern = "malformed pattern"
+)
+
+// ValidationIssue is a single problem found by Validate.
+type ValidationIssue struct {
+	Kind    string
+	Message string
+	// The routes involved, the route with the issue first.
+	Routes []*Route
+}
+
+func (i ValidationIssue) String() string {
+	var b strings.Builder
+	b.WriteString(i.Kind + ": " + i.Message)
+	for _, route := range i.Routes {
+		b.WriteString("\n\t" + route.String())
+	}
+	return b.String()
+}
+
+// ValidationError lists every issue found by Validate.
+type ValidationError []ValidationIssue
+
+func (e ValidationError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "router: %d invalid route(s)", len(e))
+	for _, issue := range e {
+		b.WriteString("\n" + issue.String())
+	}
+	return b.String()
+}
+
+// Source returns the file and line where the route was registered.
+func (r *Route) Source() string {
+	return r.source
+}
+
+// String returns the method, path, name and source of the route.
+func (r *Route) String() string {
+	var method = r.Method
+	if method == "" {
+		method = "GROUP"
+	}
+	var s = method + " " + string(r.Path)
+	if name := r.FullName(); name != "" {
+		s += " (" + name + ")"
+	}
+	if r.source != "" {
+		s += " registered at " + r.source
+	}
+	return s
+}
+
+// Validate checks the routes for conflicts and malformed patterns.
+//
+// It reports routes which are shadowed by earlier routes,
+// children which are unreachable because of their parents,
+// routes which share a full name and malformed path patterns.
+//
+// The returned error is a ValidationError, or nil if there are no issues.
+func (r *Router) Validate() error {
+	var routes = make([]*Route, 0)
+	for _, route := range r.matchOrder() {
+		WalkRoutes(route, func(route *Route, depth int) {
+			routes = append(routes, route)
+		})
+	}
+
+	var issues ValidationError
+	for _, route := range routes {
+		if msg := route.patternError(); msg != "" {
+			issues = append(issues, ValidationIssue{Kind: IssueMalformedPattern, Message: msg, Routes: []*Route{route}})
+		}
+	}
+
+	// Routes are matched in this order, the first match wins.
+	for i, route := range routes {
+		if route.HandlerFunc == nil {
+			continue
+		}
+		var sample, ok = route.samplePath()
+		if !ok {
+			continue
+		}
+		for _, earlier := range routes[:i] {
+			if earlier.HandlerFunc == nil || !methodsOverlap(earlier.Method, route.Method) {
+				continue
+			}
+			// A route bound to a host only shadows routes of the same host,
+			// and a route with constraints may not match at all.
+			if host := earlier.Host(); host != "" && host != route.Host() || earlier.constrained() {
+				continue
+			}
+			if matched, _ := earlier.matchPath(sample); !matched {
+				continue
+			}
+			var issue = ValidationIssue{Kind: IssueShadowed, Routes: []*Route{route, earlier}}
+			issue.Message = fmt.Sprintf("%s %s is shadowed by an earlier route", route.Method, route.Path)
+			if route.isDescendantOf(earlier) {
+				issue.Kind = IssueUnreachable
+				issue.Message = fmt.Sprintf("%s %s is unreachable, its parent matches the same requests", route.Method, route.Path)
+			}
+			issues = append(issues, issue)
+			break
+		}
+	}
+
+	var names = make(map[string]*Route)
+	for _, route := range routes {
+		if route.name == "" || route.inheritedName {
+			// Unnamed, or the name was inherited from the parent.
+			continue
+		}
+		var name = route.FullName()
+		var first, ok = names[name]
+		if !ok {
+			names[name] = route
+			continue
+		}
+		if first.Path != route.Path || methodsOverlap(first.Method, route.Method) {
+			issues = append(issues, ValidationIssue{
+				Kind:    IssueDuplicateName,
+				Message: fmt.Sprintf("name %q is already used", name),
+				Routes:  []*Route{route, first},
+			})
+		}
+	}
+
+	if len(issues) == 0 {
+		return nil
+	}
+	return issues
+}
+
+// MustValidate is like Validate, but panics if there are issues.
+func (r *Router) MustValidate() {
+	if err := r.Validate(); err != nil {
+		panic(err)
+	}
+}
+
+// Returns a description of what is wrong with the route's path, or an empty string.
+func (r *Route) patternError() string {
+	var path = string(r.Path)
+	if path == "" {
+		if r.parent == nil && r.HandlerFunc != nil {
+			return "empty path"
+		}
+		return ""
+	}
+	if !strings.HasPrefix(path, "/") {
+		return fmt.Sprintf("path %q does not start with a slash", path)
+	}
+	if r.parent != nil {
+		var rest = strings.TrimPrefix(path, string(r.parent.Path))
+		if rest != "" && !strings.HasPrefix(rest, "/") && !strings.HasSuffix(string(r.parent.Path), "/") {
+			return fmt.Sprintf("path %q is not separated from its parent %q by a slash", rest, r.parent.Path)
+		}
+	}
+	if strings.Contains(path, "//") {
+		return fmt.Sprintf("path %q contains an empty segment", path)
+	}
+	var seen = make(map[string]bool)
+	for _, part := range strings.Split(path, "/") {
+		if !strings.Contains(part, "<<") && !strings.Contains(part, ">>") {
+			continue
+		}
+		var name, typ, ok = pathVariable(part)
+		if !ok || strings.Count(part, "<<") != 1 || strings.Count(part, ">>") != 1 {
+			return fmt.Sprintf("segment %q must be a single variable, like <<id:int>>", part)
+		}
+		if name == "" {
+			return fmt.Sprintf("segment %q has no variable name", part)
+		}
+		if seen[name] {
+			return fmt.Sprintf("variable %q is used more than once", name)
+		}
+		seen[name] = true
+		if msg := typeError(typ); msg != "" {
+			return fmt.Sprintf("variable %q: %s", name, msg)
+		}
+	}
+	return ""
+}
+
+var knownTypes = map[string]bool{
+	"int": true, "string": true, "slug": true, "uuid": true, "any": true, "hex": true, "alphanum": true,
+}
+
+func typeError(typ string) string {
+	var lower = strings.ToLower(typ)
+	if strings.HasPrefix(lower, "raw(") && strings.HasSuffix(lower, ")") {
+		if _, err := regexp.Compile(lower[4 : len(lower)-1]); err != nil {
+			return "invalid regex: " + err.Error()
+		}
+		return ""
+	}
+	if _, custom := lookupType(typ); !knownTypes[typ] && !custom {
+		return fmt.Sprintf("unknown type %q", typ)
+	}
+	return ""
+}
+
+// Sample values for each variable type, used to detect shadowed routes.
+var sampleValues = map[string]string{
+	"int":      "1",
+	"string":   "a",
+	"slug":     "a",
+	"uuid":     "00000000-0000-0000-0000-000000000000",
+	"any":      "a",
+	"hex":      "a",
+	"alphanum": "a",
+}
+
+// Returns a path which the route matches.
+//
+// Routes with raw regex variables have no sample path.
+func (r *Route) samplePath() (string, bool) {
+	var parts = strings.Split(string(r.Path), "/")
+	for i, part := range parts {
+		var _, typ, ok = pathVariable(part)
+		if !ok {
+			continue
+		}
+		var sample, known = sampleValues[typ]
+		if t, custom := lookupType(typ); custom && t.Sample != "" {
+			sample, known = t.Sample, true
+		}
+		if !known {
+			return "", false
+		}
+		parts[i] = sample
+	}
+	var path = strings.Join(parts, "/")
+	var matched, _ = r.matchPath(path)
+	return path, matched
+}
+
+func (r *Route) isDescendantOf(other *Route) bool {
+	for route := r.parent; route != nil; route = route.parent {
+		if route == other {
+			return true
+		}
+	}
+	return false
+}
+
+func methodsOverlap(a, b string) bool {
+	return matchesMethod(a, b) || matchesMethod(b, a)
+}
+
+// Returns the file and line of the first caller outside of this package.
+//
+// Tests of this package count as callers.
+func registeredAt() string {
+	var pcs [16]uintptr
+	var n = runtime.Callers(2, pcs[:])
+	var frames = runtime.CallersFrames(pcs[:n])
+	for {
+		var frame, more = frames.Next()
+		if !strings.HasPrefix(frame.Function, "github.com/Nigel2392/router/v3.") || strings.HasSuffix(frame.File, "_test.go") {
+			return fmt.Sprintf("%s:%d", frame.File, frame.Line)
+		}
+		if !more {
+			return ""
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package router
+
+import (
+	"bytes"
+	"log"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/Nigel2392/router/v3/request"
+)
+
+func TestValidateOnServeFailsWhileInvalid(t *testing.T) {
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	defer log.SetOutput(os.Stderr)
+
+	var r = NewRouter(true)
+	r.ValidateOnServe = true
+	r.Get("/a", HandleFunc(func(r *request.Request) {}), "page")
+	r.Get("/b", HandleFunc(func(r *request.Request) {}), "page")
+
+	var serve = func() []int {
+		var statuses []int
+		for i := 0; i < 3; i++ {
+			var w = httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest("GET", "/a", nil))
+			statuses = append(statuses, w.Code)
+		}
+		return statuses
+	}
+	if statuses := serve(); statuses[0] != 500 || statuses[1] != 500 || statuses[2] != 500 {
+		t.Errorf("got statuses %v, want [500 500 500]", statuses)
+	}
+	if n := strings.Count(logs.String(), "duplicate name"); n != 1 {
+		t.Errorf("the error was logged %d times, want 1", n)
+	}
+
+	// The routes are validated again after they changed.
+	r.Group("/c", "c")
+	if statuses := serve(); statuses[0] != 500 {
+		t.Errorf("got statuses %v, the routes are still invalid", statuses)
+	}
+	if n := strings.Count(logs.String(), "duplicate name"); n != 2 {
+		t.Errorf("the error was logged %d times after the routes changed, want 2", n)
+	}
+
+	var valid = NewRouter(true)
+	valid.ValidateOnServe = true
+	valid.Get("/a", HandleFunc(func(r *request.Request) {}), "page")
+	var w = httptest.NewRecorder()
+	valid.ServeHTTP(w, httptest.NewRequest("GET", "/a", nil))
+	if w.Code != 200 {
+		t.Errorf("got status %d for valid routes, want 200", w.Code)
+	}
+}
@@ -13,6 +13,7 @@ import (
 // Middleware is the function that is called when a route is matched
 type Middleware func(Handler) Handler
 
+// Deprecated: Return errors from a HandlerE, and set Router.ErrorHandler instead.
 type ErrorHandlerMiddleware func(error, *request.Request) Middleware
 
 // Handler is the interface that wraps the ServeHTTP method.
@@ -27,6 +28,17 @@ func (f HandleFunc) ServeHTTP(r *request.Request) {
 	f(r)
 }
 
+// HandlerE is a handler which can return an error.
+//
+// Returned errors are passed to the router's ErrorHandler.
+type HandlerE func(*request.Request) error
+
+func (f HandlerE) ServeHTTP(r *request.Request) {
+	if err := f(r); err != nil {
+		r.HandleError(err)
+	}
+}
+
 // Wrapper function for http.Handler to make it compatible with Handler
 type httpHandlerWrapper struct {
 	H http.Handler